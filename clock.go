@@ -0,0 +1,38 @@
+package slog
+
+import (
+	"time"
+)
+
+// Clock provides the time for entries.
+//
+// Override it with Logger.WithClock to produce
+// deterministic timestamps in tests and replay tools.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts a function into a Clock.
+type ClockFunc func() time.Time
+
+// Now calls f.
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// MonotonicClock returns a Clock that keeps the monotonic
+// clock reading in every time so that entry times can be
+// subtracted accurately even if the wall clock changes.
+//
+// Unlike the default clock, times are not converted to UTC
+// as that strips the monotonic reading.
+func MonotonicClock() Clock {
+	return ClockFunc(time.Now)
+}
+
+func (l Logger) now() time.Time {
+	if l.clock != nil {
+		return l.clock.Now()
+	}
+	return time.Now().UTC()
+}
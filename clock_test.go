@@ -0,0 +1,28 @@
+package slog_test
+
+import (
+	"testing"
+	"time"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+)
+
+func TestLogger_WithClock(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2000, time.February, 5, 4, 4, 4, 0, time.UTC)
+
+	s := &fakeSink{}
+	l := slog.Make(s).WithClock(slog.ClockFunc(func() time.Time {
+		return now
+	}))
+	l.Info(bg, "")
+	assert.Equal(t, "time", now, s.entries[0].Time)
+
+	l = l.WithClock(slog.MonotonicClock())
+	l.Info(bg, "")
+	l.Info(bg, "")
+	assert.True(t, "monotonic", s.entries[2].Time.Sub(s.entries[1].Time) >= 0)
+	assert.True(t, "monotonic reading", s.entries[1].Time.Round(0) != s.entries[1].Time)
+}
@@ -0,0 +1,86 @@
+package slog
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+var defaultLogger atomic.Value
+
+// Default returns the Logger set with SetDefault.
+//
+// Until SetDefault is called, the default Logger has no sinks
+// and so discards every entry.
+func Default() Logger {
+	l, _ := defaultLogger.Load().(Logger)
+	return l
+}
+
+// SetDefault sets the Logger used by the package level
+// logging functions.
+func SetDefault(l Logger) {
+	defaultLogger.Store(l)
+}
+
+// Debug logs the msg and fields at LevelDebug to the default Logger.
+func Debug(ctx context.Context, msg string, fields ...Field) {
+	l := Default()
+	l.skip++
+	l.Debug(ctx, msg, fields...)
+}
+
+// Info logs the msg and fields at LevelInfo to the default Logger.
+func Info(ctx context.Context, msg string, fields ...Field) {
+	l := Default()
+	l.skip++
+	l.Info(ctx, msg, fields...)
+}
+
+// Warn logs the msg and fields at LevelWarn to the default Logger.
+func Warn(ctx context.Context, msg string, fields ...Field) {
+	l := Default()
+	l.skip++
+	l.Warn(ctx, msg, fields...)
+}
+
+// There is no package level Error as slog.Error constructs
+// an error field. Use Default().Error instead.
+
+// Critical logs the msg and fields at LevelCritical to the default Logger.
+//
+// It will then Sync().
+func Critical(ctx context.Context, msg string, fields ...Field) {
+	l := Default()
+	l.skip++
+	l.Critical(ctx, msg, fields...)
+}
+
+// Fatal logs the msg and fields at LevelFatal to the default Logger.
+//
+// It will then Sync() and os.Exit(1).
+func Fatal(ctx context.Context, msg string, fields ...Field) {
+	l := Default()
+	l.skip++
+	l.Fatal(ctx, msg, fields...)
+}
+
+type loggerKey struct{}
+
+// WithLogger returns a context that contains the given Logger.
+//
+// Use FromContext to retrieve it, e.g. in a handler behind
+// HTTP middleware that sets a request scoped Logger.
+func WithLogger(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext returns the Logger in ctx set with WithLogger.
+//
+// If there is none, the default Logger is returned.
+func FromContext(ctx context.Context) Logger {
+	l, ok := ctx.Value(loggerKey{}).(Logger)
+	if !ok {
+		return Default()
+	}
+	return l
+}
@@ -0,0 +1,51 @@
+package slog_test
+
+import (
+	"testing"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+)
+
+// This test cannot be parallel as it modifies the default logger.
+func TestDefault(t *testing.T) {
+	prev := slog.Default()
+	t.Cleanup(func() {
+		slog.SetDefault(prev)
+	})
+
+	slog.Info(bg, "discarded")
+
+	s := &fakeSink{}
+	l := slog.Make(s).Leveled(slog.LevelDebug)
+	l.SetExit(func(int) {})
+	slog.SetDefault(l)
+
+	slog.Debug(bg, "")
+	slog.Info(bg, "")
+	slog.Warn(bg, "")
+	slog.Critical(bg, "")
+	slog.Fatal(bg, "")
+
+	assert.Len(t, "entries", 5, s.entries)
+	assert.Equal(t, "syncs", 2, s.syncs)
+	assert.Equal(t, "file", slogTestFile[:len(slogTestFile)-len("slog_test.go")]+"default_test.go", s.entries[0].File)
+	assert.Equal(t, "line", 24, s.entries[0].Line)
+	assert.Equal(t, "level", slog.LevelFatal, s.entries[4].Level)
+}
+
+func TestFromContext(t *testing.T) {
+	t.Parallel()
+
+	s := &fakeSink{}
+	l := slog.Make(s).With(slog.F("request_id", 7))
+	ctx := slog.WithLogger(bg, l)
+
+	slog.FromContext(ctx).Info(ctx, "hi")
+	assert.Len(t, "entries", 1, s.entries)
+	assert.Equal(t, "fields", slog.M(slog.F("request_id", 7)), s.entries[0].Fields)
+
+	// Falls back to the default logger which has no sinks.
+	slog.FromContext(bg).Info(bg, "hi")
+	assert.Len(t, "entries", 1, s.entries)
+}
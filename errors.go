@@ -0,0 +1,122 @@
+package slog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync/atomic"
+)
+
+type errorHandler struct {
+	fn func(error)
+}
+
+type fallbackSink struct {
+	s Sink
+}
+
+var (
+	onError  atomic.Value
+	fallback atomic.Value
+)
+
+// OnError sets the function called with errors reported by sinks,
+// e.g. when an entry could not be written.
+//
+// By default, errors are printed to stderr.
+// Pass nil to restore the default.
+func OnError(fn func(err error)) {
+	onError.Store(errorHandler{fn: fn})
+}
+
+// SetFallback sets the Sink that receives the entries
+// that sinks failed to log.
+//
+// By default there is no fallback and such entries are lost.
+// Pass nil to remove the fallback.
+func SetFallback(s Sink) {
+	fallback.Store(fallbackSink{s: s})
+}
+
+type fallbackKey struct{}
+
+type reportOnceKey struct{}
+
+// reportOnce is stored in the context passed to a wrapped
+// Sink by wrappers like WithTimeout that report the entry
+// themselves so that it is only reported once.
+type reportOnce struct {
+	done int32
+}
+
+func (o *reportOnce) claim() bool {
+	return atomic.CompareAndSwapInt32(&o.done, 0, 1)
+}
+
+// ReportError is called by sinks when they encounter an error.
+//
+// It passes err to the function set with OnError and logs
+// ents, the entries that could not be logged, to the fallback
+// Sink set with SetFallback.
+//
+// Entries that were already reported by a wrapping Sink such
+// as WithTimeout are not reported again.
+func ReportError(ctx context.Context, err error, ents ...SinkEntry) {
+	o, ok := ctx.Value(reportOnceKey{}).(*reportOnce)
+	if ok && len(ents) > 0 && !o.claim() {
+		return
+	}
+
+	h, _ := onError.Load().(errorHandler)
+	if h.fn != nil {
+		h.fn(err)
+	} else {
+		fmt.Fprintf(os.Stderr, "slog: %v\n", err)
+	}
+
+	fs, _ := fallback.Load().(fallbackSink)
+	if fs.s == nil || ctx.Value(fallbackKey{}) != nil {
+		// Errors from the fallback itself are not retried.
+		return
+	}
+	ctx = context.WithValue(ctx, fallbackKey{}, true)
+	for _, ent := range ents {
+		fs.s.LogEntry(ctx, ent)
+	}
+}
+
+// Recover returns a Sink that recovers panics from s.
+//
+// A panic is reported with ReportError along with the entry
+// being logged so that it reaches the fallback Sink.
+func Recover(s Sink) Sink {
+	return recoverSink{s: s}
+}
+
+type recoverSink struct {
+	s Sink
+}
+
+func (s recoverSink) LogEntry(ctx context.Context, e SinkEntry) {
+	defer func() {
+		r := recover()
+		if r != nil {
+			ReportError(ctx, fmt.Errorf("sink panicked while logging entry: %v", r), e)
+		}
+	}()
+	s.s.LogEntry(ctx, e)
+}
+
+func (s recoverSink) Sync() {
+	defer func() {
+		r := recover()
+		if r != nil {
+			ReportError(context.Background(), fmt.Errorf("sink panicked while syncing: %v", r))
+		}
+	}()
+	s.s.Sync()
+}
+
+func (s recoverSink) Close() error {
+	return closeSinks([]Sink{s.s})
+}
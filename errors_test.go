@@ -0,0 +1,84 @@
+package slog_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+	"cdr.dev/slog/sloggers/slogjson"
+)
+
+type errWriter struct{}
+
+func (errWriter) Write([]byte) (int, error) {
+	return 0, xerrors.New("connection refused")
+}
+
+// This test cannot be parallel as it modifies global state.
+func TestReportError(t *testing.T) {
+	t.Cleanup(func() {
+		slog.OnError(nil)
+		slog.SetFallback(nil)
+	})
+
+	var errs []error
+	slog.OnError(func(err error) {
+		errs = append(errs, err)
+	})
+	fallback := &fakeSink{}
+	slog.SetFallback(fallback)
+
+	l := slog.Make(slogjson.Sink(errWriter{}))
+	l.Info(bg, "lost")
+
+	assert.Len(t, "errors", 1, errs)
+	assert.Len(t, "fallback entries", 1, fallback.entries)
+	assert.Equal(t, "msg", "lost", fallback.entries[0].Message)
+
+	// Errors from the fallback are not retried.
+	slog.SetFallback(slogjson.Sink(errWriter{}))
+	l.Info(bg, "lost")
+	assert.Len(t, "errors", 3, errs)
+
+	slog.OnError(nil)
+	slog.SetFallback(nil)
+	b := &bytes.Buffer{}
+	slog.Make(slogjson.Sink(b)).Info(bg, "ok")
+	assert.True(t, "written", b.Len() > 0)
+}
+
+type panicSink struct{}
+
+func (panicSink) LogEntry(context.Context, slog.SinkEntry) {
+	panic("bad sink")
+}
+
+func (panicSink) Sync() {
+	panic("bad sink")
+}
+
+// This test cannot be parallel as it modifies global state.
+func TestRecover(t *testing.T) {
+	t.Cleanup(func() {
+		slog.OnError(nil)
+		slog.SetFallback(nil)
+	})
+
+	var errs []error
+	slog.OnError(func(err error) {
+		errs = append(errs, err)
+	})
+	fallback := &fakeSink{}
+	slog.SetFallback(fallback)
+
+	l := slog.Make(slog.Recover(panicSink{}))
+	l.Error(bg, "hi")
+
+	assert.Len(t, "errors", 2, errs)
+	assert.Equal(t, "error", "sink panicked while logging entry: bad sink", errs[0].Error())
+	assert.Len(t, "fallback entries", 1, fallback.entries)
+}
@@ -142,3 +142,19 @@ func ExampleLogger_Leveled() {
 	// 2019-12-07 21:26:20.945 [INFO]	<example_test.go:95>	received request
 	// 2019-12-07 21:26:20.945 [DEBUG]	<example_test.go:99>	testing2
 }
+
+func Example_sloghumanOptions() {
+	ctx := context.Background()
+
+	l := slog.Make(sloghuman.Sink(os.Stdout,
+		sloghuman.WithLayout(sloghuman.PartLevel, sloghuman.PartName, sloghuman.PartMessage, sloghuman.PartFields),
+		sloghuman.WithAlignedColumns(8, 0),
+		sloghuman.WithColor(sloghuman.ColorNever),
+	))
+	l.Info(ctx, "starting")
+	l.Named("db").Warn(ctx, "slow query", slog.F("took", time.Second))
+
+	// Output:
+	// [INFO]    	        	starting
+	// [WARN]    	(db)    	slow query	{"took": "1s"}
+}
@@ -0,0 +1,145 @@
+package slog
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// fieldKind is the type of a value stored inline in a Field
+// by one of the typed constructors.
+type fieldKind uint8
+
+const (
+	// kindAny means the value is stored in Field.Value.
+	kindAny fieldKind = iota
+	kindString
+	kindInt
+	kindInt64
+	kindUint64
+	kindFloat64
+	kindBool
+	kindDuration
+	kindTimeUTC
+	kindTimeLocal
+)
+
+// String is a typed constructor for a string Field.
+//
+// The typed constructors document the type of a field and store
+// the value inline so that the field is created and encoded
+// without allocating or reflection.
+func String(name string, value string) Field {
+	return Field{Name: name, kind: kindString, str: value}
+}
+
+// Int is a typed constructor for an int Field.
+func Int(name string, value int) Field {
+	return Field{Name: name, kind: kindInt, num: uint64(value)}
+}
+
+// Int64 is a typed constructor for an int64 Field.
+func Int64(name string, value int64) Field {
+	return Field{Name: name, kind: kindInt64, num: uint64(value)}
+}
+
+// Uint64 is a typed constructor for a uint64 Field.
+func Uint64(name string, value uint64) Field {
+	return Field{Name: name, kind: kindUint64, num: value}
+}
+
+// Float64 is a typed constructor for a float64 Field.
+func Float64(name string, value float64) Field {
+	return Field{Name: name, kind: kindFloat64, num: math.Float64bits(value)}
+}
+
+// Bool is a typed constructor for a bool Field.
+func Bool(name string, value bool) Field {
+	var n uint64
+	if value {
+		n = 1
+	}
+	return Field{Name: name, kind: kindBool, num: n}
+}
+
+// Duration is a typed constructor for a time.Duration Field.
+//
+// It is encoded as a string like "1.5s".
+func Duration(name string, value time.Duration) Field {
+	return Field{Name: name, kind: kindDuration, num: uint64(value)}
+}
+
+// Time is a typed constructor for a time.Time Field.
+//
+// Only times in UTC or time.Local between the years 1678 and 2261
+// are stored inline. Other times are stored in Value.
+func Time(name string, value time.Time) Field {
+	if y := value.Year(); y > 1677 && y < 2262 {
+		switch value.Location() {
+		case time.UTC:
+			return Field{Name: name, kind: kindTimeUTC, num: uint64(value.UnixNano())}
+		case time.Local:
+			return Field{Name: name, kind: kindTimeLocal, num: uint64(value.UnixNano())}
+		}
+	}
+	return F(name, value)
+}
+
+// Any returns the value of f.
+//
+// It is Value for fields created with F and the boxed
+// inline value for fields created with a typed constructor.
+func (f Field) Any() interface{} {
+	switch f.kind {
+	case kindString:
+		return f.str
+	case kindInt:
+		return int(f.num)
+	case kindInt64:
+		return int64(f.num)
+	case kindUint64:
+		return f.num
+	case kindFloat64:
+		return math.Float64frombits(f.num)
+	case kindBool:
+		return f.num != 0
+	case kindDuration:
+		return time.Duration(f.num)
+	case kindTimeUTC:
+		return time.Unix(0, int64(f.num)).UTC()
+	case kindTimeLocal:
+		return time.Unix(0, int64(f.num))
+	}
+	return f.Value
+}
+
+// WithFields returns a Sink that prepends the given fields
+// to every entry before passing it to s.
+//
+// Use it for static metadata like the service name or version
+// that should appear on every entry regardless of which Logger
+// wrote it.
+func WithFields(s Sink, fields ...Field) Sink {
+	return fieldsSink{
+		s:      s,
+		fields: M(fields...),
+	}
+}
+
+type fieldsSink struct {
+	s      Sink
+	fields Map
+}
+
+func (s fieldsSink) LogEntry(ctx context.Context, e SinkEntry) {
+	e.Fields = s.fields.append(e.Fields)
+	s.s.LogEntry(ctx, e)
+}
+
+func (s fieldsSink) Sync() {
+	s.s.Sync()
+}
+
+func (s fieldsSink) Close() error {
+	return closeSinks([]Sink{s.s})
+}
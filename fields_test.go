@@ -0,0 +1,167 @@
+package slog_test
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+)
+
+func TestTypedFields(t *testing.T) {
+	t.Parallel()
+
+	act := marshalJSON(t, slog.M(
+		slog.String("string", "hi"),
+		slog.Int("int", -3),
+		slog.Int64("int64", math.MinInt64),
+		slog.Uint64("uint64", math.MaxUint64),
+		slog.Float64("float64", 1.5),
+		slog.Bool("bool", true),
+		slog.Duration("duration", time.Second+time.Millisecond*500),
+		slog.Time("time", time.Date(2000, time.February, 5, 4, 4, 4, 4, time.UTC)),
+	))
+	exp := indentJSON(t, `{
+		"string": "hi",
+		"int": -3,
+		"int64": -9223372036854775808,
+		"uint64": 18446744073709551615,
+		"float64": 1.5,
+		"bool": true,
+		"duration": "1.5s",
+		"time": "2000-02-05T04:04:04.000000004Z"
+	}`)
+	assert.Equal(t, "JSON", exp, act)
+}
+
+func TestTypedFields_any(t *testing.T) {
+	t.Parallel()
+
+	tm := time.Date(2000, time.February, 5, 4, 4, 4, 4, time.UTC)
+	fields := slog.M(
+		slog.String("string", "hi"),
+		slog.Int("int", -3),
+		slog.Int64("int64", math.MinInt64),
+		slog.Uint64("uint64", math.MaxUint64),
+		slog.Float64("float64", 1.5),
+		slog.Bool("bool", true),
+		slog.Duration("duration", time.Second),
+		slog.Time("time", tm),
+		slog.Time("local", tm.Local()),
+		slog.Time("zero", time.Time{}),
+		slog.Err(io.EOF),
+	)
+	exp := []interface{}{
+		"hi",
+		-3,
+		int64(math.MinInt64),
+		uint64(math.MaxUint64),
+		1.5,
+		true,
+		time.Second,
+		tm,
+		tm.Local(),
+		time.Time{},
+		io.EOF,
+	}
+	for i, f := range fields {
+		assert.Equal(t, f.Name, exp[i], f.Any())
+	}
+
+	assert.Equal(t, "local JSON", marshalJSON(t, slog.M(slog.F("local", tm.Local()))), marshalJSON(t, slog.M(fields[8])))
+	assert.Equal(t, "Err JSON", `{"error":"EOF"}`, string(slog.M(slog.Err(io.EOF)).AppendJSON(nil)))
+}
+
+func TestTypedFields_allocs(t *testing.T) {
+	b := make([]byte, 0, 1024)
+	tm := time.Date(2000, time.February, 5, 4, 4, 4, 4, time.UTC)
+	n := testing.AllocsPerRun(100, func() {
+		fields := [...]slog.Field{
+			slog.String("string", "hi"),
+			slog.Int("int", -3),
+			slog.Int64("int64", math.MinInt64),
+			slog.Uint64("uint64", math.MaxUint64),
+			slog.Float64("float64", 1.5),
+			slog.Bool("bool", true),
+			slog.Duration("duration", time.Second+time.Millisecond*500),
+			slog.Time("time", tm),
+		}
+		b = slog.Map(fields[:]).AppendJSON(b[:0])
+	})
+	assert.Equal(t, "allocs", 0.0, n)
+}
+
+// TestFastPaths ensures the fast paths match encoding/json.
+func TestFastPaths(t *testing.T) {
+	t.Parallel()
+
+	values := []interface{}{
+		"",
+		"<html> & \"quotes\" \\ \n\r\t\b\f\x00\x1f",
+		"   héllo 世界",
+		"\xff invalid utf8",
+		float32(3.14),
+		float32(1e-7),
+		1e21,
+		1e-7,
+		-0.0,
+		123456789.123,
+		int8(-8),
+		uint16(16),
+		time.Date(1999, time.December, 31, 23, 59, 59, 0, time.FixedZone("", -3600)),
+	}
+
+	for _, v := range values {
+		exp, err := json.Marshal(v)
+		assert.Success(t, "marshal", err)
+
+		act, err := json.Marshal(slog.M(slog.F("v", v)))
+		assert.Success(t, "marshal", err)
+
+		assert.Equal(t, "JSON", `{"v":`+string(exp)+`}`, string(act))
+	}
+}
+
+func TestWithFields(t *testing.T) {
+	t.Parallel()
+
+	s := &fakeSink{}
+	l := slog.Make(slog.WithFields(s, slog.F("service", "api"), slog.F("version", "abc")))
+	l.With(slog.F("a", 1)).Info(bg, "hi", slog.F("b", 2))
+	l.Sync()
+
+	assert.Equal(t, "fields", slog.M(
+		slog.F("service", "api"),
+		slog.F("version", "abc"),
+		slog.F("a", 1),
+		slog.F("b", 2),
+	), s.entries[0].Fields)
+	assert.Equal(t, "syncs", 1, s.syncs)
+}
+
+func TestMap_unsupportedValues(t *testing.T) {
+	t.Parallel()
+
+	b := slog.M(
+		slog.F("nan", math.NaN()),
+		slog.F("inf", math.Inf(1)),
+		slog.F("-inf", float32(math.Inf(-1))),
+		slog.F("nested", []float64{1, math.NaN()}),
+		slog.F("chan", (chan int)(nil)),
+		slog.F("complex", complex(1, 2)),
+	).AppendJSON(nil)
+	assert.Equal(t, "JSON", `{"nan":"NaN","inf":"+Inf","-inf":"-Inf","nested":[1,"NaN"],"chan":"\u003cnil\u003e","complex":"(1+2i)"}`, string(b))
+}
+
+func TestMap_jsonNumber(t *testing.T) {
+	t.Parallel()
+
+	b := slog.M(
+		slog.F("n", json.Number("1.5")),
+		slog.F("nested", []json.Number{"1"}),
+	).AppendJSON(nil)
+	assert.Equal(t, "JSON", `{"n":1.5,"nested":[1]}`, string(b))
+}
@@ -0,0 +1,69 @@
+package slog
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// NameFilter returns a Sink that filters entries by their
+// logger names before passing them to s.
+//
+// The logger names of an entry are joined with "." and matched
+// against the glob patterns with path.Match. For example,
+// "comp.poller.*" matches every logger below "comp.poller".
+//
+// If allow is not empty, only entries matching one of its
+// patterns are passed on. Entries matching any pattern in
+// deny are dropped.
+//
+// It panics if a pattern is malformed.
+func NameFilter(s Sink, allow, deny []string) Sink {
+	for _, p := range append(append([]string(nil), allow...), deny...) {
+		_, err := path.Match(p, "")
+		if err != nil {
+			panic(fmt.Sprintf("slog: invalid name pattern %q: %v", p, err))
+		}
+	}
+	return nameFilterSink{
+		s:     s,
+		allow: append([]string(nil), allow...),
+		deny:  append([]string(nil), deny...),
+	}
+}
+
+type nameFilterSink struct {
+	s     Sink
+	allow []string
+	deny  []string
+}
+
+func (s nameFilterSink) LogEntry(ctx context.Context, e SinkEntry) {
+	name := strings.Join(e.LoggerNames, ".")
+	if len(s.allow) > 0 && !matchName(s.allow, name) {
+		return
+	}
+	if matchName(s.deny, name) {
+		return
+	}
+	s.s.LogEntry(ctx, e)
+}
+
+func (s nameFilterSink) Sync() {
+	s.s.Sync()
+}
+
+func (s nameFilterSink) Close() error {
+	return closeSinks([]Sink{s.s})
+}
+
+func matchName(patterns []string, name string) bool {
+	for _, p := range patterns {
+		ok, _ := path.Match(p, name)
+		if ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package slog_test
+
+import (
+	"testing"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+)
+
+func TestNameFilter(t *testing.T) {
+	t.Parallel()
+
+	s := &fakeSink{}
+	l := slog.Make(slog.NameFilter(s, []string{"comp", "comp.*"}, []string{"comp.poller.*"}))
+	comp := l.Named("comp")
+
+	l.Info(bg, "root")
+	comp.Info(bg, "comp")
+	comp.Named("db").Info(bg, "db")
+	comp.Named("poller").Info(bg, "poller")
+	comp.Named("poller").Named("tick").Info(bg, "tick")
+	l.Named("other").Info(bg, "other")
+
+	assert.Len(t, "entries", 3, s.entries)
+	assert.Equal(t, "msg", "comp", s.entries[0].Message)
+	assert.Equal(t, "msg", "db", s.entries[1].Message)
+	assert.Equal(t, "msg", "poller", s.entries[2].Message)
+
+	s = &fakeSink{}
+	l = slog.Make(slog.NameFilter(s, nil, []string{"comp.poller*"}))
+	l.Info(bg, "root")
+	l.Named("comp").Named("poller").Info(bg, "poller")
+	assert.Len(t, "entries", 1, s.entries)
+
+	defer func() {
+		assert.True(t, "panicked", recover() != nil)
+	}()
+	slog.NameFilter(s, []string{"["}, nil)
+}
@@ -7,8 +7,12 @@ require (
 	github.com/alecthomas/chroma v0.9.4
 	github.com/fatih/color v1.13.0
 	github.com/google/go-cmp v0.5.6
+	github.com/mattn/go-colorable v0.1.9
 	go.opencensus.io v0.23.0
 	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9
+	golang.org/x/sys v0.0.0-20211124211545-fe61309f8881
 	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1
 	google.golang.org/genproto v0.0.0-20211206160659-862468c7d6e0
+	google.golang.org/grpc v1.40.0
+	google.golang.org/protobuf v1.27.1
 )
@@ -0,0 +1,82 @@
+// Package callerpath trims the file paths of callers.
+package callerpath
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Format is how a caller's file path is formatted.
+type Format int
+
+// The formats.
+const (
+	// Full is the path as recorded by the runtime.
+	Full Format = iota
+	// Base is only the file name, e.g. main.go.
+	Base
+	// Short is the last two path components, e.g. cmd/main.go.
+	Short
+	// Module is the path relative to the root of the module
+	// that contains the file, e.g. internal/cmd/main.go. Paths
+	// outside of a module are formatted like Short.
+	Module
+)
+
+// Trim formats file with f.
+func Trim(file string, f Format) string {
+	switch f {
+	case Base:
+		return path.Base(file)
+	case Short:
+		return short(file)
+	case Module:
+		root, ok := moduleRoot(path.Dir(file))
+		if !ok {
+			return short(file)
+		}
+		return strings.TrimPrefix(file, root+"/")
+	default:
+		return file
+	}
+}
+
+func short(file string) string {
+	i := strings.LastIndexByte(file, '/')
+	if i < 0 {
+		return file
+	}
+	j := strings.LastIndexByte(file[:i], '/')
+	return file[j+1:]
+}
+
+// roots caches the module root of every directory.
+var roots sync.Map // map[string]string
+
+// moduleRoot returns the closest parent of dir, or dir itself,
+// that contains a go.mod.
+func moduleRoot(dir string) (string, bool) {
+	if root, ok := roots.Load(dir); ok {
+		root := root.(string)
+		return root, root != ""
+	}
+
+	var root string
+	for d := dir; ; {
+		_, err := os.Stat(filepath.FromSlash(d + "/go.mod"))
+		if err == nil {
+			root = d
+			break
+		}
+		parent := path.Dir(d)
+		if parent == d || parent == "." {
+			break
+		}
+		d = parent
+	}
+	roots.Store(dir, root)
+	return root, root != ""
+}
@@ -0,0 +1,37 @@
+// Package detach detaches a context from its parent's cancellation.
+package detach
+
+import (
+	"context"
+	"time"
+)
+
+// Context returns a context with the values of ctx that is
+// never canceled and has no deadline.
+//
+// Sinks that write entries after LogEntry has returned use it so
+// that entries logged with a request's context are not dropped
+// once the request completes.
+func Context(ctx context.Context) context.Context {
+	return detachedContext{ctx}
+}
+
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+// +build !windows
+
+package entryhuman
+
+import (
+	"io"
+)
+
+// ConsoleWriter returns w as terminals outside of
+// Windows support ANSI escapes natively.
+func ConsoleWriter(w io.Writer) io.Writer {
+	return w
+}
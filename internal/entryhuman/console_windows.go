@@ -0,0 +1,48 @@
+package entryhuman
+
+import (
+	"io"
+	"os"
+
+	"github.com/mattn/go-colorable"
+	"golang.org/x/sys/windows"
+)
+
+// ConsoleWriter returns a writer that displays colors correctly
+// if w is a Windows console.
+//
+// Virtual terminal processing is enabled on consoles that support
+// it so ANSI escapes work natively. Older consoles get a writer
+// that translates the escapes into console API calls.
+func ConsoleWriter(w io.Writer) io.Writer {
+	f, ok := w.(*os.File)
+	if !ok || !isTTY(f) {
+		return w
+	}
+
+	h := windows.Handle(f.Fd())
+	var mode uint32
+	err := windows.GetConsoleMode(h, &mode)
+	if err != nil {
+		return w
+	}
+	if mode&windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING != 0 {
+		return w
+	}
+	err = windows.SetConsoleMode(h, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING)
+	if err == nil {
+		return w
+	}
+	return consoleWriter{
+		Writer: colorable.NewColorable(f),
+	}
+}
+
+type consoleWriter struct {
+	io.Writer
+}
+
+// Sync is a no-op as consoles are not buffered.
+func (w consoleWriter) Sync() error {
+	return nil
+}
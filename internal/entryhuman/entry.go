@@ -8,10 +8,12 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/fatih/color"
 	"go.opencensus.io/trace"
@@ -33,13 +35,155 @@ func StripTimestamp(ent string) (time.Time, string, error) {
 // TimeFormat is a simplified RFC3339 format.
 const TimeFormat = "2006-01-02 15:04:05.000"
 
-func c(w io.Writer, attrs ...color.Attribute) *color.Color {
-	c := color.New(attrs...)
-	c.DisableColor()
-	if shouldColor(w) {
-		c.EnableColor()
+// Options configures FmtWith.
+// The zero value formats entries like Fmt.
+type Options struct {
+	// Theme holds the colors to use when w is colored.
+	// Defaults to DefaultTheme.
+	Theme *Theme
+
+	// Layout is the order of the parts of every line.
+	// Parts that are not in it are not formatted.
+	// Defaults to DefaultLayout.
+	Layout []Part
+
+	// Time formats the time of an entry. If it returns ""
+	// the time is omitted. Defaults to formatting with TimeFormat.
+	Time func(t time.Time) string
+
+	// Pretty formats the fields as an indented block
+	// under the message instead of inline JSON.
+	Pretty bool
+
+	// LevelWidth, NameWidth and CallerWidth are the minimum widths
+	// the level, logger name and caller are padded to with spaces
+	// so that consecutive lines align. If NameWidth is set, entries
+	// without a name are padded too.
+	LevelWidth  int
+	NameWidth   int
+	CallerWidth int
+
+	// Color controls whether the entry is colored.
+	// Defaults to ColorAuto.
+	Color ColorMode
+
+	// Caller formats the file of the caller.
+	// Defaults to the base name of the file.
+	Caller func(file string) string
+
+	// Level formats the level of an entry.
+	// Defaults to the name of the level in brackets, e.g. [INFO].
+	Level func(level slog.Level) string
+
+	// RichErrors formats errors with frames, like those created
+	// with xerrors.Errorf, as an indented cause chain with the
+	// function and location of every frame instead of with %+v.
+	RichErrors bool
+
+	// DimDebug renders entries below LevelInfo in a faint
+	// style when colored so they do not compete with the rest.
+	DimDebug bool
+}
+
+// Part is a part of a formatted entry.
+type Part int
+
+// The parts of a formatted entry.
+const (
+	PartTime Part = iota
+	PartLevel
+	PartName
+	PartCaller
+	PartFunc
+	PartMessage
+	PartFields
+)
+
+// DefaultLayout is the layout used by Fmt.
+// It does not include PartFunc.
+var DefaultLayout = []Part{
+	PartTime,
+	PartLevel,
+	PartName,
+	PartCaller,
+	PartMessage,
+	PartFields,
+}
+
+func hasPart(layout []Part, p Part) bool {
+	for _, p2 := range layout {
+		if p2 == p {
+			return true
+		}
+	}
+	return false
+}
+
+type formatter struct {
+	color  bool
+	theme  *Theme
+	time   func(t time.Time) string
+	caller func(file string) string
+	level  func(level slog.Level) string
+	pretty bool
+	rich   bool
+	dim    bool
+
+	levelWidth  int
+	nameWidth   int
+	callerWidth int
+}
+
+func newFormatter(w io.Writer, opts Options) formatter {
+	f := formatter{
+		color:  ShouldColor(w, opts.Color),
+		theme:  opts.Theme,
+		time:   opts.Time,
+		caller: opts.Caller,
+		level:  opts.Level,
+		pretty: opts.Pretty,
+		rich:   opts.RichErrors,
+		dim:    opts.DimDebug,
+
+		levelWidth:  opts.LevelWidth,
+		nameWidth:   opts.NameWidth,
+		callerWidth: opts.CallerWidth,
+	}
+	if f.theme == nil {
+		f.theme = &DefaultTheme
+	}
+	if f.level == nil {
+		f.level = func(level slog.Level) string {
+			return "[" + level.String() + "]"
+		}
+	}
+	if f.caller == nil {
+		f.caller = filepath.Base
+	}
+	if f.time == nil {
+		f.time = func(t time.Time) string {
+			return t.Format(TimeFormat)
+		}
 	}
-	return c
+	return f
+}
+
+// paint colors s with attrs if the writer is colored.
+func (f formatter) paint(attrs []color.Attribute, s string) string {
+	if !f.color {
+		return s
+	}
+	return paint(attrs, s)
+}
+
+// paintPad is like paint but pads s with spaces to width runes.
+// The padding is not colored.
+func (f formatter) paintPad(attrs []color.Attribute, s string, width int) string {
+	n := width - utf8.RuneCountInString(s)
+	if n <= 0 {
+		return f.paint(attrs, s)
+	}
+	return f.paint(attrs, s) + strings.Repeat(" ", n)
 }
 
 // Fmt returns a human readable format for ent.
@@ -50,34 +194,26 @@ func c(w io.Writer, attrs ...color.Attribute) *color.Color {
 // for extra lines in a log so if we did it here, the fields would be indented
 // twice in test logs. So the Stderr logger indents all the fields itself.
 func Fmt(w io.Writer, ent slog.SinkEntry) string {
-	var ents string
-	ts := ent.Time.Format(TimeFormat)
-	ents += ts + " "
-
-	level := "[" + ent.Level.String() + "]"
-	level = c(w, levelColor(ent.Level)).Sprint(level)
-	ents += fmt.Sprintf("%v\t", level)
+	return FmtWith(w, ent, Options{})
+}
 
-	if len(ent.LoggerNames) > 0 {
-		loggerName := "(" + quoteKey(strings.Join(ent.LoggerNames, ".")) + ")"
-		loggerName = c(w, color.FgMagenta).Sprint(loggerName)
-		ents += fmt.Sprintf("%v\t", loggerName)
+// FmtWith is like Fmt but formats ent with opts.
+func FmtWith(w io.Writer, ent slog.SinkEntry, opts Options) string {
+	f := newFormatter(w, opts)
+	layout := opts.Layout
+	if layout == nil {
+		layout = DefaultLayout
 	}
 
-	loc := fmt.Sprintf("<%v:%v>", filepath.Base(ent.File), ent.Line)
-	loc = c(w, color.FgCyan).Sprint(loc)
-	ents += fmt.Sprintf("%v\t", loc)
-
 	var multilineKey string
 	var multilineVal string
 	msg := strings.TrimSpace(ent.Message)
-	if strings.Contains(msg, "\n") {
+	if hasPart(layout, PartMessage) && strings.Contains(msg, "\n") {
 		multilineKey = "msg"
 		multilineVal = msg
 		msg = "..."
 	}
 	msg = quote(msg)
-	ents += msg
 
 	if ent.SpanContext != (trace.SpanContext{}) {
 		ent.Fields = append(slog.M(
@@ -86,17 +222,17 @@ func Fmt(w io.Writer, ent slog.SinkEntry) string {
 		), ent.Fields...)
 	}
 
-	for i, f := range ent.Fields {
-		if multilineVal != "" {
+	for i, field := range ent.Fields {
+		if multilineVal != "" || f.pretty || !hasPart(layout, PartFields) {
 			break
 		}
 
 		var s string
-		switch v := f.Value.(type) {
+		switch v := field.Any().(type) {
 		case string:
 			s = v
 		case error, xerrors.Formatter:
-			s = fmt.Sprintf("%+v", v)
+			s = f.fmtErrorValue(v)
 		}
 		s = strings.TrimSpace(s)
 		if !strings.Contains(s, "\n") {
@@ -105,21 +241,32 @@ func Fmt(w io.Writer, ent slog.SinkEntry) string {
 
 		// Remove this field.
 		ent.Fields = append(ent.Fields[:i], ent.Fields[i+1:]...)
-		multilineKey = f.Name
+		multilineKey = field.Name
 		multilineVal = s
 	}
 
-	if len(ent.Fields) > 0 {
-		// No error is guaranteed due to slog.Map handling errors itself.
-		fields, _ := json.MarshalIndent(ent.Fields, "", "")
-		fields = bytes.ReplaceAll(fields, []byte(",\n"), []byte(", "))
-		fields = bytes.ReplaceAll(fields, []byte("\n"), []byte(""))
-		fields = formatJSON(w, fields)
-		ents += "\t" + string(fields)
+	var ents string
+	prev := Part(-1)
+	for _, p := range layout {
+		s := f.fmtPart(p, ent, msg)
+		if s == "" {
+			continue
+		}
+		if ents != "" {
+			// The time is followed by a space to keep it
+			// visually attached to the level.
+			if prev == PartTime {
+				ents += " "
+			} else {
+				ents += "\t"
+			}
+		}
+		ents += s
+		prev = p
 	}
 
 	if multilineVal != "" {
-		if msg != "..." {
+		if msg != "..." && !f.pretty {
 			ents += " ..."
 		}
 
@@ -132,25 +279,82 @@ func Fmt(w io.Writer, ent slog.SinkEntry) string {
 		}
 		multilineVal = strings.Join(lines, "\n")
 
-		multilineKey = c(w, color.FgBlue).Sprintf(`"%v"`, multilineKey)
+		multilineKey = f.paint(f.theme.Key, `"`+multilineKey+`"`)
 		ents += fmt.Sprintf("\n%v: %v", multilineKey, multilineVal)
 	}
 
+	if f.pretty && len(ent.Fields) > 0 && hasPart(layout, PartFields) {
+		ents += "\n" + f.fmtBlock(ent.Fields)
+	}
+
+	if f.color && f.dim && ent.Level < slog.LevelInfo {
+		ents = dim(ents)
+	}
+
 	return ents
 }
 
-func levelColor(level slog.Level) color.Attribute {
-	switch level {
-	case slog.LevelDebug:
-		return color.Reset
-	case slog.LevelInfo:
-		return color.FgBlue
-	case slog.LevelWarn:
-		return color.FgYellow
-	case slog.LevelError:
-		return color.FgRed
+// dim renders s in a faint style. The style is reapplied
+// after every reset of the colored parts within s.
+func dim(s string) string {
+	const faint = "\x1b[2m"
+	const reset = "\x1b[0m"
+	return faint + strings.ReplaceAll(s, reset, reset+faint) + reset
+}
+
+// fmtErrorValue formats an error field.
+func (f formatter) fmtErrorValue(v interface{}) string {
+	if f.rich {
+		if s, ok := f.fmtError(v); ok {
+			return s
+		}
+	}
+	return fmt.Sprintf("%+v", v)
+}
+
+// fmtPart returns the formatted part p of ent or
+// "" if the entry doesn't have it.
+func (f formatter) fmtPart(p Part, ent slog.SinkEntry, msg string) string {
+	switch p {
+	case PartTime:
+		ts := f.time(ent.Time)
+		if ts == "" {
+			return ""
+		}
+		return f.paint(f.theme.Time, ts)
+	case PartLevel:
+		level := f.level(ent.Level)
+		return f.paintPad(f.theme.level(ent.Level), level, f.levelWidth)
+	case PartName:
+		if len(ent.LoggerNames) == 0 {
+			return strings.Repeat(" ", f.nameWidth)
+		}
+		loggerName := "(" + quoteKey(strings.Join(ent.LoggerNames, ".")) + ")"
+		return f.paintPad(f.theme.Name, loggerName, f.nameWidth)
+	case PartCaller:
+		loc := fmt.Sprintf("<%v:%v>", f.caller(ent.File), ent.Line)
+		return f.paintPad(f.theme.Caller, loc, f.callerWidth)
+	case PartFunc:
+		if ent.Func == "" {
+			return ""
+		}
+		return f.paint(f.theme.Caller, path.Base(ent.Func))
+	case PartMessage:
+		return msg
+	case PartFields:
+		if len(ent.Fields) == 0 || f.pretty {
+			return ""
+		}
+		// No error is guaranteed due to slog.Map handling errors itself.
+		fields, _ := json.MarshalIndent(ent.Fields, "", "")
+		fields = bytes.ReplaceAll(fields, []byte(",\n"), []byte(", "))
+		fields = bytes.ReplaceAll(fields, []byte("\n"), []byte(""))
+		if f.color {
+			fields = colorizeJSON(fields, f.theme)
+		}
+		return string(fields)
 	default:
-		return color.FgHiRed
+		return ""
 	}
 }
 
@@ -167,8 +371,42 @@ func isTTY(w io.Writer) bool {
 	return ok && terminal.IsTerminal(int(f.Fd()))
 }
 
-func shouldColor(w io.Writer) bool {
-	return isTTY(w) || os.Getenv("FORCE_COLOR") != ""
+// ColorMode controls whether output is colored.
+type ColorMode int
+
+// The color modes.
+const (
+	// ColorAuto colors output written to a TTY. The NO_COLOR
+	// environment variable disables color and FORCE_COLOR
+	// enables it regardless of the writer. FORCE_COLOR takes
+	// precedence and can be set to 0 or false to disable color.
+	ColorAuto ColorMode = iota
+	// ColorAlways always colors output.
+	ColorAlways
+	// ColorNever never colors output.
+	ColorNever
+)
+
+// ShouldColor reports whether output written to w should be colored.
+func ShouldColor(w io.Writer, mode ColorMode) bool {
+	switch mode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+
+	if force, ok := os.LookupEnv("FORCE_COLOR"); ok {
+		switch strings.ToLower(force) {
+		case "0", "false":
+			return false
+		}
+		return true
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return isTTY(w)
 }
 
 // quotes quotes a string so that it is suitable
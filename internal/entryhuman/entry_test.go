@@ -1,10 +1,14 @@
 package entryhuman_test
 
 import (
+	"bytes"
+	"io"
 	"io/ioutil"
+	"os"
 	"testing"
 	"time"
 
+	"github.com/fatih/color"
 	"go.opencensus.io/trace"
 
 	"cdr.dev/slog"
@@ -91,4 +95,91 @@ func TestEntry(t *testing.T) {
 		})
 		assert.Equal(t, "entry", "0001-01-01 00:00:00.000 \x1b[91m[CRITICAL]\x1b[0m\t\x1b[36m<.:0>\x1b[0m\t\"\"\t{\x1b[34m\"hey\"\x1b[0m: \x1b[32m\"hi\"\x1b[0m}", act)
 	})
+
+	t.Run("theme", func(t *testing.T) {
+		t.Parallel()
+
+		act := entryhuman.FmtWith(entryhuman.ForceColorWriter, slog.SinkEntry{
+			Level: slog.LevelInfo,
+			Fields: slog.M(
+				slog.F("a", []interface{}{1.5, true, "x\"y"}),
+			),
+		}, entryhuman.Options{
+			Theme: &entryhuman.Theme{
+				Time:    []color.Attribute{color.Faint},
+				Info:    []color.Attribute{color.FgGreen},
+				Key:     []color.Attribute{color.FgRed},
+				Number:  []color.Attribute{color.FgYellow},
+				Keyword: []color.Attribute{color.Bold},
+			},
+		})
+		assert.Equal(t, "entry", "\x1b[2m0001-01-01 00:00:00.000\x1b[0m \x1b[32m[INFO]\x1b[0m\t<.:0>\t\"\"\t{\x1b[31m\"a\"\x1b[0m: [\x1b[33m1.5\x1b[0m, \x1b[1mtrue\x1b[0m, \"x\\\"y\"]}", act)
+	})
+}
+
+func TestConsoleWriter(t *testing.T) {
+	t.Parallel()
+
+	b := &bytes.Buffer{}
+	assert.True(t, "same writer", entryhuman.ConsoleWriter(b) == io.Writer(b))
+}
+
+func TestShouldColor(t *testing.T) {
+	setenv := func(key, val string, ok bool) func() {
+		old, oldOK := os.LookupEnv(key)
+		if ok {
+			os.Setenv(key, val)
+		} else {
+			os.Unsetenv(key)
+		}
+		return func() {
+			if oldOK {
+				os.Setenv(key, old)
+			} else {
+				os.Unsetenv(key)
+			}
+		}
+	}
+	defer setenv("FORCE_COLOR", "", false)()
+	defer setenv("NO_COLOR", "", false)()
+
+	b := &bytes.Buffer{}
+	assert.False(t, "auto", entryhuman.ShouldColor(b, entryhuman.ColorAuto))
+	assert.True(t, "tty", entryhuman.ShouldColor(entryhuman.ForceColorWriter, entryhuman.ColorAuto))
+	assert.True(t, "always", entryhuman.ShouldColor(b, entryhuman.ColorAlways))
+
+	defer setenv("NO_COLOR", "1", true)()
+	assert.False(t, "NO_COLOR", entryhuman.ShouldColor(entryhuman.ForceColorWriter, entryhuman.ColorAuto))
+	assert.True(t, "always", entryhuman.ShouldColor(b, entryhuman.ColorAlways))
+
+	defer setenv("FORCE_COLOR", "1", true)()
+	assert.True(t, "FORCE_COLOR", entryhuman.ShouldColor(b, entryhuman.ColorAuto))
+	assert.False(t, "never", entryhuman.ShouldColor(b, entryhuman.ColorNever))
+
+	defer setenv("FORCE_COLOR", "0", true)()
+	assert.False(t, "FORCE_COLOR=0", entryhuman.ShouldColor(entryhuman.ForceColorWriter, entryhuman.ColorAuto))
+}
+
+func TestColorizeJSON(t *testing.T) {
+	t.Parallel()
+
+	theme := &entryhuman.Theme{
+		Key:     []color.Attribute{color.FgRed},
+		String:  []color.Attribute{color.FgGreen},
+		Number:  []color.Attribute{color.FgYellow},
+		Keyword: []color.Attribute{color.FgBlue},
+	}
+	paint := func(code, s string) string {
+		return "\x1b[" + code + "m" + s + "\x1b[0m"
+	}
+
+	act := entryhuman.ColorizeJSON([]byte(`{"k\"ey": "a \"quoted\" \\", "n": -1.5e+10, "l": [true, null, 0]}`), theme)
+	exp := "{" + paint("31", `"k\"ey"`) + ": " + paint("32", `"a \"quoted\" \\"`) +
+		", " + paint("31", `"n"`) + ": " + paint("33", "-1.5e+10") +
+		", " + paint("31", `"l"`) + ": [" + paint("34", "true") + ", " + paint("34", "null") + ", " + paint("33", "0") + "]}"
+	assert.Equal(t, "JSON", exp, string(act))
+
+	// Parts without a color are left as is.
+	act = entryhuman.ColorizeJSON([]byte(`{"a":1}`), &entryhuman.Theme{})
+	assert.Equal(t, "JSON", `{"a":1}`, string(act))
 }
@@ -0,0 +1,46 @@
+package entryhuman
+
+import (
+	"strings"
+
+	"cdr.dev/slog/internal/errorchain"
+)
+
+// fmtError formats v as an indented cause chain with the function
+// and location of every frame. ok is false if the chain has no
+// frames in which case v should be formatted with %+v.
+//
+//	failed to run
+//	  main.run
+//	    main.go:42
+//	caused by: unexpected EOF
+func (f formatter) fmtError(v interface{}) (s string, ok bool) {
+	err, isErr := v.(error)
+	if !isErr {
+		return "", false
+	}
+	causes := errorchain.Causes(err)
+
+	var lines []string
+	for i, c := range causes {
+		msg := f.paint(f.theme.Error, c.Msg)
+		if i > 0 {
+			msg = f.paint(f.theme.Key, "caused by:") + " " + msg
+		}
+		lines = append(lines, msg)
+
+		if c.Func != "" {
+			ok = true
+			lines = append(lines, "  "+f.paint(f.theme.Name, c.Func))
+		}
+		if c.Loc != "" {
+			ok = true
+			loc := c.Loc
+			if i := strings.LastIndexByte(loc, ':'); i > 0 {
+				loc = f.caller(loc[:i]) + loc[i:]
+			}
+			lines = append(lines, "    "+f.paint(f.theme.Caller, loc))
+		}
+	}
+	return strings.Join(lines, "\n"), ok
+}
@@ -1,3 +1,5 @@
 package entryhuman
 
 var ForceColorWriter = forceColorWriter
+
+var ColorizeJSON = colorizeJSON
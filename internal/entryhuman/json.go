@@ -1,42 +1,63 @@
 package entryhuman
 
 import (
-	"bytes"
 	"io"
 
 	"github.com/alecthomas/chroma"
-	"github.com/alecthomas/chroma/formatters"
 	jlexers "github.com/alecthomas/chroma/lexers/j"
+	"github.com/fatih/color"
 )
 
-// Adapted from https://github.com/alecthomas/chroma/blob/2f5349aa18927368dbec6f8c11608bf61c38b2dd/styles/bw.go#L7
-// https://github.com/alecthomas/chroma/blob/2f5349aa18927368dbec6f8c11608bf61c38b2dd/formatters/tty_indexed.go
-// https://github.com/alecthomas/chroma/blob/2f5349aa18927368dbec6f8c11608bf61c38b2dd/lexers/j/json.go
-var style = chroma.MustNewStyle("slog", chroma.StyleEntries{
-	// Magenta.
-	chroma.Keyword: "#7f007f",
-	// Magenta.
-	chroma.Number: "#7f007f",
-	// Magenta.
-	chroma.Name: "#00007f",
-	// Green.
-	chroma.String: "#007f00",
-})
+// FormatJSON colorizes the JSON in buf if ShouldColor
+// reports that w should be colored in mode.
+func FormatJSON(w io.Writer, buf []byte, mode ColorMode) []byte {
+	if !ShouldColor(w, mode) {
+		return buf
+	}
+
+	return colorizeJSON(buf, &DefaultTheme)
+}
 
 var jsonLexer = chroma.Coalesce(jlexers.JSON)
 
-func formatJSON(w io.Writer, buf []byte) []byte {
-	if !shouldColor(w) {
+// colorizeJSON colors the keys, strings, numbers and
+// keywords of the JSON in buf with the theme.
+//
+// chroma styles hold RGB colors while a Theme holds ANSI
+// attributes so the tokens are painted directly instead
+// of going through a chroma formatter.
+func colorizeJSON(buf []byte, t *Theme) []byte {
+	it, err := jsonLexer.Tokenise(nil, string(buf))
+	if err != nil {
 		return buf
 	}
 
-	highlighted, _ := colorizeJSON(buf)
-	return highlighted
+	b := make([]byte, 0, len(buf)*2)
+	for tok := it(); tok != chroma.EOF; tok = it() {
+		b = append(b, paint(tokenColor(t, tok.Type), tok.Value)...)
+	}
+	return b
 }
 
-func colorizeJSON(buf []byte) ([]byte, error) {
-	it, _ := jsonLexer.Tokenise(nil, string(buf))
-	b := &bytes.Buffer{}
-	formatters.TTY8.Format(b, style, it)
-	return b.Bytes(), nil
+func tokenColor(t *Theme, typ chroma.TokenType) []color.Attribute {
+	switch {
+	case typ.InCategory(chroma.Name):
+		return t.Key
+	case typ.InSubCategory(chroma.String):
+		return t.String
+	case typ.InSubCategory(chroma.Number):
+		return t.Number
+	case typ.InCategory(chroma.Keyword):
+		return t.Keyword
+	}
+	return nil
+}
+
+func paint(attrs []color.Attribute, s string) string {
+	if len(attrs) == 0 {
+		return s
+	}
+	c := color.New(attrs...)
+	c.EnableColor()
+	return c.Sprint(s)
 }
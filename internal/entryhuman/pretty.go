@@ -0,0 +1,95 @@
+package entryhuman
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/entryjson"
+)
+
+// fmtBlock formats fields as an indented YAML like block.
+// Errors are formatted with %+v and multiline strings are
+// written verbatim so that stack traces stay readable.
+func (f formatter) fmtBlock(fields slog.Map) string {
+	m := make(slog.Map, len(fields))
+	for i, field := range fields {
+		switch v := field.Any().(type) {
+		case error, xerrors.Formatter:
+			field = slog.F(field.Name, f.fmtErrorValue(v))
+		}
+		m[i] = field
+	}
+
+	// No error is guaranteed due to slog.Map handling errors itself.
+	b, _ := json.Marshal(m)
+	v, err := entryjson.Decode(b)
+	if err != nil {
+		return string(b)
+	}
+	return strings.Join(f.appendBlock(nil, "", v), "\n")
+}
+
+func (f formatter) appendBlock(lines []string, indent string, v interface{}) []string {
+	switch v := v.(type) {
+	case entryjson.Object:
+		for _, field := range v {
+			key := f.paint(f.theme.Key, quote(field.Key))
+			lines = f.appendBlockValue(lines, indent, key+":", field.Value)
+		}
+	case []interface{}:
+		for _, el := range v {
+			lines = f.appendBlockValue(lines, indent, "-", el)
+		}
+	}
+	return lines
+}
+
+// appendBlockValue appends v prefixed with prefix, either on the
+// same line if it is a scalar or indented on the following lines.
+func (f formatter) appendBlockValue(lines []string, indent, prefix string, v interface{}) []string {
+	switch v := v.(type) {
+	case entryjson.Object:
+		if len(v) > 0 {
+			lines = append(lines, indent+prefix)
+			return f.appendBlock(lines, indent+"  ", v)
+		}
+	case []interface{}:
+		if len(v) > 0 {
+			lines = append(lines, indent+prefix)
+			return f.appendBlock(lines, indent+"  ", v)
+		}
+	case string:
+		if strings.Contains(v, "\n") {
+			lines = append(lines, indent+prefix+" |")
+			for _, line := range strings.Split(strings.TrimSpace(v), "\n") {
+				if line != "" {
+					line = indent + "  " + line
+				}
+				lines = append(lines, line)
+			}
+			return lines
+		}
+	}
+	return append(lines, indent+prefix+" "+f.fmtScalar(v))
+}
+
+func (f formatter) fmtScalar(v interface{}) string {
+	switch v := v.(type) {
+	case entryjson.Object:
+		return "{}"
+	case []interface{}:
+		return "[]"
+	case string:
+		return f.paint(f.theme.String, quote(v))
+	case json.Number:
+		return f.paint(f.theme.Number, v.String())
+	case bool:
+		return f.paint(f.theme.Keyword, fmt.Sprint(v))
+	default:
+		return f.paint(f.theme.Keyword, "null")
+	}
+}
@@ -0,0 +1,61 @@
+package entryhuman
+
+import (
+	"github.com/fatih/color"
+
+	"cdr.dev/slog"
+)
+
+// Theme holds the colors of every part of an entry.
+// A nil color leaves the part uncolored.
+type Theme struct {
+	Time []color.Attribute
+
+	Debug []color.Attribute
+	Info  []color.Attribute
+	Warn  []color.Attribute
+	Error []color.Attribute
+	// Critical is also used for LevelFatal.
+	Critical []color.Attribute
+
+	Name   []color.Attribute
+	Caller []color.Attribute
+
+	// The colors of the JSON fields.
+	Key     []color.Attribute
+	String  []color.Attribute
+	Number  []color.Attribute
+	Keyword []color.Attribute
+}
+
+// DefaultTheme is for terminals with a dark background.
+var DefaultTheme = Theme{
+	Debug:    []color.Attribute{color.Reset},
+	Info:     []color.Attribute{color.FgBlue},
+	Warn:     []color.Attribute{color.FgYellow},
+	Error:    []color.Attribute{color.FgRed},
+	Critical: []color.Attribute{color.FgHiRed},
+
+	Name:   []color.Attribute{color.FgMagenta},
+	Caller: []color.Attribute{color.FgCyan},
+
+	Key:     []color.Attribute{color.FgBlue},
+	String:  []color.Attribute{color.FgGreen},
+	Number:  []color.Attribute{color.FgMagenta},
+	Keyword: []color.Attribute{color.FgMagenta},
+}
+
+func (t *Theme) level(level slog.Level) []color.Attribute {
+	switch {
+	case level < slog.LevelInfo:
+		return t.Debug
+	case level < slog.LevelWarn:
+		return t.Info
+	case level < slog.LevelError:
+		return t.Warn
+	case level < slog.LevelCritical:
+		return t.Error
+	default:
+		return t.Critical
+	}
+}
@@ -0,0 +1,120 @@
+package entryjson
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"cdr.dev/slog"
+)
+
+// Object is a decoded JSON object that
+// preserves the order of its fields.
+type Object []Field
+
+// Field is a field of an Object.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// MarshalJSON encodes o with its fields in order.
+func (o Object) MarshalJSON() ([]byte, error) {
+	b := []byte{'{'}
+	for i, f := range o {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		k, err := json.Marshal(f.Key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(f.Value)
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, k...)
+		b = append(b, ':')
+		b = append(b, v...)
+	}
+	return append(b, '}'), nil
+}
+
+// String formats a decoded value for the sinks that write values
+// as text. Strings are written verbatim and other values as JSON.
+func String(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// Decode decodes the JSON in b into an Object, []interface{},
+// string, json.Number, bool or nil.
+func Decode(b []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return decode(dec)
+}
+
+func decode(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		obj := Object{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := decode(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, Field{key.(string), v})
+		}
+		_, err = dec.Token()
+		return obj, err
+	case json.Delim('['):
+		arr := []interface{}{}
+		for dec.More() {
+			v, err := decode(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		_, err = dec.Token()
+		return arr, err
+	}
+	return tok, nil
+}
+
+// Flatten encodes m like slog.Map.MarshalJSON and flattens the
+// nested objects into fields with keys joined by sep, e.g. a.b.
+// Arrays are not flattened.
+func Flatten(m slog.Map, sep string) []Field {
+	// No error is guaranteed due to slog.Map handling errors itself.
+	b, _ := m.MarshalJSON()
+	v, err := Decode(b)
+	if err != nil {
+		return nil
+	}
+	obj, _ := v.(Object)
+	return flatten(nil, "", sep, obj)
+}
+
+func flatten(fields []Field, prefix, sep string, obj Object) []Field {
+	for _, f := range obj {
+		key := prefix + f.Key
+		if o, ok := f.Value.(Object); ok && len(o) > 0 {
+			fields = flatten(fields, key+sep, sep, o)
+			continue
+		}
+		fields = append(fields, Field{key, f.Value})
+	}
+	return fields
+}
@@ -0,0 +1,76 @@
+// Package errorchain walks the chain of wrapped errors
+// for sinks that present the causes of an error.
+package errorchain
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// Cause is one error in the chain of a wrapped error.
+type Cause struct {
+	Msg string
+	// Type is the Go type of the error, e.g. *xerrors.wrapError.
+	Type string
+	// Func and Loc are the function and file:line where the
+	// error was created if it was created with xerrors.
+	Func string
+	Loc  string
+}
+
+// Causes returns the chain of err like slog.Map encodes it.
+func Causes(err error) []Cause {
+	var causes []Cause
+
+	next := err
+	for next != nil {
+		typ := fmt.Sprintf("%T", next)
+		switch e := next.(type) {
+		case xerrors.Formatter:
+			p := &causePrinter{}
+			next = e.FormatError(p)
+			p.c.Type = typ
+			causes = append(causes, p.c)
+		default:
+			inner := xerrors.Unwrap(e)
+			if inner == nil {
+				return append(causes, Cause{Msg: e.Error(), Type: typ})
+			}
+			msg := strings.TrimSuffix(e.Error(), inner.Error())
+			msg = strings.TrimSuffix(strings.TrimSpace(msg), ":")
+			causes = append(causes, Cause{Msg: msg, Type: typ})
+			next = inner
+		}
+	}
+	return causes
+}
+
+type causePrinter struct {
+	c Cause
+}
+
+func (p *causePrinter) Print(v ...interface{}) {
+	p.write(fmt.Sprint(v...))
+}
+
+func (p *causePrinter) Printf(f string, v ...interface{}) {
+	p.write(fmt.Sprintf(f, v...))
+}
+
+func (p *causePrinter) Detail() bool {
+	return true
+}
+
+func (p *causePrinter) write(s string) {
+	s = strings.TrimSpace(s)
+	switch {
+	case p.c.Msg == "":
+		p.c.Msg = s
+	case p.c.Func == "":
+		p.c.Func = s
+	case p.c.Loc == "":
+		p.c.Loc = s
+	}
+}
@@ -0,0 +1,186 @@
+// Package httpretry sends HTTP requests with retries for the
+// sinks that write to HTTP APIs.
+package httpretry
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// Options represents the options of Do.
+type Options struct {
+	// Client defaults to a client with a 30 second timeout.
+	Client *http.Client
+
+	// MaxRetries defaults to 3.
+	MaxRetries int
+
+	// Backoff is the delay before the first retry. It doubles
+	// for every following retry.
+	//
+	// Defaults to 500ms.
+	Backoff time.Duration
+
+	// MaxRetryAfter caps the delay requested by the Retry-After
+	// header so that a server cannot stall the sink indefinitely.
+	//
+	// Defaults to 1 minute.
+	MaxRetryAfter time.Duration
+
+	// Retry reports whether a request that failed with a
+	// response should be retried.
+	//
+	// Defaults to retrying 429 and 5xx statuses.
+	Retry func(err *StatusError) bool
+
+	// Prepare is called with the request of every attempt
+	// before it is sent, e.g. to sign it with the current time.
+	Prepare func(req *http.Request)
+}
+
+// WithDefaults returns o with the defaults of the unset options.
+func (o Options) WithDefaults() Options {
+	if o.Client == nil {
+		o.Client = defaultClient
+	}
+	if o.MaxRetries <= 0 {
+		o.MaxRetries = 3
+	}
+	if o.Backoff <= 0 {
+		o.Backoff = 500 * time.Millisecond
+	}
+	if o.MaxRetryAfter <= 0 {
+		o.MaxRetryAfter = time.Minute
+	}
+	if o.Retry == nil {
+		o.Retry = func(err *StatusError) bool {
+			return err.StatusCode == http.StatusTooManyRequests || err.StatusCode/100 == 5
+		}
+	}
+	return o
+}
+
+var defaultClient = &http.Client{
+	Timeout: 30 * time.Second,
+}
+
+// StatusError is returned by Do when a request fails with
+// a status that is not retried or after the last retry.
+type StatusError struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (err *StatusError) Error() string {
+	return "unexpected status " + strconv.Itoa(err.StatusCode) + ": " + string(err.Body)
+}
+
+// Do sends a request with the given method, url, header and body
+// and returns the response body if the status is 2xx.
+//
+// Network errors and the statuses accepted by opts.Retry are retried
+// with exponential backoff. The Retry-After header is honored if it is set
+// up to opts.MaxRetryAfter.
+func Do(ctx context.Context, opts Options, method, url string, header http.Header, body []byte) ([]byte, error) {
+	opts = opts.WithDefaults()
+
+	backoff := opts.Backoff
+	for i := 0; ; i++ {
+		respBody, retryAfter, retry, err := do(ctx, opts, method, url, header, body)
+		if err == nil {
+			return respBody, nil
+		}
+		if i == opts.MaxRetries || !retry {
+			return nil, err
+		}
+
+		wait := backoff
+		if retryAfter > 0 {
+			wait = retryAfter
+		}
+		backoff *= 2
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, xerrors.Errorf("%v: %w", err, ctx.Err())
+		case <-t.C:
+		}
+	}
+}
+
+// do sends a single request. It returns the delay requested by the
+// server and whether the request should be retried if it fails.
+func do(ctx context.Context, opts Options, method, url string, header http.Header, body []byte) ([]byte, time.Duration, bool, error) {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, false, xerrors.Errorf("failed to create request: %w", err)
+	}
+	req = req.WithContext(ctx)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if opts.Prepare != nil {
+		opts.Prepare(req)
+	}
+
+	resp, err := opts.Client.Do(req)
+	if err != nil {
+		return nil, 0, true, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, 0, true, xerrors.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		err := &StatusError{
+			StatusCode: resp.StatusCode,
+			Body:       bytes.TrimSpace(respBody),
+		}
+		retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now(), opts.MaxRetryAfter)
+		return nil, retryAfter, opts.Retry(err), err
+	}
+	return respBody, 0, false, nil
+}
+
+// parseRetryAfter parses the Retry-After header h which is either
+// a number of seconds or an HTTP date. The delay is capped at max.
+// It returns 0 if h is not set, invalid or in the past.
+func parseRetryAfter(h string, now time.Time, max time.Duration) time.Duration {
+	if h == "" {
+		return 0
+	}
+
+	var d time.Duration
+	secs, err := strconv.ParseInt(h, 10, 64)
+	if err == nil {
+		if secs > int64(max/time.Second) {
+			return max
+		}
+		d = time.Duration(secs) * time.Second
+	} else {
+		t, err := http.ParseTime(h)
+		if err != nil {
+			return 0
+		}
+		d = t.Sub(now)
+	}
+
+	if d < 0 {
+		return 0
+	}
+	if d > max {
+		return max
+	}
+	return d
+}
@@ -0,0 +1,91 @@
+package httpretry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"cdr.dev/slog/internal/assert"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2015, time.October, 21, 7, 28, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		h    string
+		exp  time.Duration
+	}{
+		{"unset", "", 0},
+		{"seconds", "120", 2 * time.Minute},
+		{"capped", "3600", 5 * time.Minute},
+		{"overflow", "99999999999999999", 5 * time.Minute},
+		{"negative", "-1", 0},
+		{"date", "Wed, 21 Oct 2015 07:29:00 GMT", time.Minute},
+		{"pastDate", "Wed, 21 Oct 2015 07:27:00 GMT", 0},
+		{"cappedDate", "Thu, 22 Oct 2015 07:28:00 GMT", 5 * time.Minute},
+		{"invalid", "soon", 0},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, "delay", tc.exp, parseRetryAfter(tc.h, now, 5*time.Minute))
+		})
+	}
+}
+
+func TestDo_maxRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	b, err := Do(ctx, Options{
+		MaxRetryAfter: time.Millisecond,
+	}, http.MethodPost, srv.URL, nil, nil)
+	assert.Success(t, "do", err)
+	assert.Equal(t, "body", "ok", string(b))
+	assert.Equal(t, "calls", 2, calls)
+}
+
+func TestDo_prepare(t *testing.T) {
+	t.Parallel()
+
+	var attempts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts = append(attempts, r.Header.Get("X-Attempt"))
+		if len(attempts) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+	}))
+	defer srv.Close()
+
+	prepares := 0
+	_, err := Do(context.Background(), Options{
+		Backoff: time.Millisecond,
+		Prepare: func(req *http.Request) {
+			prepares++
+			req.Header.Set("X-Attempt", strconv.Itoa(prepares))
+		},
+	}, http.MethodPost, srv.URL, http.Header{"X-Attempt": []string{"0"}}, nil)
+	assert.Success(t, "do", err)
+	assert.Equal(t, "attempts", []string{"1", "2"}, attempts)
+}
@@ -0,0 +1,274 @@
+// Package netqueue writes frames to a connection in the background
+// for the sinks that must not block on the network.
+package netqueue
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+)
+
+// Options represents the options of New.
+type Options struct {
+	// Name prefixes the reported errors, e.g. slognet.
+	Name string
+
+	// BufferSize defaults to 1000.
+	BufferSize int
+
+	// MinBackoff defaults to 100ms.
+	MinBackoff time.Duration
+
+	// MaxBackoff defaults to 30 seconds.
+	MaxBackoff time.Duration
+
+	// WriteTimeout defaults to 10 seconds.
+	WriteTimeout time.Duration
+}
+
+// Queue writes frames to a connection from a background goroutine.
+//
+// It connects lazily and reconnects with exponential backoff when a
+// write fails or does not complete within WriteTimeout. Up to
+// BufferSize frames are buffered in the meantime and the oldest
+// frames are dropped once it is full. The first of consecutive
+// failures to connect and dropped frames are reported with
+// slog.ReportError.
+type Queue struct {
+	dial       func() (net.Conn, error)
+	name       string
+	maxBuffer  int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	// writeTimeout bounds every write so that a peer that stopped
+	// reading cannot hang the queue and thereby Close.
+	writeTimeout time.Duration
+
+	mu   sync.Mutex
+	cond *sync.Cond
+	// queue holds the frames to write.
+	queue [][]byte
+	// writing is the number of frames being written.
+	writing   int
+	dropped   int
+	connected bool
+	closed    bool
+
+	closing chan struct{}
+	done    chan struct{}
+}
+
+// New creates a Queue writing to the connections returned by dial.
+func New(dial func() (net.Conn, error), opts Options) *Queue {
+	q := &Queue{
+		dial:         dial,
+		name:         opts.Name,
+		maxBuffer:    opts.BufferSize,
+		minBackoff:   opts.MinBackoff,
+		maxBackoff:   opts.MaxBackoff,
+		writeTimeout: opts.WriteTimeout,
+		connected:    true,
+		closing:      make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	if q.maxBuffer <= 0 {
+		q.maxBuffer = 1000
+	}
+	if q.minBackoff <= 0 {
+		q.minBackoff = 100 * time.Millisecond
+	}
+	if q.maxBackoff <= 0 {
+		q.maxBackoff = 30 * time.Second
+	}
+	if q.writeTimeout <= 0 {
+		q.writeTimeout = 10 * time.Second
+	}
+	q.cond = sync.NewCond(&q.mu)
+
+	go q.run()
+	return q
+}
+
+// Write queues frame. It returns false if the queue is closed.
+func (q *Queue) Write(frame []byte) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return false
+	}
+	q.queue = append(q.queue, frame)
+	q.trimLocked()
+	q.cond.Broadcast()
+	return true
+}
+
+// trimLocked drops the oldest frames beyond the buffer size.
+func (q *Queue) trimLocked() {
+	if n := len(q.queue) - q.maxBuffer; n > 0 {
+		q.dropped += n
+		q.queue = q.queue[n:]
+	}
+}
+
+func (q *Queue) run() {
+	defer close(q.done)
+
+	var conn net.Conn
+	defer func() {
+		if conn != nil {
+			conn.Close()
+		}
+	}()
+
+	backoff := q.minBackoff
+	// reported is whether the current failure to connect was reported.
+	reported := false
+	for {
+		q.mu.Lock()
+		for len(q.queue) == 0 && !q.closed {
+			q.cond.Wait()
+		}
+		if len(q.queue) == 0 {
+			q.mu.Unlock()
+			return
+		}
+		frames := q.queue
+		q.queue = nil
+		q.writing = len(frames)
+		dropped := q.dropped
+		q.dropped = 0
+		q.mu.Unlock()
+
+		if dropped > 0 {
+			slog.ReportError(context.Background(), xerrors.Errorf("%v: dropped %v entries while disconnected", q.name, dropped))
+		}
+
+		if conn == nil {
+			c, err := q.dial()
+			if err != nil {
+				if !reported {
+					slog.ReportError(context.Background(), xerrors.Errorf("%v: failed to connect: %w", q.name, err))
+					reported = true
+				}
+				q.requeue(frames, false)
+				if !q.sleep(backoff) {
+					q.drop()
+					return
+				}
+				if backoff *= 2; backoff > q.maxBackoff {
+					backoff = q.maxBackoff
+				}
+				continue
+			}
+			conn = c
+			backoff = q.minBackoff
+			reported = false
+		}
+
+		for i, frame := range frames {
+			err := conn.SetWriteDeadline(time.Now().Add(q.writeTimeout))
+			if err == nil {
+				_, err = conn.Write(frame)
+			}
+			if err != nil {
+				conn.Close()
+				conn = nil
+				frames = frames[i:]
+				break
+			}
+			if i == len(frames)-1 {
+				frames = nil
+			}
+		}
+		q.requeue(frames, conn != nil)
+
+		if conn == nil && q.isClosing() {
+			// Do not reconnect after Close to write the rest.
+			q.drop()
+			return
+		}
+	}
+}
+
+func (q *Queue) isClosing() bool {
+	select {
+	case <-q.closing:
+		return true
+	default:
+		return false
+	}
+}
+
+// requeue puts the unwritten frames back at the front of the
+// queue and updates whether the queue is connected.
+func (q *Queue) requeue(frames [][]byte, connected bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.queue = append(frames, q.queue...)
+	q.trimLocked()
+	q.writing = 0
+	q.connected = connected
+	q.cond.Broadcast()
+}
+
+// sleep waits for d and returns false if the queue
+// was closed in the meantime.
+func (q *Queue) sleep(d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return true
+	case <-q.closing:
+		return false
+	}
+}
+
+// drop reports the frames that could not be written before Close.
+func (q *Queue) drop() {
+	q.mu.Lock()
+	n := len(q.queue) + q.dropped
+	q.queue = nil
+	q.cond.Broadcast()
+	q.mu.Unlock()
+
+	if n > 0 {
+		slog.ReportError(context.Background(), xerrors.Errorf("%v: dropped %v entries on close while disconnected", q.name, n))
+	}
+}
+
+// Sync waits until the queued frames have been written
+// or the queue is disconnected.
+func (q *Queue) Sync() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for (len(q.queue) > 0 || q.writing > 0) && q.connected && !q.closed {
+		q.cond.Wait()
+	}
+}
+
+// Close writes the queued frames if the queue is connected
+// and closes the connection.
+func (q *Queue) Close() error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return nil
+	}
+	q.closed = true
+	close(q.closing)
+	q.cond.Broadcast()
+	q.mu.Unlock()
+
+	<-q.done
+	return nil
+}
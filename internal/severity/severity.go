@@ -0,0 +1,28 @@
+// Package severity maps levels to the severities of other logging systems.
+package severity
+
+import (
+	"cdr.dev/slog"
+)
+
+// Syslog maps l to the RFC 5424 severities:
+// 7 (debug) for LevelDebug and below, 6 (informational) for
+// LevelInfo, 4 (warning) for LevelWarn, 3 (error) for
+// LevelError, 2 (critical) for LevelCritical and
+// 0 (emergency) for LevelFatal and above.
+func Syslog(l slog.Level) int {
+	switch {
+	case l < slog.LevelInfo:
+		return 7
+	case l < slog.LevelWarn:
+		return 6
+	case l < slog.LevelError:
+		return 4
+	case l < slog.LevelCritical:
+		return 3
+	case l < slog.LevelFatal:
+		return 2
+	default:
+		return 0
+	}
+}
@@ -2,18 +2,23 @@
 package syncwriter
 
 import (
+	"context"
 	"errors"
-	"fmt"
 	"io"
 	"os"
-	"sync"
 	"syscall"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
 )
 
 // Writer implements a concurrency safe io.Writer wrapper.
 type Writer struct {
-	mu sync.Mutex
-	w  io.Writer
+	// sem is a semaphore of one guarding w so that
+	// waiting for it can be canceled.
+	sem chan struct{}
+	w   io.Writer
 
 	errorf func(f string, v ...interface{})
 }
@@ -21,21 +26,40 @@ type Writer struct {
 // New returns a new Writer that writes to w.
 func New(w io.Writer) *Writer {
 	return &Writer{
-		w: w,
+		sem: make(chan struct{}, 1),
+		w:   w,
 
 		errorf: func(f string, v ...interface{}) {
-			println(fmt.Sprintf(f, v...))
+			slog.ReportError(context.Background(), xerrors.Errorf(f, v...))
 		},
 	}
 }
 
-func (w *Writer) Write(name string, p []byte) {
-	w.mu.Lock()
-	defer w.mu.Unlock()
+// Write writes p to the underlying writer.
+//
+// If another write is in progress, it waits until either it
+// completes or ctx is done. A write in progress cannot be
+// canceled as io.Writer does not support it.
+//
+// The caller is expected to report the returned error
+// with the entry that could not be written.
+func (w *Writer) Write(ctx context.Context, name string, p []byte) error {
+	select {
+	case w.sem <- struct{}{}:
+	default:
+		select {
+		case w.sem <- struct{}{}:
+		case <-ctx.Done():
+			return xerrors.Errorf("%v: failed to write entry: %w", name, ctx.Err())
+		}
+	}
+	defer func() { <-w.sem }()
+
 	_, err := w.w.Write(p)
 	if err != nil {
-		w.errorf("%v: failed to write entry: %+v", name, err)
+		return xerrors.Errorf("%v: failed to write entry: %w", name, err)
 	}
+	return nil
 }
 
 type syncer interface {
@@ -47,14 +71,17 @@ var _ syncer = &os.File{}
 // Sync calls Sync on the underlying writer
 // if possible.
 func (w *Writer) Sync(sinkName string) {
-	w.mu.Lock()
-	defer w.mu.Unlock()
+	w.sem <- struct{}{}
+	defer func() { <-w.sem }()
 
 	s, ok := w.w.(syncer)
 	if !ok {
 		return
 	}
 	err := s.Sync()
+	if err == nil {
+		return
+	}
 	if _, ok := w.w.(*os.File); ok {
 		// Opened files do not necessarily support syncing.
 		// E.g. stdout and stderr both do not so we need
@@ -66,7 +93,7 @@ func (w *Writer) Sync(sinkName string) {
 		}
 	}
 
-	w.errorf("failed to sync %v: %+v", sinkName, err)
+	w.errorf("failed to sync %v: %w", sinkName, err)
 }
 
 func errorsIsAny(err error, errs ...error) bool {
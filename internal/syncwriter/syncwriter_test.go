@@ -1,6 +1,7 @@
 package syncwriter
 
 import (
+	"context"
 	"io"
 	"os"
 	"testing"
@@ -45,10 +46,10 @@ func TestWriter_Sync(t *testing.T) {
 				return io.EOF
 			},
 		})
-		tw.w.Write("hello", nil)
-		assert.Equal(t, "errors", 1, tw.errors)
+		err := tw.w.Write(context.Background(), "hello", nil)
+		assert.Error(t, "write", err)
 		tw.w.Sync("test")
-		assert.Equal(t, "errors", 2, tw.errors)
+		assert.Equal(t, "errors", 1, tw.errors)
 	})
 
 	t.Run("stdout", func(t *testing.T) {
@@ -70,7 +71,7 @@ func TestWriter_Sync(t *testing.T) {
 				return io.EOF
 			},
 		})
-		sw.Write("hello", nil)
+		sw.Write(context.Background(), "hello", nil)
 	})
 }
 
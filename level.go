@@ -0,0 +1,263 @@
+package slog
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/xerrors"
+)
+
+// AtomicLevel is a Level that can be safely changed at runtime.
+//
+// The zero value is LevelDebug.
+type AtomicLevel struct {
+	level int32
+}
+
+// NewAtomicLevel creates an AtomicLevel set to level.
+func NewAtomicLevel(level Level) *AtomicLevel {
+	lvl := &AtomicLevel{}
+	lvl.Set(level)
+	return lvl
+}
+
+// Level returns the current level.
+func (lvl *AtomicLevel) Level() Level {
+	return Level(atomic.LoadInt32(&lvl.level))
+}
+
+// Set changes the current level.
+func (lvl *AtomicLevel) Set(level Level) {
+	atomic.StoreInt32(&lvl.level, int32(level))
+}
+
+// String implements fmt.Stringer.
+func (lvl *AtomicLevel) String() string {
+	return lvl.Level().String()
+}
+
+// LevelFilter returns a Sink that only passes entries
+// equal to or above the given level to s.
+//
+// Unlike Logger.Leveled, it filters per sink so that
+// one Logger can write debug logs to one sink and only
+// info logs and above to another.
+func LevelFilter(s Sink, level Level) Sink {
+	return levelFilterSink{
+		s:     s,
+		level: level,
+	}
+}
+
+type levelFilterSink struct {
+	s     Sink
+	level Level
+}
+
+func (s levelFilterSink) LogEntry(ctx context.Context, e SinkEntry) {
+	if e.Level < s.level {
+		return
+	}
+	s.s.LogEntry(ctx, e)
+}
+
+func (s levelFilterSink) Sync() {
+	s.s.Sync()
+}
+
+func (s levelFilterSink) Close() error {
+	return closeSinks([]Sink{s.s})
+}
+
+// ComponentLevels maps dotted logger names to levels so that
+// components can be configured individually, e.g. "comp.db"
+// at LevelDebug and everything else at LevelWarn.
+//
+// The logger names of an entry are joined with "." and the level
+// of the longest matching prefix applies. "comp.db" matches
+// "comp.db" and "comp.db.pool" but not "comp.dbx". The name ""
+// is the default for entries without a more specific match.
+//
+// It is safe to update the levels at runtime. The zero value
+// logs everything at LevelDebug and above.
+type ComponentLevels struct {
+	mu     sync.Mutex
+	levels atomic.Value // map[string]Level
+}
+
+// NewComponentLevels creates ComponentLevels with
+// the given default level.
+func NewComponentLevels(def Level) *ComponentLevels {
+	c := &ComponentLevels{}
+	c.Set("", def)
+	return c
+}
+
+// Set sets the level of the named component.
+// Use "" to set the default level.
+func (c *ComponentLevels) Set(name string, level Level) {
+	c.update(func(m map[string]Level) {
+		m[name] = level
+	})
+}
+
+// Unset removes the level of the named component
+// so that its parent's level applies.
+func (c *ComponentLevels) Unset(name string) {
+	c.update(func(m map[string]Level) {
+		delete(m, name)
+	})
+}
+
+// Levels returns a copy of the configured levels.
+func (c *ComponentLevels) Levels() map[string]Level {
+	m := c.load()
+	m2 := make(map[string]Level, len(m))
+	for name, level := range m {
+		m2[name] = level
+	}
+	return m2
+}
+
+// Level returns the level that applies to the given logger names.
+func (c *ComponentLevels) Level(names []string) Level {
+	m := c.load()
+	name := strings.Join(names, ".")
+	for {
+		level, ok := m[name]
+		if ok {
+			return level
+		}
+		if name == "" {
+			return LevelDebug
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			i = 0
+		}
+		name = name[:i]
+	}
+}
+
+func (c *ComponentLevels) load() map[string]Level {
+	m, _ := c.levels.Load().(map[string]Level)
+	return m
+}
+
+// update copies the levels, applies fn and stores the copy
+// so that Level never has to lock.
+func (c *ComponentLevels) update(fn func(m map[string]Level)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	m := c.Levels()
+	fn(m)
+	c.levels.Store(m)
+}
+
+// ComponentFilter returns a Sink that only passes entries
+// equal to or above the level configured in c for the
+// entry's logger names to s.
+//
+// Entries are filtered by the Logger's own level first so the
+// Logger must be leveled at or below the most verbose component.
+func ComponentFilter(s Sink, c *ComponentLevels) Sink {
+	return componentFilterSink{
+		s: s,
+		c: c,
+	}
+}
+
+type componentFilterSink struct {
+	s Sink
+	c *ComponentLevels
+}
+
+func (s componentFilterSink) LogEntry(ctx context.Context, e SinkEntry) {
+	if e.Level < s.c.Level(e.LoggerNames) {
+		return
+	}
+	s.s.LogEntry(ctx, e)
+}
+
+func (s componentFilterSink) Sync() {
+	s.s.Sync()
+}
+
+func (s componentFilterSink) Close() error {
+	return closeSinks([]Sink{s.s})
+}
+
+// ParseLevel parses the name of a level case insensitively.
+// Levels registered with RegisterLevel are supported.
+//
+// RegisterLevel rejects names that only differ in case
+// so at most one level matches.
+func ParseLevel(s string) (Level, error) {
+	levelStringsMu.RLock()
+	defer levelStringsMu.RUnlock()
+
+	for level, name := range levelStrings {
+		if strings.EqualFold(s, name) {
+			return level, nil
+		}
+	}
+	return 0, xerrors.Errorf("unknown level %q", s)
+}
+
+// ParseLevels parses a comma separated list of levels
+// into ComponentLevels.
+//
+// Each element is either a component name and level
+// like "comp.db=debug" or just a level which sets the
+// default. The name "default" also sets the default.
+//
+// For example: "comp.db=debug,default=warn".
+//
+// The default level is LevelInfo if the spec does not set it.
+func ParseLevels(spec string) (*ComponentLevels, error) {
+	c := NewComponentLevels(LevelInfo)
+	for _, el := range strings.Split(spec, ",") {
+		el = strings.TrimSpace(el)
+		if el == "" {
+			continue
+		}
+
+		var name string
+		levelName := el
+		if i := strings.IndexByte(el, '='); i >= 0 {
+			name = strings.TrimSpace(el[:i])
+			levelName = strings.TrimSpace(el[i+1:])
+		}
+		if name == "default" {
+			name = ""
+		}
+
+		level, err := ParseLevel(levelName)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to parse %q: %w", el, err)
+		}
+		c.Set(name, level)
+	}
+	return c, nil
+}
+
+// LevelFromEnv parses the environment variable key with ParseLevels.
+// If it is unset, everything is logged at LevelInfo and above.
+//
+// Use it with ComponentFilter to let operators tune verbosity
+// without code changes:
+//
+//	levels, err := slog.LevelFromEnv("SLOG_LEVEL")
+//	s = slog.ComponentFilter(s, levels)
+//	l := slog.Make(s).Leveled(slog.LevelDebug)
+func LevelFromEnv(key string) (*ComponentLevels, error) {
+	c, err := ParseLevels(os.Getenv(key))
+	if err != nil {
+		return nil, xerrors.Errorf("failed to parse $%v: %w", key, err)
+	}
+	return c, nil
+}
@@ -0,0 +1,152 @@
+package slog_test
+
+import (
+	"testing"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+)
+
+func TestLevelFilter(t *testing.T) {
+	t.Parallel()
+
+	s1 := &fakeSink{}
+	s2 := &fakeSink{}
+	l := slog.Make(s1, slog.LevelFilter(s2, slog.LevelWarn))
+	l = l.Leveled(slog.LevelDebug)
+
+	l.Debug(bg, "")
+	l.Info(bg, "")
+	l.Warn(bg, "")
+	l.Error(bg, "")
+
+	assert.Len(t, "entries", 4, s1.entries)
+	assert.Len(t, "entries", 2, s2.entries)
+	assert.Equal(t, "level", slog.LevelWarn, s2.entries[0].Level)
+	assert.Equal(t, "level", slog.LevelError, s2.entries[1].Level)
+	assert.Equal(t, "syncs", 1, s2.syncs)
+}
+
+func TestAtomicLevel(t *testing.T) {
+	t.Parallel()
+
+	s := &fakeSink{}
+	lvl := slog.NewAtomicLevel(slog.LevelInfo)
+	l1 := slog.Make(s).AtomicLeveled(lvl)
+	l2 := slog.Make(s).Named("l2").AtomicLeveled(lvl)
+
+	l1.Debug(bg, "")
+	l2.Debug(bg, "")
+	assert.Len(t, "entries", 0, s.entries)
+
+	lvl.Set(slog.LevelDebug)
+	assert.Equal(t, "level", slog.LevelDebug, lvl.Level())
+
+	l1.Debug(bg, "")
+	l2.Debug(bg, "")
+	assert.Len(t, "entries", 2, s.entries)
+
+	l1 = l1.Leveled(slog.LevelWarn)
+	l1.Info(bg, "")
+	assert.Len(t, "entries", 2, s.entries)
+}
+
+func TestRegisterLevel(t *testing.T) {
+	t.Parallel()
+
+	levelNotice := slog.LevelInfo + 5
+	slog.RegisterLevel(levelNotice, "NOTICE")
+	assert.Equal(t, "level string", "NOTICE", levelNotice.String())
+
+	s := &fakeSink{}
+	l := slog.Make(slog.LevelFilter(s, levelNotice))
+	l.Info(bg, "")
+	l.Log(bg, slog.SinkEntry{Level: levelNotice})
+	l.Warn(bg, "")
+	assert.Len(t, "entries", 2, s.entries)
+
+	defer func() {
+		assert.True(t, "panicked", recover() != nil)
+	}()
+	slog.RegisterLevel(slog.LevelInfo, "NOTICE")
+}
+
+func TestRegisterLevel_nameCollision(t *testing.T) {
+	t.Parallel()
+
+	slog.RegisterLevel(slog.LevelDebug-7, "FINEST")
+	level, err := slog.ParseLevel("finest")
+	assert.Success(t, "parse", err)
+	assert.Equal(t, "level", slog.LevelDebug-7, level)
+
+	defer func() {
+		assert.True(t, "panicked", recover() != nil)
+	}()
+	slog.RegisterLevel(slog.LevelDebug-8, "Finest")
+}
+
+func TestComponentLevels(t *testing.T) {
+	t.Parallel()
+
+	c := slog.NewComponentLevels(slog.LevelWarn)
+	c.Set("comp.db", slog.LevelDebug)
+	c.Set("comp.db.pool", slog.LevelError)
+
+	assert.Equal(t, "level", slog.LevelWarn, c.Level(nil))
+	assert.Equal(t, "level", slog.LevelWarn, c.Level([]string{"comp"}))
+	assert.Equal(t, "level", slog.LevelWarn, c.Level([]string{"comp", "dbx"}))
+	assert.Equal(t, "level", slog.LevelDebug, c.Level([]string{"comp", "db"}))
+	assert.Equal(t, "level", slog.LevelDebug, c.Level([]string{"comp", "db", "conn"}))
+	assert.Equal(t, "level", slog.LevelError, c.Level([]string{"comp", "db", "pool"}))
+
+	s := &fakeSink{}
+	l := slog.Make(slog.ComponentFilter(s, c)).Leveled(slog.LevelDebug).Named("comp")
+	l.Info(bg, "")
+	l.Named("db").Debug(bg, "")
+	assert.Len(t, "entries", 1, s.entries)
+
+	c.Unset("comp.db")
+	l.Named("db").Debug(bg, "")
+	assert.Len(t, "entries", 1, s.entries)
+	assert.Equal(t, "levels", map[string]slog.Level{
+		"":             slog.LevelWarn,
+		"comp.db.pool": slog.LevelError,
+	}, c.Levels())
+
+	var zero slog.ComponentLevels
+	assert.Equal(t, "level", slog.LevelDebug, zero.Level([]string{"comp"}))
+}
+
+func TestParseLevels(t *testing.T) {
+	t.Parallel()
+
+	level, err := slog.ParseLevel("Warn")
+	assert.Success(t, "parse", err)
+	assert.Equal(t, "level", slog.LevelWarn, level)
+
+	_, err = slog.ParseLevel("loud")
+	assert.Error(t, "parse", err)
+
+	c, err := slog.ParseLevels("comp.db=debug, default=warn")
+	assert.Success(t, "parse", err)
+	assert.Equal(t, "levels", map[string]slog.Level{
+		"":        slog.LevelWarn,
+		"comp.db": slog.LevelDebug,
+	}, c.Levels())
+
+	c, err = slog.ParseLevels("error,comp=info")
+	assert.Success(t, "parse", err)
+	assert.Equal(t, "levels", map[string]slog.Level{
+		"":     slog.LevelError,
+		"comp": slog.LevelInfo,
+	}, c.Levels())
+
+	_, err = slog.ParseLevels("comp=loud")
+	assert.Error(t, "parse", err)
+
+	c, err = slog.LevelFromEnv("SLOG_TEST_LEVEL_UNSET")
+	assert.Success(t, "env", err)
+	assert.Equal(t, "levels", map[string]slog.Level{
+		"": slog.LevelInfo,
+	}, c.Levels())
+}
@@ -2,10 +2,15 @@ package slog
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"math"
 	"reflect"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"golang.org/x/xerrors"
 )
@@ -22,142 +27,302 @@ var _ json.Marshaler = Map(nil)
 //
 // Every field value is encoded with the following process:
 //
-// 1. json.Marshaller is handled.
+// 1. slog.Value is handled by encoding the result of SlogValue().
 //
-// 2. xerrors.Formatter is handled.
+// 2. json.Marshaller is handled, including types that implement it
+// with a pointer receiver. json.RawMessage is embedded verbatim and
+// encoded as a string if it is invalid. json.Number is encoded as a
+// number like json.Marshal.
 //
-// 3. structs that have a field with a json tag are encoded with json.Marshal.
+// 3. xerrors.Formatter and errors that wrap another error are encoded
+// as an array of the errors in the chain ending with the root error.
+// Errors created with xerrors include the function and file:line that
+// created them. Errors wrapped with fmt.Errorf or any other error with
+// an Unwrap method only include their message and type as there is no
+// caller to record. Wrap an error with FlatError to encode it as a
+// single string instead.
 //
-// 4. error and fmt.Stringer is handled.
+// 4. structs that have a field with a json tag are encoded with json.Marshal.
 //
-// 5. slices and arrays go through the encode function for every element.
+// 5. error and fmt.Stringer is handled.
 //
-// 6. For values that cannot be encoded with json.Marshal, fmt.Sprintf("%+v") is used.
+// 6. []byte is encoded as a base64 string like json.Marshal. Other slices
+// and arrays go through the encode function for every element.
 //
-// 7. json.Marshal(v) is used for all other values.
+// 7. For values that cannot be encoded with json.Marshal, fmt.Sprintf("%+v") is used.
+// NaN and infinite floats are encoded as "NaN", "+Inf" and "-Inf".
+//
+// 8. json.Marshal(v) is used for all other values.
 func (m Map) MarshalJSON() ([]byte, error) {
-	b := &bytes.Buffer{}
-	b.WriteByte('{')
-	for i, f := range m {
-		b.WriteByte('\n')
-		b.Write(encode(f.Name))
-		b.WriteByte(':')
-		b.Write(encode(f.Value))
+	return m.AppendJSON(nil), nil
+}
 
-		if i < len(m)-1 {
-			b.WriteByte(',')
+// AppendJSON appends the JSON encoding of m to b and returns
+// the extended buffer. It encodes the fields like MarshalJSON
+// but avoids the allocations of going through json.Marshal
+// for the common field types.
+func (m Map) AppendJSON(b []byte) []byte {
+	b = append(b, '{')
+	for i, f := range m {
+		if i > 0 {
+			b = append(b, ',')
 		}
+		b = appendJSONString(b, f.Name)
+		b = append(b, ':')
+		b = appendField(b, f)
 	}
-	b.WriteByte('}')
+	return append(b, '}')
+}
 
-	return b.Bytes(), nil
+// appendField appends the value of f to b. Values stored
+// inline by the typed constructors are encoded without
+// being boxed into an interface.
+func appendField(b []byte, f Field) []byte {
+	switch f.kind {
+	case kindString:
+		return appendJSONString(b, f.str)
+	case kindInt, kindInt64:
+		return strconv.AppendInt(b, int64(f.num), 10)
+	case kindUint64:
+		return strconv.AppendUint(b, f.num, 10)
+	case kindFloat64:
+		return appendJSONFloat(b, math.Float64frombits(f.num), 64)
+	case kindBool:
+		return strconv.AppendBool(b, f.num != 0)
+	case kindDuration:
+		return appendJSONString(b, time.Duration(f.num).String())
+	case kindTimeUTC:
+		return appendJSONTime(b, time.Unix(0, int64(f.num)).UTC())
+	case kindTimeLocal:
+		return appendJSONTime(b, time.Unix(0, int64(f.num)))
+	}
+	return appendValue(b, f.Value)
 }
 
-func marshalList(rv reflect.Value) []byte {
-	b := &bytes.Buffer{}
-	b.WriteByte('[')
-	for i := 0; i < rv.Len(); i++ {
-		b.WriteByte('\n')
-		b.Write(encode(rv.Index(i).Interface()))
+// appendJSONTime appends t to b as an RFC 3339 string.
+// The year of t must be within the range json.Marshal accepts.
+func appendJSONTime(b []byte, t time.Time) []byte {
+	b = append(b, '"')
+	b = t.AppendFormat(b, time.RFC3339Nano)
+	return append(b, '"')
+}
 
-		if i < rv.Len()-1 {
-			b.WriteByte(',')
+func appendList(b []byte, rv reflect.Value) []byte {
+	b = append(b, '[')
+	for i := 0; i < rv.Len(); i++ {
+		if i > 0 {
+			b = append(b, ',')
 		}
+		b = appendValue(b, rv.Index(i).Interface())
 	}
-	b.WriteByte(']')
-
-	return b.Bytes()
+	return append(b, ']')
 }
 
-func encode(v interface{}) []byte {
+func appendValue(b []byte, v interface{}) []byte {
+	// Fast paths for common types that avoid reflection.
 	switch v := v.(type) {
+	case string:
+		return appendJSONString(b, v)
+	case bool:
+		return strconv.AppendBool(b, v)
+	case int:
+		return strconv.AppendInt(b, int64(v), 10)
+	case int8:
+		return strconv.AppendInt(b, int64(v), 10)
+	case int16:
+		return strconv.AppendInt(b, int64(v), 10)
+	case int32:
+		return strconv.AppendInt(b, int64(v), 10)
+	case int64:
+		return strconv.AppendInt(b, v, 10)
+	case uint:
+		return strconv.AppendUint(b, uint64(v), 10)
+	case uint8:
+		return strconv.AppendUint(b, uint64(v), 10)
+	case uint16:
+		return strconv.AppendUint(b, uint64(v), 10)
+	case uint32:
+		return strconv.AppendUint(b, uint64(v), 10)
+	case uint64:
+		return strconv.AppendUint(b, v, 10)
+	case float32:
+		return appendJSONFloat(b, float64(v), 32)
+	case float64:
+		return appendJSONFloat(b, v, 64)
+	case time.Duration:
+		return appendJSONString(b, v.String())
+	case time.Time:
+		// json.Marshal errors for years outside of this range.
+		if y := v.Year(); y >= 0 && y < 10000 {
+			return appendJSONTime(b, v)
+		}
+	case Level:
+		return appendJSONString(b, v.String())
+	case []string:
+		if v != nil {
+			b = append(b, '[')
+			for i, s := range v {
+				if i > 0 {
+					b = append(b, ',')
+				}
+				b = appendJSONString(b, s)
+			}
+			return append(b, ']')
+		}
+	case Map:
+		return v.AppendJSON(b)
+	case json.RawMessage:
+		return appendRawJSON(b, v)
+	case json.Number:
+		return appendJSON(b, v)
+	case []byte:
+		if v != nil {
+			b = append(b, '"')
+			n := len(b)
+			b = append(b, make([]byte, base64.StdEncoding.EncodedLen(len(v)))...)
+			base64.StdEncoding.Encode(b[n:], v)
+			return append(b, '"')
+		}
+	}
+
+	switch v := v.(type) {
+	case Value:
+		return appendValue(b, v.SlogValue())
 	case json.Marshaler:
-		return encodeJSON(v)
+		return appendJSON(b, v)
 	case xerrors.Formatter:
-		return encode(errorChain(v))
+		return appendValue(b, errorChain(v))
+	case error:
+		if xerrors.Unwrap(v) != nil {
+			return appendValue(b, errorChain(v))
+		}
 	}
 
 	rv := reflect.Indirect(reflect.ValueOf(v))
 	if !rv.IsValid() {
-		return encodeJSON(v)
+		return appendJSON(b, v)
 	}
 
-	if rv.Kind() == reflect.Struct {
-		b, ok := encodeStruct(rv)
-		if ok {
-			return b
-		}
+	// json.Marshal only calls MarshalJSON on a pointer receiver
+	// for addressable values so we make the value addressable.
+	if rv.Kind() != reflect.Ptr && reflect.PtrTo(rv.Type()).Implements(marshalerType) {
+		prv := reflect.New(rv.Type())
+		prv.Elem().Set(rv)
+		return appendJSON(b, prv.Interface())
+	}
+
+	if rv.Kind() == reflect.Struct && hasJSONTag(rv) {
+		return appendJSON(b, rv.Interface())
 	}
 
 	switch v.(type) {
 	case error, fmt.Stringer:
-		return encode(fmt.Sprint(v))
+		return appendJSONString(b, fmt.Sprint(v))
 	}
 
 	switch rv.Type().Kind() {
 	case reflect.Slice:
 		if !rv.IsNil() {
-			return marshalList(rv)
+			return appendList(b, rv)
 		}
 	case reflect.Array:
-		return marshalList(rv)
+		return appendList(b, rv)
 	case reflect.Struct, reflect.Chan, reflect.Complex64, reflect.Complex128, reflect.Func:
 		// These types cannot be directly encoded with json.Marshal.
 		// See https://golang.org/pkg/encoding/json/#Marshal
-		return encodeJSON(fmt.Sprintf("%+v", v))
+		return appendJSONString(b, fmt.Sprintf("%+v", v))
 	}
 
-	return encodeJSON(v)
+	return appendJSON(b, v)
 }
 
-func encodeStruct(rv reflect.Value) ([]byte, bool) {
-	if rv.Kind() == reflect.Struct {
-		for i := 0; i < rv.NumField(); i++ {
-			ft := rv.Type().Field(i)
-			// Found a field with a json tag.
-			if ft.Tag.Get("json") != "" {
-				return encodeJSON(rv.Interface()), true
-			}
-		}
+var marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// appendRawJSON appends raw verbatim after compacting it
+// so that an entry stays on one line.
+func appendRawJSON(b []byte, raw json.RawMessage) []byte {
+	if raw == nil {
+		return append(b, "null"...)
 	}
+	buf := bytes.NewBuffer(b)
+	err := json.Compact(buf, raw)
+	if err != nil {
+		return appendJSON(b, string(raw))
+	}
+	return buf.Bytes()
+}
 
-	return nil, false
+func hasJSONTag(rv reflect.Value) bool {
+	for i := 0; i < rv.NumField(); i++ {
+		ft := rv.Type().Field(i)
+		// Found a field with a json tag.
+		if ft.Tag.Get("json") != "" {
+			return true
+		}
+	}
+	return false
 }
 
-func encodeJSON(v interface{}) []byte {
-	b, err := json.Marshal(v)
+// appendJSON appends the result of json.Marshal(v) to b.
+// It is the fallback for types without a fast path.
+func appendJSON(b []byte, v interface{}) []byte {
+	vb, err := json.Marshal(v)
 	if err != nil {
-		return encode(M(
+		return M(
 			Error(xerrors.Errorf("failed to marshal to JSON: %w", err)),
 			F("type", reflect.TypeOf(v)),
 			F("value", fmt.Sprintf("%+v", v)),
-		))
+		).AppendJSON(b)
 	}
-	return b
+	return append(b, vb...)
 }
 
-func errorChain(f xerrors.Formatter) []interface{} {
+func errorChain(err error) []interface{} {
 	var errs []interface{}
 
-	next := error(f)
-	for {
-		f, ok := next.(xerrors.Formatter)
-		if !ok {
-			errs = append(errs, next)
-			return errs
+	next := err
+	for next != nil {
+		switch e := next.(type) {
+		case xerrors.Formatter:
+			p := &xerrorPrinter{}
+			next = e.FormatError(p)
+			errs = append(errs, p.e)
+		default:
+			inner := xerrors.Unwrap(e)
+			if inner == nil {
+				errs = append(errs, e)
+				return errs
+			}
+			errs = append(errs, wrapError{
+				Msg:  wrapMessage(e, inner),
+				Type: fmt.Sprintf("%T", e),
+			})
+			next = inner
 		}
+	}
+	return errs
+}
 
-		p := &xerrorPrinter{}
-		next = f.FormatError(p)
-		errs = append(errs, p.e)
+// wrapMessage returns the message err adds to inner
+// by stripping the message of inner from err's.
+func wrapMessage(err, inner error) string {
+	msg := err.Error()
+	if !strings.HasSuffix(msg, inner.Error()) {
+		return msg
 	}
+	msg = strings.TrimSuffix(msg, inner.Error())
+	msg = strings.TrimSpace(msg)
+	return strings.TrimSuffix(msg, ":")
 }
 
 type wrapError struct {
 	Msg string `json:"msg"`
-	Fun string `json:"fun"`
+	// Type is only set for errors wrapped with
+	// fmt.Errorf or that implement Unwrap.
+	Type string `json:"type,omitempty"`
+	Fun  string `json:"fun,omitempty"`
 	// file:line
-	Loc string `json:"loc"`
+	Loc string `json:"loc,omitempty"`
 }
 
 type xerrorPrinter struct {
@@ -196,3 +361,92 @@ func (m Map) append(m2 Map) Map {
 	m3 = append(m3, m2...)
 	return m3
 }
+
+const hex = "0123456789abcdef"
+
+// appendJSONString appends s to b as a JSON string
+// escaped exactly like json.Marshal.
+func appendJSONString(b []byte, s string) []byte {
+	b = append(b, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= ' ' && c != '"' && c != '\\' && c != '<' && c != '>' && c != '&' {
+				i++
+				continue
+			}
+			b = append(b, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				b = append(b, '\\', c)
+			case '\b':
+				b = append(b, '\\', 'b')
+			case '\f':
+				b = append(b, '\\', 'f')
+			case '\n':
+				b = append(b, '\\', 'n')
+			case '\r':
+				b = append(b, '\\', 'r')
+			case '\t':
+				b = append(b, '\\', 't')
+			default:
+				b = append(b, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b = append(b, s[start:i]...)
+			b = append(b, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		// U+2028 and U+2029 are escaped for JSONP.
+		if r == '\u2028' || r == '\u2029' {
+			b = append(b, s[start:i]...)
+			b = append(b, '\\', 'u', '2', '0', '2', hex[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	b = append(b, s[start:]...)
+	return append(b, '"')
+}
+
+// appendJSONFloat appends f to b formatted exactly like json.Marshal.
+//
+// json.Marshal errors on NaN and infinities so they are encoded
+// as the strings "NaN", "+Inf" and "-Inf" instead.
+func appendJSONFloat(b []byte, f float64, bits int) []byte {
+	switch {
+	case math.IsNaN(f):
+		return append(b, `"NaN"`...)
+	case math.IsInf(f, 1):
+		return append(b, `"+Inf"`...)
+	case math.IsInf(f, -1):
+		return append(b, `"-Inf"`...)
+	}
+
+	abs := math.Abs(f)
+	fmt := byte('f')
+	if abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			fmt = 'e'
+		}
+	}
+	b = strconv.AppendFloat(b, f, fmt, -1, bits)
+	if fmt == 'e' {
+		// Clean up e-09 to e-9.
+		n := len(b)
+		if n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+	return b
+}
@@ -3,6 +3,7 @@ package slog_test
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"runtime"
 	"strings"
@@ -62,12 +63,12 @@ func TestMap(t *testing.T) {
 				{
 					"msg": "wrap1",
 					"fun": "cdr.dev/slog_test.TestMap.func2",
-					"loc": "`+mapTestFile+`:41" 
+					"loc": "`+mapTestFile+`:42" 
 				},
 				{
 					"msg": "wrap2",
 					"fun": "cdr.dev/slog_test.TestMap.func2",
-					"loc": "`+mapTestFile+`:42" 
+					"loc": "`+mapTestFile+`:43" 
 				},
 				"EOF"
 			],
@@ -92,10 +93,14 @@ func TestMap(t *testing.T) {
 				"error": [
 					{
 						"msg": "failed to marshal to JSON",
-						"fun": "cdr.dev/slog.encodeJSON",
-						"loc": "`+mapTestFile+`:131"
+						"fun": "cdr.dev/slog.appendJSON",
+						"loc": "`+mapTestFile+`:272"
+					},
+					{
+						"msg": "json: error calling MarshalJSON for type *slog_test.complexJSON",
+						"type": "*json.MarshalerError"
 					},
-					"json: error calling MarshalJSON for type slog_test.complexJSON: json: unsupported type: complex128"
+					"json: unsupported type: complex128"
 				],
 				"type": "slog_test.complexJSON",
 				"value": "(10+10i)"
@@ -245,3 +250,102 @@ type complexJSON complex128
 func (c complexJSON) MarshalJSON() ([]byte, error) {
 	return json.Marshal(complex128(c))
 }
+
+func TestGroup(t *testing.T) {
+	t.Parallel()
+
+	act := marshalJSON(t, slog.M(
+		slog.Group("http",
+			slog.F("method", "GET"),
+			slog.Group("response",
+				slog.F("status", 200),
+			),
+		),
+	))
+	exp := indentJSON(t, `{"http": {"method": "GET", "response": {"status": 200}}}`)
+	assert.Equal(t, "JSON", exp, act)
+}
+
+func TestMap_wrappedError(t *testing.T) {
+	t.Parallel()
+
+	act := marshalJSON(t, slog.M(
+		slog.Error(fmt.Errorf("wrap1: %w",
+			xerrors.Errorf("wrap2: %w",
+				fmt.Errorf("wrap3: %w", io.EOF),
+			),
+		)),
+	))
+	exp := indentJSON(t, `{
+		"error": [
+			{
+				"msg": "wrap1",
+				"type": "*fmt.wrapError"
+			},
+			{
+				"msg": "wrap2",
+				"fun": "cdr.dev/slog_test.TestMap_wrappedError",
+				"loc": "`+mapTestFile+`:274"
+			},
+			{
+				"msg": "wrap3",
+				"type": "*fmt.wrapError"
+			},
+			"EOF"
+		]
+	}`)
+	assert.Equal(t, "JSON", exp, act)
+}
+
+func TestMap_flatError(t *testing.T) {
+	t.Parallel()
+
+	act := marshalJSON(t, slog.M(
+		slog.F("error", slog.FlatError(xerrors.Errorf("wrap: %w", io.EOF))),
+		slog.F("nil", slog.FlatError(nil)),
+	))
+	exp := indentJSON(t, `{"error": "wrap: EOF", "nil": null}`)
+	assert.Equal(t, "JSON", exp, act)
+}
+
+func TestMap_AppendJSON(t *testing.T) {
+	t.Parallel()
+
+	m := slog.M(
+		slog.F("str", "<a>"),
+		slog.F("level", slog.LevelWarn),
+		slog.F("names", []string{"a", "b"}),
+		slog.F("nested", slog.M(slog.F("ints", []int{1, 2}))),
+		slog.F("nil", nil),
+	)
+	exp := `{"str":"\u003ca\u003e","level":"WARN","names":["a","b"],"nested":{"ints":[1,2]},"nil":null}`
+
+	b := m.AppendJSON([]byte("prefix "))
+	assert.Equal(t, "JSON", "prefix "+exp, string(b))
+
+	b, err := json.Marshal(m)
+	assert.Success(t, "marshal", err)
+	assert.Equal(t, "JSON", exp, string(b))
+}
+
+type ptrMarshaler struct {
+	n int
+}
+
+func (m *ptrMarshaler) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf(`{"n":%v}`, m.n)), nil
+}
+
+func TestMap_marshalerPassthrough(t *testing.T) {
+	t.Parallel()
+
+	b := slog.M(
+		slog.F("raw", json.RawMessage(`{"a": [1, 2],
+			"b": "<c>"}`)),
+		slog.F("nilRaw", json.RawMessage(nil)),
+		slog.F("badRaw", json.RawMessage(`{`)),
+		slog.F("ptr", ptrMarshaler{n: 1}),
+		slog.F("ptr2", &ptrMarshaler{n: 2}),
+	).AppendJSON(nil)
+	assert.Equal(t, "JSON", `{"raw":{"a":[1,2],"b":"<c>"},"nilRaw":null,"badRaw":"{","ptr":{"n":1},"ptr2":{"n":2}}`, string(b))
+}
@@ -0,0 +1,70 @@
+package slog
+
+import (
+	"context"
+)
+
+// Middleware wraps a Sink to add cross cutting behaviour
+// such as redaction, sampling or metrics.
+type Middleware func(Sink) Sink
+
+// Chain composes the middlewares into one.
+//
+// The first middleware is the outermost and so sees
+// every entry first.
+func Chain(mws ...Middleware) Middleware {
+	mws = append([]Middleware(nil), mws...)
+	return func(s Sink) Sink {
+		for i := len(mws) - 1; i >= 0; i-- {
+			s = mws[i](s)
+		}
+		return s
+	}
+}
+
+// Intercept returns a Middleware that calls fn for every entry
+// with the wrapped Sink as next.
+//
+// fn must call next.LogEntry itself for the entry to be logged.
+// Sync is forwarded to next.
+func Intercept(fn func(ctx context.Context, e SinkEntry, next Sink)) Middleware {
+	return func(next Sink) Sink {
+		return interceptSink{
+			next: next,
+			fn:   fn,
+		}
+	}
+}
+
+type interceptSink struct {
+	next Sink
+	fn   func(ctx context.Context, e SinkEntry, next Sink)
+}
+
+func (s interceptSink) LogEntry(ctx context.Context, e SinkEntry) {
+	s.fn(ctx, e, s.next)
+}
+
+func (s interceptSink) Sync() {
+	s.next.Sync()
+}
+
+func (s interceptSink) Close() error {
+	return closeSinks([]Sink{s.next})
+}
+
+// Transform returns a Sink that passes every entry through fn
+// before logging it to s.
+//
+// fn may rewrite the message, add or remove fields, or return
+// false to drop the entry. fn must not modify the Fields or
+// LoggerNames slices in place as they may be shared with other
+// entries; assign new slices instead.
+func Transform(s Sink, fn func(e SinkEntry) (SinkEntry, bool)) Sink {
+	return Intercept(func(ctx context.Context, e SinkEntry, next Sink) {
+		e, ok := fn(e)
+		if ok {
+			next.LogEntry(ctx, e)
+		}
+	})(s)
+}
@@ -0,0 +1,60 @@
+package slog_test
+
+import (
+	"context"
+	"testing"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+)
+
+func TestChain(t *testing.T) {
+	t.Parallel()
+
+	appendMsg := func(suffix string) slog.Middleware {
+		return slog.Intercept(func(ctx context.Context, e slog.SinkEntry, next slog.Sink) {
+			e.Message += suffix
+			next.LogEntry(ctx, e)
+		})
+	}
+	drop := slog.Intercept(func(ctx context.Context, e slog.SinkEntry, next slog.Sink) {
+		if e.Message != "drop" {
+			next.LogEntry(ctx, e)
+		}
+	})
+
+	s := &fakeSink{}
+	mw := slog.Chain(drop, appendMsg("1"), appendMsg("2"))
+	l := slog.Make(mw(s))
+
+	l.Info(bg, "drop")
+	l.Info(bg, "msg")
+	l.Sync()
+
+	assert.Len(t, "entries", 1, s.entries)
+	assert.Equal(t, "msg", "msg12", s.entries[0].Message)
+	assert.Equal(t, "syncs", 1, s.syncs)
+}
+
+func TestTransform(t *testing.T) {
+	t.Parallel()
+
+	s := &fakeSink{}
+	l := slog.Make(slog.Transform(s, func(e slog.SinkEntry) (slog.SinkEntry, bool) {
+		if e.Message == "drop" {
+			return e, false
+		}
+		e.Message = "[" + e.Message + "]"
+		e.Fields = append(slog.M(slog.F("region", "us")), e.Fields...)
+		return e, true
+	}))
+
+	l.Info(bg, "drop")
+	l.Info(bg, "msg", slog.F("a", 1))
+	l.Sync()
+
+	assert.Len(t, "entries", 1, s.entries)
+	assert.Equal(t, "msg", "[msg]", s.entries[0].Message)
+	assert.Equal(t, "fields", slog.M(slog.F("region", "us"), slog.F("a", 1)), s.entries[0].Fields)
+	assert.Equal(t, "syncs", 1, s.syncs)
+}
@@ -0,0 +1,54 @@
+package slog
+
+import (
+	"context"
+)
+
+// Route sends entries between Min and Max inclusive to Sink.
+type Route struct {
+	Min  Level
+	Max  Level
+	Sink Sink
+}
+
+// Router returns a Sink that logs every entry to the sinks
+// of all routes matching its level.
+//
+// For example, to send debug and info logs to stdout,
+// warnings and errors to stderr and critical logs to a
+// paging sink:
+//
+//	slog.Router(
+//		slog.Route{Min: slog.LevelDebug, Max: slog.LevelInfo, Sink: sloghuman.Sink(os.Stdout)},
+//		slog.Route{Min: slog.LevelWarn, Max: slog.LevelError, Sink: sloghuman.Sink(os.Stderr)},
+//		slog.Route{Min: slog.LevelCritical, Max: slog.LevelFatal, Sink: pager},
+//	)
+//
+// Sync and Close are called on the sink of every route.
+func Router(routes ...Route) Sink {
+	return routerSink(append([]Route(nil), routes...))
+}
+
+type routerSink []Route
+
+func (s routerSink) LogEntry(ctx context.Context, e SinkEntry) {
+	for _, r := range s {
+		if e.Level >= r.Min && e.Level <= r.Max {
+			r.Sink.LogEntry(ctx, e)
+		}
+	}
+}
+
+func (s routerSink) Sync() {
+	for _, r := range s {
+		r.Sink.Sync()
+	}
+}
+
+func (s routerSink) Close() error {
+	sinks := make([]Sink, 0, len(s))
+	for _, r := range s {
+		sinks = append(sinks, r.Sink)
+	}
+	return closeSinks(sinks)
+}
@@ -0,0 +1,38 @@
+package slog_test
+
+import (
+	"testing"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+)
+
+func TestRouter(t *testing.T) {
+	t.Parallel()
+
+	stdout := &fakeSink{}
+	stderr := &fakeSink{}
+	pager := &fakeSink{}
+	all := &fakeSink{}
+	l := slog.Make(slog.Router(
+		slog.Route{Min: slog.LevelDebug, Max: slog.LevelInfo, Sink: stdout},
+		slog.Route{Min: slog.LevelWarn, Max: slog.LevelError, Sink: stderr},
+		slog.Route{Min: slog.LevelCritical, Max: slog.LevelFatal, Sink: pager},
+		slog.Route{Min: slog.LevelDebug, Max: slog.LevelFatal, Sink: all},
+	)).Leveled(slog.LevelDebug)
+
+	l.Debug(bg, "")
+	l.Info(bg, "")
+	l.Warn(bg, "")
+	l.Error(bg, "")
+	l.Critical(bg, "")
+	l.Sync()
+
+	assert.Len(t, "stdout", 2, stdout.entries)
+	assert.Len(t, "stderr", 2, stderr.entries)
+	assert.Len(t, "pager", 1, pager.entries)
+	assert.Len(t, "all", 5, all.entries)
+	assert.Equal(t, "level", slog.LevelWarn, stderr.entries[0].Level)
+	// Error and Critical sync as well.
+	assert.Equal(t, "syncs", 3, pager.syncs)
+}
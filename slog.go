@@ -11,11 +11,16 @@
 package slog // import "cdr.dev/slog"
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"runtime"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.opencensus.io/trace"
@@ -31,12 +36,22 @@ type Sink interface {
 	Sync()
 }
 
+// BatchSink is a Sink that can efficiently log many entries at once.
+//
+// Sinks that pay a high cost per write such as those that write
+// over the network should implement it. See the slogbatch slogger
+// for accumulating entries into batches.
+type BatchSink interface {
+	Sink
+	LogEntries(ctx context.Context, ents []SinkEntry)
+}
+
 // Log logs the given entry with the context to the
 // underlying sinks.
 //
 // It extends the entry with the set fields and names.
 func (l Logger) Log(ctx context.Context, e SinkEntry) {
-	if e.Level < l.level {
+	if e.Level < l.minLevel() {
 		return
 	}
 
@@ -55,18 +70,83 @@ func (l Logger) Sync() {
 	}
 }
 
+// Close syncs and then closes all the underlying sinks
+// that implement io.Closer.
+//
+// Call it before the program exits to ensure buffered
+// entries are written. If more than one sink fails
+// to close, a CloseError with all the errors is returned.
+func (l Logger) Close() error {
+	l.Sync()
+	return closeSinks(l.sinks)
+}
+
+func closeSinks(sinks []Sink) error {
+	var errs CloseError
+	for _, s := range sinks {
+		c, ok := s.(io.Closer)
+		if !ok {
+			continue
+		}
+		err := c.Close()
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	return errs
+}
+
+// CloseError is returned when more than one sink
+// fails to close. It contains every error in the
+// order of the sinks.
+type CloseError []error
+
+func (e CloseError) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("failed to close %v sinks: %v", len(e), strings.Join(msgs, "; "))
+}
+
 // Logger wraps Sink with a nice API to log entries.
 //
 // Logger is safe for concurrent use.
+//
+// The zero value is a valid Logger that discards every entry.
 type Logger struct {
-	sinks []Sink
-	level Level
+	sinks       []Sink
+	level       Level
+	atomicLevel *AtomicLevel
 
 	names  []string
 	fields Map
 
 	skip int
 	exit func(int)
+
+	stacktrace      bool
+	stacktraceLevel Level
+
+	goroutineID bool
+	seq         *uint64
+
+	clock Clock
+}
+
+// Discard returns a Logger that discards every entry.
+//
+// Use it to satisfy APIs that require a Logger when the logs
+// are not needed. It is equivalent to the zero value.
+// Fatal and Panic still exit and panic respectively.
+func Discard() Logger {
+	return Logger{}
 }
 
 // Make creates a logger that writes logs to the passed sinks at LevelInfo.
@@ -110,6 +190,16 @@ func (l Logger) Critical(ctx context.Context, msg string, fields ...Field) {
 	l.Sync()
 }
 
+// Panic logs the msg and fields at LevelCritical.
+//
+// It will then Sync() and panic with msg.
+func (l Logger) Panic(ctx context.Context, msg string, fields ...Field) {
+	l.log(ctx, LevelCritical, msg, fields)
+	l.Sync()
+
+	panic(msg)
+}
+
 // Fatal logs the msg and fields at LevelFatal.
 //
 // It will then Sync() and os.Exit(1).
@@ -140,14 +230,95 @@ func (l Logger) Named(name string) Logger {
 	return l
 }
 
+// WithCallerSkip returns a Logger that skips n additional
+// stack frames when determining the caller of a log method.
+//
+// It is useful for wrappers around Logger where slog.Helper
+// cannot be used. To set the location of a single entry,
+// pass it to Log directly.
+func (l Logger) WithCallerSkip(n int) Logger {
+	l.skip += n
+	return l
+}
+
+// WithStacktrace returns a Logger that adds the stack of the
+// caller as the "stacktrace" field to entries equal to or
+// above the given level.
+func (l Logger) WithStacktrace(level Level) Logger {
+	l.stacktrace = true
+	l.stacktraceLevel = level
+	return l
+}
+
+// WithProcessInfo returns a Logger that adds the "pid" and
+// "hostname" fields to every entry as well as the "goroutine"
+// field with the ID of the logging goroutine.
+//
+// Use it to correlate logs from many processes and goroutines.
+func (l Logger) WithProcessInfo() Logger {
+	hostname, _ := os.Hostname()
+	l = l.With(
+		F("pid", os.Getpid()),
+		F("hostname", hostname),
+	)
+	l.goroutineID = true
+	return l
+}
+
+// WithClock returns a Logger that uses c to set the time
+// of entries. The default is time.Now in UTC.
+func (l Logger) WithClock(c Clock) Logger {
+	l.clock = c
+	return l
+}
+
+// WithSequence returns a Logger that adds the "seq" field
+// to every entry with a monotonically increasing number
+// starting at 1.
+//
+// The counter is shared by all Loggers derived from the
+// returned Logger so consumers can detect dropped or
+// reordered entries. Entries below the Logger's level do
+// not consume a number.
+func (l Logger) WithSequence() Logger {
+	l.seq = new(uint64)
+	return l
+}
+
 // Leveled returns a Logger that only logs entries
 // equal to or above the given level.
 func (l Logger) Leveled(level Level) Logger {
 	l.level = level
+	l.atomicLevel = nil
 	l.sinks = append([]Sink(nil), l.sinks...)
 	return l
 }
 
+// AtomicLeveled returns a Logger that only logs entries
+// equal to or above the current level of lvl.
+//
+// lvl may be shared between loggers and changed at runtime.
+func (l Logger) AtomicLeveled(lvl *AtomicLevel) Logger {
+	l.atomicLevel = lvl
+	l.sinks = append([]Sink(nil), l.sinks...)
+	return l
+}
+
+// Enabled reports whether an entry at level would be logged.
+//
+// Use it to avoid building expensive fields for entries
+// that will be discarded.
+func (l Logger) Enabled(ctx context.Context, level Level) bool {
+	return len(l.sinks) > 0 && level >= l.minLevel()
+}
+
+func (l Logger) minLevel() Level {
+	if l.atomicLevel != nil {
+		return l.atomicLevel.Level()
+	}
+	return l.level
+}
+
 // AppendSinks appends the sinks to the set sink
 // targets on the logger.
 func (l Logger) AppendSinks(s ...Sink) Logger {
@@ -156,19 +327,31 @@ func (l Logger) AppendSinks(s ...Sink) Logger {
 }
 
 func (l Logger) log(ctx context.Context, level Level, msg string, fields Map) {
+	if len(l.sinks) == 0 || level < l.minLevel() {
+		return
+	}
 	ent := l.entry(ctx, level, msg, fields)
 	l.Log(ctx, ent)
 }
 
 func (l Logger) entry(ctx context.Context, level Level, msg string, fields Map) SinkEntry {
 	ent := SinkEntry{
-		Time:        time.Now().UTC(),
+		Time:        l.now(),
 		Level:       level,
 		Message:     msg,
 		Fields:      fieldsFromContext(ctx).append(fields),
 		SpanContext: trace.FromContext(ctx).SpanContext(),
 	}
 	ent = ent.fillLoc(l.skip + 3)
+	if l.seq != nil {
+		ent.Fields = append(ent.Fields, F("seq", atomic.AddUint64(l.seq, 1)))
+	}
+	if l.goroutineID {
+		ent.Fields = append(ent.Fields, F("goroutine", goroutineID()))
+	}
+	if l.stacktrace && level >= l.stacktraceLevel {
+		ent.Fields = append(ent.Fields, F("stacktrace", stacktrace(l.skip+3)))
+	}
 	return ent
 }
 
@@ -209,6 +392,48 @@ func (ent SinkEntry) fillLoc(skip int) SinkEntry {
 	}
 }
 
+// goroutineID parses the ID of the current goroutine from
+// the header of its stack trace, e.g. "goroutine 18 [running]:".
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	i := bytes.IndexByte(b, ' ')
+	if i < 0 {
+		return 0
+	}
+	id, _ := strconv.ParseUint(string(b[:i]), 10, 64)
+	return id
+}
+
+// stacktrace formats the stack of the caller like debug.Stack
+// but starting at the first frame that isn't a helper.
+func stacktrace(skip int) string {
+	const maxStackLen = 64
+	var pc [maxStackLen]uintptr
+
+	n := runtime.Callers(skip+2, pc[:])
+	frames := runtime.CallersFrames(pc[:n])
+
+	var b strings.Builder
+	inHelpers := true
+	for {
+		frame, more := frames.Next()
+		if inHelpers {
+			_, helper := helpers.Load(frame.Function)
+			if helper && more {
+				continue
+			}
+			inHelpers = false
+		}
+
+		fmt.Fprintf(&b, "%v\n\t%v:%v\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			return strings.TrimSuffix(b.String(), "\n")
+		}
+	}
+}
+
 func location(skip int) (file string, line int, fn string) {
 	pc, file, line, _ := runtime.Caller(skip + 1)
 	f := runtime.FuncForPC(pc)
@@ -226,9 +451,18 @@ func appendNames(names []string, names2 ...string) []string {
 }
 
 // Field represents a log field.
+//
+// Fields created with the typed constructors like String and Int
+// store their value inline instead of in Value so that they can be
+// created and encoded without allocating. Use Any to read the value
+// of any Field.
 type Field struct {
 	Name  string
 	Value interface{}
+
+	kind fieldKind
+	num  uint64
+	str  string
 }
 
 // F is a convenience constructor for Field.
@@ -241,11 +475,22 @@ func M(fs ...Field) Map {
 	return fs
 }
 
+// Group returns a Field that nests the given fields under name.
+func Group(name string, fields ...Field) Field {
+	return F(name, M(fields...))
+}
+
 // Error is the standard key used for logging a Go error value.
 func Error(err error) Field {
 	return F("error", err)
 }
 
+// Err is a typed constructor for the standard error Field.
+// It is equivalent to Error.
+func Err(err error) Field {
+	return Error(err)
+}
+
 type fieldsKey struct{}
 
 func fieldsWithContext(ctx context.Context, fields Map) context.Context {
@@ -293,9 +538,15 @@ type Level int
 // The supported log levels.
 //
 // The default level is Info.
+//
+// The levels are spaced 10 apart to leave room for custom
+// levels registered with RegisterLevel. This changed their
+// numeric values which used to be 0 through 5, e.g. LevelInfo
+// was 1 and is now 10. Code that stores or compares the numeric
+// values instead of using the constants must be updated.
 const (
 	// LevelDebug is used for development and debugging messages.
-	LevelDebug Level = iota
+	LevelDebug Level = iota * 10
 
 	// LevelInfo is used for normal informational messages.
 	LevelInfo
@@ -323,9 +574,38 @@ var levelStrings = map[Level]string{
 	LevelFatal:    "FATAL",
 }
 
+var levelStringsMu sync.RWMutex
+
+// RegisterLevel registers a custom level with the given name.
+//
+// Levels are ordered by their value. For example, a level registered
+// as LevelDebug-5 is more verbose than LevelDebug and one registered
+// as LevelInfo+5 sits between LevelInfo and LevelWarn.
+//
+// It should be called during initialization and will panic
+// if the level has already been registered or if the name
+// matches the name of another level case insensitively as
+// ParseLevel could not tell them apart.
+func RegisterLevel(level Level, name string) {
+	levelStringsMu.Lock()
+	defer levelStringsMu.Unlock()
+
+	if s, ok := levelStrings[level]; ok {
+		panic(fmt.Sprintf("slog: level %v already registered as %q", int(level), s))
+	}
+	for l, s := range levelStrings {
+		if strings.EqualFold(s, name) {
+			panic(fmt.Sprintf("slog: level name %q already registered for level %v as %q", name, int(l), s))
+		}
+	}
+	levelStrings[level] = name
+}
+
 // String implements fmt.Stringer.
 func (l Level) String() string {
+	levelStringsMu.RLock()
 	s, ok := levelStrings[l]
+	levelStringsMu.RUnlock()
 	if !ok {
 		return fmt.Sprintf("slog.Level(%v)", int(l))
 	}
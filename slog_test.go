@@ -149,6 +149,22 @@ func TestLogger(t *testing.T) {
 		assert.Equal(t, "level", slog.LevelFatal, s.entries[5].Level)
 		assert.Equal(t, "exits", 1, exits)
 	})
+
+	t.Run("panic", func(t *testing.T) {
+		t.Parallel()
+
+		s := &fakeSink{}
+		l := slog.Make(s)
+
+		defer func() {
+			assert.Equal(t, "recovered", "oops", recover())
+			assert.Len(t, "entries", 1, s.entries)
+			assert.Equal(t, "level", slog.LevelCritical, s.entries[0].Level)
+			assert.Equal(t, "syncs", 1, s.syncs)
+		}()
+
+		l.Panic(bg, "oops")
+	})
 }
 
 func TestLevel_String(t *testing.T) {
@@ -156,3 +172,130 @@ func TestLevel_String(t *testing.T) {
 
 	assert.Equal(t, "level string", "slog.Level(12)", slog.Level(12).String())
 }
+
+func TestLogger_WithCallerSkip(t *testing.T) {
+	t.Parallel()
+
+	s := &fakeSink{}
+	l := slog.Make(s).WithCallerSkip(1)
+	logWrapper := func(msg string) {
+		l.Info(bg, msg)
+	}
+
+	logWrapper("hi")
+
+	assert.Len(t, "entries", 1, s.entries)
+	assert.Equal(t, "file", slogTestFile, s.entries[0].File)
+	assert.Equal(t, "line", 185, s.entries[0].Line)
+}
+
+func TestLogger_WithStacktrace(t *testing.T) {
+	t.Parallel()
+
+	s := &fakeSink{}
+	l := slog.Make(s).WithStacktrace(slog.LevelError)
+
+	l.Warn(bg, "no stack")
+	l.Error(bg, "stack", slog.F("a", 1))
+
+	assert.Len(t, "entries", 2, s.entries)
+	assert.Len(t, "fields", 0, s.entries[0].Fields)
+	assert.Len(t, "fields", 2, s.entries[1].Fields)
+
+	f := s.entries[1].Fields[1]
+	assert.Equal(t, "name", "stacktrace", f.Name)
+	exp := "cdr.dev/slog_test.TestLogger_WithStacktrace\n\t" + slogTestFile + ":199\n"
+	st := f.Any().(string)
+	assert.Equal(t, "stack start", exp, st[:len(exp)])
+}
+
+func TestLogger_Enabled(t *testing.T) {
+	t.Parallel()
+
+	l := slog.Make(&fakeSink{})
+	assert.False(t, "debug", l.Enabled(bg, slog.LevelDebug))
+	assert.True(t, "info", l.Enabled(bg, slog.LevelInfo))
+
+	l = l.Leveled(slog.LevelDebug)
+	assert.True(t, "debug", l.Enabled(bg, slog.LevelDebug))
+
+	var discard slog.Logger
+	assert.False(t, "no sinks", discard.Enabled(bg, slog.LevelFatal))
+}
+
+type closeSink struct {
+	fakeSink
+	closes int
+	err    error
+}
+
+func (s *closeSink) Close() error {
+	s.closes++
+	return s.err
+}
+
+func TestLogger_Close(t *testing.T) {
+	t.Parallel()
+
+	s1 := &closeSink{}
+	s2 := &closeSink{err: io.ErrClosedPipe}
+	s3 := &fakeSink{}
+	l := slog.Make(s1, slog.Tee(slog.LevelFilter(s2, slog.LevelError), s3))
+	l.Info(bg, "hi")
+
+	err := l.Close()
+	assert.Equal(t, "err", io.ErrClosedPipe, err)
+	assert.Equal(t, "syncs", 1, s1.syncs)
+	assert.Equal(t, "closes", 1, s1.closes)
+	assert.Equal(t, "closes", 1, s2.closes)
+	assert.Equal(t, "syncs", 1, s3.syncs)
+}
+
+func TestLogger_WithProcessInfo(t *testing.T) {
+	t.Parallel()
+
+	s := &fakeSink{}
+	l := slog.Make(s).WithProcessInfo()
+	l.Info(bg, "hi", slog.F("a", 1))
+
+	fields := s.entries[0].Fields
+	assert.Len(t, "fields", 4, fields)
+	assert.Equal(t, "pid", "pid", fields[0].Name)
+	assert.True(t, "pid", fields[0].Any().(int) > 0)
+	assert.Equal(t, "hostname", "hostname", fields[1].Name)
+	assert.Equal(t, "a", slog.F("a", 1), fields[2])
+	assert.Equal(t, "goroutine", "goroutine", fields[3].Name)
+	assert.True(t, "goroutine id", fields[3].Any().(uint64) > 0)
+}
+
+func TestLogger_WithSequence(t *testing.T) {
+	t.Parallel()
+
+	s := &fakeSink{}
+	l := slog.Make(s).WithSequence()
+	l2 := l.Named("l2")
+
+	l.Info(bg, "")
+	l.Debug(bg, "")
+	l2.Info(bg, "")
+	slog.Make(s).WithSequence().Info(bg, "")
+
+	assert.Len(t, "entries", 3, s.entries)
+	assert.Equal(t, "seq", slog.M(slog.F("seq", uint64(1))), s.entries[0].Fields)
+	assert.Equal(t, "seq", slog.M(slog.F("seq", uint64(2))), s.entries[1].Fields)
+	assert.Equal(t, "seq", slog.M(slog.F("seq", uint64(1))), s.entries[2].Fields)
+}
+
+func TestDiscard(t *testing.T) {
+	t.Parallel()
+
+	for _, l := range []slog.Logger{slog.Discard(), {}} {
+		l = l.Named("named").With(slog.F("a", 1)).WithSequence()
+		assert.False(t, "enabled", l.Enabled(bg, slog.LevelCritical))
+		l.Debug(bg, "")
+		l.Error(bg, "")
+		l.Sync()
+		assert.Success(t, "close", l.Close())
+		l.Sugar().Infof(bg, "%v", 1)
+	}
+}
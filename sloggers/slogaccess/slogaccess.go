@@ -0,0 +1,222 @@
+// Package slogaccess contains an HTTP middleware that logs requests
+// and the slogger that writes them in the Apache access log formats.
+//
+// Some tools like fail2ban, awstats and legacy log parsers require
+// these exact formats.
+//
+// Format
+//
+//	127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326 "http://www.example.com/start.html" "Mozilla/4.08"
+//
+// See https://httpd.apache.org/docs/current/logs.html#accesslog
+package slogaccess // import "cdr.dev/slog/sloggers/slogaccess"
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/syncwriter"
+)
+
+// Handler returns an http.Handler that logs every request
+// served by next at LevelInfo with the message "request"
+// and the following fields:
+//
+//	remote_addr  the host of the client
+//	user         the user of basic authentication
+//	method       the request method
+//	uri          the request URI
+//	proto        the protocol, e.g. HTTP/1.1
+//	status       the status code of the response
+//	bytes        the length of the response body
+//	referer      the Referer header
+//	user_agent   the User-Agent header
+//
+// Empty fields are omitted.
+func Handler(l slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &responseWriter{
+			ResponseWriter: w,
+		}
+		next.ServeHTTP(rw, r)
+		if rw.status == 0 {
+			rw.status = http.StatusOK
+		}
+
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		user, _, _ := r.BasicAuth()
+
+		fields := make([]slog.Field, 0, 9)
+		add := func(name, v string) {
+			if v != "" {
+				fields = append(fields, slog.F(name, v))
+			}
+		}
+		add("remote_addr", host)
+		add("user", user)
+		add("method", r.Method)
+		add("uri", r.RequestURI)
+		add("proto", r.Proto)
+		fields = append(fields,
+			slog.F("status", rw.status),
+			slog.F("bytes", rw.bytes),
+		)
+		add("referer", r.Referer())
+		add("user_agent", r.UserAgent())
+
+		l.Info(r.Context(), "request", fields...)
+	})
+}
+
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += int64(n)
+	return n, err
+}
+
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, xerrors.New("response writer does not support hijacking")
+	}
+	return h.Hijack()
+}
+
+// Format is an Apache access log format.
+type Format int
+
+// The supported formats.
+const (
+	// FormatCommon is the Common Log Format:
+	//	%h %l %u %t "%r" %>s %b
+	FormatCommon Format = iota
+	// FormatCombined is the Combined Log Format which adds
+	// the referer and user agent to the Common Log Format:
+	//	%h %l %u %t "%r" %>s %b "%{Referer}i" "%{User-agent}i"
+	FormatCombined
+)
+
+// Sink creates a slog.Sink that writes the entries logged by
+// Handler to the given writer in the given format. Entries
+// without a method field are ignored so that the sink can
+// be used alongside others.
+//
+// If the writer implements Sync() error then
+// it will be called when syncing.
+func Sink(w io.Writer, format Format) slog.Sink {
+	return accessSink{
+		w:      syncwriter.New(w),
+		format: format,
+	}
+}
+
+type accessSink struct {
+	w      *syncwriter.Writer
+	format Format
+}
+
+func (s accessSink) LogEntry(ctx context.Context, ent slog.SinkEntry) {
+	fields := make(map[string]string, len(ent.Fields))
+	for _, f := range ent.Fields {
+		fields[f.Name] = fmt.Sprint(f.Any())
+	}
+	if fields["method"] == "" {
+		return
+	}
+
+	b := make([]byte, 0, 256)
+	b = appendValue(b, fields["remote_addr"])
+	b = append(b, " - "...)
+	b = appendValue(b, fields["user"])
+	b = append(b, " ["...)
+	b = ent.Time.AppendFormat(b, "02/Jan/2006:15:04:05 -0700")
+	b = append(b, "] \""...)
+	b = appendEscaped(b, fields["method"]+" "+fields["uri"]+" "+fields["proto"])
+	b = append(b, "\" "...)
+	b = appendValue(b, fields["status"])
+	b = append(b, ' ')
+	if fields["bytes"] == "0" {
+		b = append(b, '-')
+	} else {
+		b = appendValue(b, fields["bytes"])
+	}
+	if s.format == FormatCombined {
+		b = append(b, " \""...)
+		b = appendValue(b, fields["referer"])
+		b = append(b, "\" \""...)
+		b = appendValue(b, fields["user_agent"])
+		b = append(b, '"')
+	}
+	b = append(b, '\n')
+
+	err := s.w.Write(ctx, "slogaccess", b)
+	if err != nil {
+		slog.ReportError(ctx, err, ent)
+	}
+}
+
+// appendValue appends v escaped or - if it is empty.
+func appendValue(b []byte, v string) []byte {
+	if v == "" {
+		return append(b, '-')
+	}
+	return appendEscaped(b, v)
+}
+
+// appendEscaped escapes quotes, backslashes and non printable
+// characters like Apache to keep every entry on one line.
+func appendEscaped(b []byte, v string) []byte {
+	for i := 0; i < len(v); i++ {
+		c := v[i]
+		switch {
+		case c == '"' || c == '\\':
+			b = append(b, '\\', c)
+		case c < 0x20 || c >= 0x7f:
+			b = append(b, `\x`...)
+			if c < 0x10 {
+				b = append(b, '0')
+			}
+			b = strconv.AppendUint(b, uint64(c), 16)
+		default:
+			b = append(b, c)
+		}
+	}
+	return b
+}
+
+func (s accessSink) Sync() {
+	s.w.Sync("slogaccess")
+}
@@ -0,0 +1,97 @@
+package slogaccess_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+	"cdr.dev/slog/sloggers/slogaccess"
+)
+
+var bg = context.Background()
+
+var kt = time.Date(2000, time.October, 10, 13, 55, 36, 0, time.FixedZone("", -7*60*60))
+
+type fakeSink struct {
+	entries []slog.SinkEntry
+}
+
+func (s *fakeSink) LogEntry(_ context.Context, e slog.SinkEntry) {
+	s.entries = append(s.entries, e)
+}
+
+func (s *fakeSink) Sync() {}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	s := &fakeSink{}
+	h := slogaccess.Handler(slog.Make(s), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("short and stout"))
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/pot?brew=1", nil)
+	r.SetBasicAuth("frank", "secret")
+	r.Header.Set("User-Agent", "Mozilla/4.08")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	assert.Len(t, "entries", 1, s.entries)
+	assert.Equal(t, "message", "request", s.entries[0].Message)
+	assert.Equal(t, "fields", slog.M(
+		slog.F("remote_addr", "192.0.2.1"),
+		slog.F("user", "frank"),
+		slog.F("method", "GET"),
+		slog.F("uri", "/pot?brew=1"),
+		slog.F("proto", "HTTP/1.1"),
+		slog.F("status", http.StatusTeapot),
+		slog.F("bytes", int64(15)),
+		slog.F("user_agent", "Mozilla/4.08"),
+	), s.entries[0].Fields)
+}
+
+func TestSink(t *testing.T) {
+	t.Parallel()
+
+	ent := slog.SinkEntry{
+		Time:    kt,
+		Message: "request",
+		Fields: slog.M(
+			slog.F("remote_addr", "127.0.0.1"),
+			slog.F("user", "frank"),
+			slog.F("method", "GET"),
+			slog.F("uri", "/apache_pb.gif"),
+			slog.F("proto", "HTTP/1.0"),
+			slog.F("status", 200),
+			slog.F("bytes", int64(2326)),
+			slog.F("referer", "http://www.example.com/start.html"),
+			slog.F("user_agent", `Mozilla/4.08 "quoted"`+"\n"),
+		),
+	}
+
+	b := &bytes.Buffer{}
+	slogaccess.Sink(b, slogaccess.FormatCommon).LogEntry(bg, ent)
+	assert.Equal(t, "common", `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326`+"\n", b.String())
+
+	b.Reset()
+	s := slogaccess.Sink(b, slogaccess.FormatCombined)
+	s.LogEntry(bg, ent)
+	s.LogEntry(bg, slog.SinkEntry{Time: kt, Message: "unrelated"})
+	s.LogEntry(bg, slog.SinkEntry{
+		Time: kt,
+		Fields: slog.M(
+			slog.F("method", "HEAD"),
+			slog.F("uri", "/"),
+			slog.F("proto", "HTTP/1.1"),
+			slog.F("status", 304),
+			slog.F("bytes", int64(0)),
+		),
+	})
+	assert.Equal(t, "combined", `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326 "http://www.example.com/start.html" "Mozilla/4.08 \"quoted\"\x0a"`+"\n"+
+		`- - - [10/Oct/2000:13:55:36 -0700] "HEAD / HTTP/1.1" 304 - "-" "-"`+"\n", b.String())
+}
@@ -0,0 +1,136 @@
+// Package slogadmin contains an HTTP handler that reports
+// and changes log levels at runtime.
+package slogadmin // import "cdr.dev/slog/sloggers/slogadmin"
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+)
+
+// Levels is the JSON document served and accepted by Handler.
+//
+//	{
+//	  "level": "info",
+//	  "components": {
+//	    "default": "warn",
+//	    "comp.db": "debug"
+//	  }
+//	}
+//
+// The "default" component is the default level
+// of the ComponentLevels.
+type Levels struct {
+	Level      string             `json:"level,omitempty"`
+	Components map[string]*string `json:"components,omitempty"`
+}
+
+// Handler returns an http.Handler that reports the levels
+// on GET and changes them on PUT. Either lvl or c may be nil.
+//
+// A PUT only changes the levels present in the document.
+// A component set to null is unset so that its parent's
+// level applies.
+func Handler(lvl *slog.AtomicLevel, c *slog.ComponentLevels) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			err := update(r, lvl, c)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{
+					"error": err.Error(),
+				})
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{
+				"error": "method not allowed",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, levels(lvl, c))
+	})
+}
+
+func levels(lvl *slog.AtomicLevel, c *slog.ComponentLevels) Levels {
+	var v Levels
+	if lvl != nil {
+		v.Level = lvl.String()
+	}
+	if c != nil {
+		v.Components = make(map[string]*string)
+		for name, level := range c.Levels() {
+			if name == "" {
+				name = "default"
+			}
+			s := level.String()
+			v.Components[name] = &s
+		}
+	}
+	return v
+}
+
+func update(r *http.Request, lvl *slog.AtomicLevel, c *slog.ComponentLevels) error {
+	var v Levels
+	err := json.NewDecoder(r.Body).Decode(&v)
+	if err != nil {
+		return xerrors.Errorf("failed to decode levels: %w", err)
+	}
+
+	if v.Level != "" && lvl == nil {
+		return xerrors.New("level cannot be changed")
+	}
+	if len(v.Components) > 0 && c == nil {
+		return xerrors.New("component levels cannot be changed")
+	}
+
+	// Parse everything before changing anything
+	// so that an invalid document has no effect.
+	var level slog.Level
+	if v.Level != "" {
+		level, err = slog.ParseLevel(v.Level)
+		if err != nil {
+			return err
+		}
+	}
+	components := make(map[string]*slog.Level, len(v.Components))
+	for name, s := range v.Components {
+		if name == "default" {
+			name = ""
+		}
+		if s == nil {
+			components[name] = nil
+			continue
+		}
+		level, err := slog.ParseLevel(*s)
+		if err != nil {
+			return xerrors.Errorf("invalid level for component %q: %w", name, err)
+		}
+		components[name] = &level
+	}
+
+	if v.Level != "" {
+		lvl.Set(level)
+	}
+	for name, level := range components {
+		if level == nil {
+			c.Unset(name)
+			continue
+		}
+		c.Set(name, *level)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	b, _ := json.Marshal(v)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(append(b, '\n'))
+}
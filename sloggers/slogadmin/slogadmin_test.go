@@ -0,0 +1,68 @@
+package slogadmin_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+	"cdr.dev/slog/sloggers/slogadmin"
+)
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	lvl := slog.NewAtomicLevel(slog.LevelInfo)
+	c := slog.NewComponentLevels(slog.LevelWarn)
+	c.Set("comp.db", slog.LevelError)
+	h := slogadmin.Handler(lvl, c)
+
+	do := func(method, body string) (int, slogadmin.Levels) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, "/", strings.NewReader(body))
+		h.ServeHTTP(w, r)
+
+		var v slogadmin.Levels
+		if w.Code == http.StatusOK {
+			err := json.Unmarshal(w.Body.Bytes(), &v)
+			assert.Success(t, "unmarshal", err)
+		}
+		return w.Code, v
+	}
+	str := func(s string) *string {
+		return &s
+	}
+
+	code, v := do(http.MethodGet, "")
+	assert.Equal(t, "code", http.StatusOK, code)
+	assert.Equal(t, "levels", slogadmin.Levels{
+		Level: "INFO",
+		Components: map[string]*string{
+			"default": str("WARN"),
+			"comp.db": str("ERROR"),
+		},
+	}, v)
+
+	code, v = do(http.MethodPut, `{"level": "debug", "components": {"comp.db": null, "comp.http": "debug"}}`)
+	assert.Equal(t, "code", http.StatusOK, code)
+	assert.Equal(t, "level", slog.LevelDebug, lvl.Level())
+	assert.Equal(t, "levels", map[string]slog.Level{
+		"":          slog.LevelWarn,
+		"comp.http": slog.LevelDebug,
+	}, c.Levels())
+	assert.Equal(t, "level", "DEBUG", v.Level)
+
+	code, _ = do(http.MethodPut, `{"level": "warn", "components": {"comp.db": "loud"}}`)
+	assert.Equal(t, "code", http.StatusBadRequest, code)
+	assert.Equal(t, "level", slog.LevelDebug, lvl.Level())
+
+	code, _ = do(http.MethodPost, "")
+	assert.Equal(t, "code", http.StatusMethodNotAllowed, code)
+
+	h = slogadmin.Handler(lvl, nil)
+	code, _ = do(http.MethodPut, `{"components": {"comp.db": "info"}}`)
+	assert.Equal(t, "code", http.StatusBadRequest, code)
+}
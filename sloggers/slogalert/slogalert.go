@@ -0,0 +1,274 @@
+// Package slogalert contains the slogger that posts alerts for
+// high severity entries to a webhook such as a Slack incoming webhook.
+//
+// It gives small teams paging without running extra services.
+package slogalert // import "cdr.dev/slog/sloggers/slogalert"
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/entryjson"
+	"cdr.dev/slog/internal/httpretry"
+	"cdr.dev/slog/sloggers/slogjson"
+	"cdr.dev/slog/sloggers/slograte"
+)
+
+// Format is the format of the posted alerts.
+type Format int
+
+// The supported formats.
+const (
+	// FormatSlack posts messages for Slack incoming webhooks
+	// and compatible services like Mattermost.
+	FormatSlack Format = iota
+	// FormatJSON posts the entry as written by slogjson.
+	FormatJSON
+)
+
+// Options represents the options for the sink returned by Sink.
+type Options struct {
+	// URL is the URL of the webhook.
+	URL string
+
+	// Format defaults to FormatSlack.
+	Format Format
+
+	// Level is the minimum level of the entries posted.
+	//
+	// Defaults to slog.LevelError.
+	Level slog.Level
+
+	// DedupWindow is the duration for which alerts with the same
+	// level, logger names and message as a posted alert are
+	// suppressed. The number of suppressed alerts is posted
+	// with the next identical alert after the window.
+	//
+	// Defaults to 10 minutes.
+	DedupWindow time.Duration
+
+	// RateLimit limits the alerts posted.
+	// See slograte.Options.
+	//
+	// Defaults to 10 alerts per minute with a burst of 10.
+	RateLimit *slograte.Options
+
+	// Client defaults to a client with a 30 second timeout.
+	Client *http.Client
+
+	// MaxRetries is the maximum number of retries of an alert
+	// that fails with a network error, 429 or 5xx.
+	//
+	// Defaults to 3.
+	MaxRetries int
+
+	// Backoff is the delay before the first retry. It doubles
+	// for every following retry.
+	//
+	// Defaults to 500ms.
+	Backoff time.Duration
+}
+
+// Sink creates a slog.Sink that posts the entries at or above
+// Level to a webhook. Combine it with a regular sink in slog.Make.
+//
+// Alerts are deduplicated and then rate limited with slograte which
+// posts a warning with the number of dropped alerts.
+//
+// Alerts are posted before LogEntry returns as they are rare and
+// Error and Critical sync the sink anyway.
+func Sink(opts *Options) (slog.Sink, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	if opts.URL == "" {
+		return nil, xerrors.New("URL is required")
+	}
+
+	ps := &postSink{
+		url:    opts.URL,
+		format: opts.Format,
+		header: http.Header{
+			"Content-Type": []string{"application/json"},
+		},
+		opts: httpretry.Options{
+			Client:     opts.Client,
+			MaxRetries: opts.MaxRetries,
+			Backoff:    opts.Backoff,
+		},
+	}
+	if ps.format == FormatJSON {
+		ps.enc = slogjson.Sink(&ps.buf)
+	}
+
+	rateLimit := opts.RateLimit
+	if rateLimit == nil {
+		rateLimit = &slograte.Options{
+			Rate:            10.0 / 60,
+			Burst:           10,
+			SummaryInterval: time.Minute,
+		}
+	}
+
+	ds := &dedupSink{
+		s:      slograte.Sink(ps, rateLimit),
+		window: opts.DedupWindow,
+		seen:   make(map[string]*dup),
+		now:    time.Now,
+	}
+	if ds.window <= 0 {
+		ds.window = 10 * time.Minute
+	}
+
+	level := opts.Level
+	if level == 0 {
+		level = slog.LevelError
+	}
+	return slog.LevelFilter(ds, level), nil
+}
+
+type dup struct {
+	posted     time.Time
+	suppressed int
+}
+
+type dedupSink struct {
+	s      slog.Sink
+	window time.Duration
+
+	mu   sync.Mutex
+	seen map[string]*dup
+
+	now func() time.Time
+}
+
+func (s *dedupSink) LogEntry(ctx context.Context, ent slog.SinkEntry) {
+	suppressed, ok := s.allow(ent)
+	if !ok {
+		return
+	}
+	if suppressed > 0 {
+		ent.Fields = append(ent.Fields[:len(ent.Fields):len(ent.Fields)], slog.F("suppressed", suppressed))
+	}
+	s.s.LogEntry(ctx, ent)
+}
+
+// allow reports whether ent should be posted and how many
+// identical alerts were suppressed before it.
+func (s *dedupSink) allow(ent slog.SinkEntry) (int, bool) {
+	k := ent.Level.String() + "\x00" + strings.Join(ent.LoggerNames, ".") + "\x00" + ent.Message
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.seen[k]
+	if ok && now.Sub(d.posted) < s.window {
+		d.suppressed++
+		return 0, false
+	}
+
+	// Forget expired alerts so that the map stays small.
+	for k2, d2 := range s.seen {
+		if now.Sub(d2.posted) >= s.window && d2.suppressed == 0 {
+			delete(s.seen, k2)
+		}
+	}
+
+	var suppressed int
+	if ok {
+		suppressed = d.suppressed
+	}
+	s.seen[k] = &dup{posted: now}
+	return suppressed, true
+}
+
+func (s *dedupSink) Sync() {
+	s.s.Sync()
+}
+
+func (s *dedupSink) Close() error {
+	if c, ok := s.s.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+type postSink struct {
+	url    string
+	format Format
+	header http.Header
+	opts   httpretry.Options
+
+	mu  sync.Mutex
+	enc slog.Sink
+	buf bytes.Buffer
+}
+
+func (s *postSink) LogEntry(ctx context.Context, ent slog.SinkEntry) {
+	var body []byte
+	if s.format == FormatJSON {
+		body = s.json(ent)
+	} else {
+		body, _ = json.Marshal(map[string]string{
+			"text": slackText(ent),
+		})
+	}
+
+	_, err := httpretry.Do(ctx, s.opts, http.MethodPost, s.url, s.header, body)
+	if err != nil {
+		slog.ReportError(ctx, xerrors.Errorf("slogalert: failed to post alert: %w", err), ent)
+	}
+}
+
+func (s *postSink) json(ent slog.SinkEntry) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.buf.Reset()
+	s.enc.LogEntry(context.Background(), ent)
+	return append([]byte(nil), s.buf.Bytes()...)
+}
+
+func (s *postSink) Sync() {}
+
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// slackText formats ent as Slack mrkdwn:
+//
+//	*ERROR* comp.db: failed to connect
+//	`main.go:62`
+//	• *addr*: localhost:5432
+func slackText(ent slog.SinkEntry) string {
+	var b strings.Builder
+	b.WriteString("*" + ent.Level.String() + "* ")
+	if len(ent.LoggerNames) > 0 {
+		b.WriteString(slackEscaper.Replace(strings.Join(ent.LoggerNames, ".")) + ": ")
+	}
+	b.WriteString(slackEscaper.Replace(ent.Message))
+	if ent.File != "" {
+		b.WriteString("\n`" + slackEscaper.Replace(ent.File) + ":" + strconv.Itoa(ent.Line) + "`")
+	}
+	for _, f := range entryjson.Flatten(ent.Fields, ".") {
+		var v string
+		switch fv := f.Value.(type) {
+		case string:
+			v = fv
+		default:
+			j, _ := json.Marshal(fv)
+			v = string(j)
+		}
+		b.WriteString("\n• *" + slackEscaper.Replace(f.Key) + "*: " + slackEscaper.Replace(v))
+	}
+	return b.String()
+}
@@ -0,0 +1,132 @@
+package slogalert_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+	"cdr.dev/slog/sloggers/slogalert"
+	"cdr.dev/slog/sloggers/slograte"
+)
+
+var bg = context.Background()
+
+var kt = time.Date(2000, time.February, 5, 4, 4, 4, 123456789, time.UTC)
+
+type webhook struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	bodies []map[string]interface{}
+}
+
+func newWebhook(t *testing.T) *webhook {
+	w := &webhook{}
+	w.Server = httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "content type", "application/json", r.Header.Get("Content-Type"))
+		b, err := ioutil.ReadAll(r.Body)
+		assert.Success(t, "read body", err)
+
+		var m map[string]interface{}
+		err = json.Unmarshal(b, &m)
+		assert.Success(t, "unmarshal", err)
+
+		w.mu.Lock()
+		w.bodies = append(w.bodies, m)
+		w.mu.Unlock()
+	}))
+	return w
+}
+
+func (w *webhook) posted() []map[string]interface{} {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.bodies
+}
+
+func TestSink_slack(t *testing.T) {
+	t.Parallel()
+
+	w := newWebhook(t)
+	defer w.Close()
+
+	s, err := slogalert.Sink(&slogalert.Options{
+		URL:         w.URL,
+		DedupWindow: 50 * time.Millisecond,
+	})
+	assert.Success(t, "sink", err)
+
+	ent := slog.SinkEntry{
+		Time:        kt,
+		Level:       slog.LevelError,
+		LoggerNames: []string{"comp", "db"},
+		Message:     "failed to connect <db>",
+		File:        "main.go",
+		Line:        62,
+		Fields: slog.M(
+			slog.F("addr", "localhost:5432"),
+			slog.F("conn", slog.M(slog.F("attempt", 1))),
+		),
+	}
+	s.LogEntry(bg, slog.SinkEntry{Level: slog.LevelWarn, Message: "ignored"})
+	s.LogEntry(bg, ent)
+	assert.Equal(t, "alerts", []map[string]interface{}{{
+		"text": "*ERROR* comp.db: failed to connect &lt;db&gt;\n`main.go:62`\n• *addr*: localhost:5432\n• *conn.attempt*: 1",
+	}}, w.posted())
+
+	// Identical alerts are suppressed within the window.
+	ent.Fields = slog.M(slog.F("addr", "localhost:5433"))
+	s.LogEntry(bg, ent)
+	s.LogEntry(bg, ent)
+	assert.Len(t, "alerts", 1, w.posted())
+
+	time.Sleep(50 * time.Millisecond)
+	ent.Level = slog.LevelCritical
+	s.LogEntry(bg, ent)
+	ent.Level = slog.LevelError
+	s.LogEntry(bg, ent)
+	alerts := w.posted()
+	assert.Len(t, "alerts", 3, alerts)
+	assert.Equal(t, "alert", "*CRITICAL* comp.db: failed to connect &lt;db&gt;\n`main.go:62`\n• *addr*: localhost:5433", alerts[1]["text"])
+	assert.Equal(t, "alert", "*ERROR* comp.db: failed to connect &lt;db&gt;\n`main.go:62`\n• *addr*: localhost:5433\n• *suppressed*: 2", alerts[2]["text"])
+}
+
+func TestSink_json(t *testing.T) {
+	t.Parallel()
+
+	w := newWebhook(t)
+	defer w.Close()
+
+	s, err := slogalert.Sink(&slogalert.Options{
+		URL:    w.URL,
+		Format: slogalert.FormatJSON,
+		RateLimit: &slograte.Options{
+			Rate:  0.001,
+			Burst: 1,
+		},
+	})
+	assert.Success(t, "sink", err)
+
+	s.LogEntry(bg, slog.SinkEntry{Time: kt, Level: slog.LevelError, Message: "1"})
+	s.LogEntry(bg, slog.SinkEntry{Time: kt, Level: slog.LevelError, Message: "2"})
+	s.Sync()
+
+	alerts := w.posted()
+	assert.Len(t, "alerts", 2, alerts)
+	assert.Equal(t, "alert", map[string]interface{}{
+		"ts":     "2000-02-05T04:04:04.123456789Z",
+		"level":  "ERROR",
+		"msg":    "1",
+		"caller": ":0",
+		"func":   "",
+	}, alerts[0])
+	assert.Equal(t, "summary", "dropped entries due to rate limit", alerts[1]["msg"])
+	assert.Equal(t, "dropped", map[string]interface{}{"dropped": 1.0}, alerts[1]["fields"])
+}
@@ -0,0 +1,200 @@
+// Package slogasync contains a slogger that writes entries to
+// another sink from a background goroutine.
+package slogasync // import "cdr.dev/slog/sloggers/slogasync"
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/detach"
+)
+
+// Options represents the options for the sink returned by Sink.
+type Options struct {
+	// BufferSize is the number of entries that can be queued.
+	//
+	// Defaults to 1024.
+	BufferSize int
+
+	// Drop causes entries to be dropped when the queue is full
+	// instead of blocking until there is room or the entry's
+	// context is done.
+	//
+	// Once the queue drains, the number of dropped entries is
+	// written to the sink in a warning with a "dropped" field.
+	Drop bool
+}
+
+// Sink creates a slog.Sink that queues entries and writes them
+// to s from a background goroutine.
+//
+// Sync blocks until every queued entry has been written and s has
+// been synced. Use SyncContext to give up earlier.
+//
+// The returned sink implements io.Closer. Close flushes the queue,
+// stops the goroutine and closes s if it implements io.Closer.
+// Entries logged after Close are written to s directly.
+func Sink(s slog.Sink, opts *Options) slog.Sink {
+	if opts == nil {
+		opts = &Options{}
+	}
+	size := opts.BufferSize
+	if size <= 0 {
+		size = 1024
+	}
+
+	as := &asyncSink{
+		s:    s,
+		drop: opts.Drop,
+		q:    make(chan item, size),
+		done: make(chan struct{}),
+	}
+	go as.run()
+	return as
+}
+
+type item struct {
+	ctx  context.Context
+	ent  slog.SinkEntry
+	sync chan struct{}
+}
+
+type asyncSink struct {
+	s    slog.Sink
+	drop bool
+	// dropped is the number of entries dropped since
+	// the last report. It is accessed atomically.
+	dropped uint64
+
+	mu     sync.RWMutex
+	closed bool
+	q      chan item
+	done   chan struct{}
+}
+
+func (s *asyncSink) run() {
+	defer close(s.done)
+
+	for it := range s.q {
+		if it.sync != nil {
+			s.reportDropped()
+			s.s.Sync()
+			close(it.sync)
+			continue
+		}
+		s.s.LogEntry(it.ctx, it.ent)
+		if len(s.q) == 0 {
+			s.reportDropped()
+		}
+	}
+	s.reportDropped()
+}
+
+func (s *asyncSink) reportDropped() {
+	n := atomic.SwapUint64(&s.dropped, 0)
+	if n == 0 {
+		return
+	}
+	s.s.LogEntry(context.Background(), slog.SinkEntry{
+		Time:    time.Now(),
+		Level:   slog.LevelWarn,
+		Message: "slogasync: dropped entries as the queue was full",
+		Fields:  slog.M(slog.F("dropped", n)),
+	})
+}
+
+func (s *asyncSink) LogEntry(ctx context.Context, ent slog.SinkEntry) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		s.s.LogEntry(ctx, ent)
+		return
+	}
+
+	it := item{
+		ctx: detach.Context(ctx),
+		ent: ent,
+	}
+	if s.drop {
+		select {
+		case s.q <- it:
+		default:
+			atomic.AddUint64(&s.dropped, 1)
+		}
+		return
+	}
+	select {
+	case s.q <- it:
+	case <-ctx.Done():
+		slog.ReportError(ctx, xerrors.Errorf("slogasync: failed to queue entry: %w", ctx.Err()), ent)
+	}
+}
+
+func (s *asyncSink) Sync() {
+	s.syncContext(context.Background())
+}
+
+// SyncContext is like calling Sync on s but returns an error
+// once ctx is done instead of blocking until a full queue has
+// been written. A sync that was already queued is still carried
+// out in the background.
+//
+// s must have been returned by Sink, otherwise s.Sync is called.
+func SyncContext(ctx context.Context, s slog.Sink) error {
+	as, ok := s.(*asyncSink)
+	if !ok {
+		s.Sync()
+		return nil
+	}
+	return as.syncContext(ctx)
+}
+
+func (s *asyncSink) syncContext(ctx context.Context) error {
+	s.mu.RLock()
+	if s.closed {
+		s.mu.RUnlock()
+		s.s.Sync()
+		return nil
+	}
+	synced := make(chan struct{})
+	select {
+	case s.q <- item{sync: synced}:
+	case <-ctx.Done():
+		s.mu.RUnlock()
+		return xerrors.Errorf("slogasync: failed to queue sync: %w", ctx.Err())
+	}
+	s.mu.RUnlock()
+
+	select {
+	case <-synced:
+		return nil
+	case <-ctx.Done():
+		return xerrors.Errorf("slogasync: failed to sync: %w", ctx.Err())
+	}
+}
+
+func (s *asyncSink) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.q)
+	s.mu.Unlock()
+
+	<-s.done
+	s.s.Sync()
+
+	if c, ok := s.s.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
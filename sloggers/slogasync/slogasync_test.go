@@ -0,0 +1,115 @@
+package slogasync_test
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+	"cdr.dev/slog/sloggers/slogasync"
+)
+
+var bg = context.Background()
+
+type fakeSink struct {
+	mu      sync.Mutex
+	entries []slog.SinkEntry
+	syncs   int
+	closes  int
+	block   chan struct{}
+}
+
+func (s *fakeSink) LogEntry(_ context.Context, e slog.SinkEntry) {
+	if s.block != nil {
+		<-s.block
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, e)
+}
+
+func (s *fakeSink) Sync() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.syncs++
+}
+
+func (s *fakeSink) Close() error {
+	s.closes++
+	return nil
+}
+
+func TestSink(t *testing.T) {
+	t.Parallel()
+
+	fs := &fakeSink{}
+	s := slogasync.Sink(fs, nil)
+	l := slog.Make(s)
+
+	for i := 0; i < 100; i++ {
+		l.Info(bg, "hi")
+	}
+	l.Sync()
+	assert.Len(t, "entries", 100, fs.entries)
+	assert.Equal(t, "syncs", 1, fs.syncs)
+
+	l.Info(bg, "hi")
+	err := s.(io.Closer).Close()
+	assert.Success(t, "close", err)
+	assert.Len(t, "entries", 101, fs.entries)
+	assert.Equal(t, "closes", 1, fs.closes)
+
+	l.Info(bg, "after close")
+	assert.Len(t, "entries", 102, fs.entries)
+}
+
+func TestDrop(t *testing.T) {
+	t.Parallel()
+
+	fs := &fakeSink{
+		block: make(chan struct{}),
+	}
+	s := slogasync.Sink(fs, &slogasync.Options{
+		BufferSize: 1,
+		Drop:       true,
+	})
+	l := slog.Make(s)
+
+	for i := 0; i < 10; i++ {
+		l.Info(bg, "hi")
+	}
+	close(fs.block)
+	l.Sync()
+
+	// The last entry reports the dropped entries.
+	n := len(fs.entries) - 1
+	assert.True(t, "dropped", n < 10)
+	assert.Equal(t, "fields", slog.M(slog.F("dropped", uint64(10-n))), fs.entries[n].Fields)
+	assert.Equal(t, "level", slog.LevelWarn, fs.entries[n].Level)
+}
+
+func TestSyncContext(t *testing.T) {
+	t.Parallel()
+
+	fs := &fakeSink{
+		block: make(chan struct{}),
+	}
+	defer close(fs.block)
+	s := slogasync.Sink(fs, &slogasync.Options{
+		BufferSize: 1,
+	})
+	l := slog.Make(s)
+	l.Info(bg, "hi")
+	l.Info(bg, "hi")
+
+	ctx, cancel := context.WithTimeout(bg, time.Millisecond*10)
+	defer cancel()
+	err := slogasync.SyncContext(ctx, s)
+	assert.Error(t, "sync", err)
+	assert.True(t, "deadline", xerrors.Is(err, context.DeadlineExceeded))
+}
@@ -0,0 +1,195 @@
+// Package slogazure contains the slogger that sends entries to
+// Azure Monitor Log Analytics with the HTTP Data Collector API.
+//
+// Entries are encoded with slogjson with flattened fields by default
+// so that every field becomes a column of the custom log table.
+//
+// Microsoft has deprecated the Data Collector API in favor of the
+// Logs Ingestion API but it is still the only API that accepts
+// custom logs with just the workspace key.
+//
+// See https://learn.microsoft.com/azure/azure-monitor/logs/data-collector-api
+package slogazure // import "cdr.dev/slog/sloggers/slogazure"
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/httpretry"
+	"cdr.dev/slog/sloggers/slogbatch"
+	"cdr.dev/slog/sloggers/slogjson"
+)
+
+// Options represents the options for the sink returned by Sink.
+type Options struct {
+	// WorkspaceID is the ID of the Log Analytics workspace.
+	WorkspaceID string
+
+	// SharedKey is the base64 encoded primary or secondary
+	// key of the workspace.
+	SharedKey string
+
+	// LogType is the name of the custom log table without
+	// the _CL suffix that Azure adds. It may only contain
+	// letters, digits and underscores.
+	LogType string
+
+	// TimeField is the key of the time of the entry that Azure
+	// uses as the TimeGenerated column. It must match the
+	// time key of the JSON options.
+	//
+	// Defaults to "ts".
+	TimeField string
+
+	// JSON customizes the encoding of entries.
+	//
+	// Defaults to slogjson.WithFlatFields(slogjson.CollisionPrefix).
+	JSON []slogjson.Option
+
+	// Endpoint defaults to https://<workspace>.ods.opinsights.azure.com.
+	// Set it for sovereign clouds.
+	Endpoint string
+
+	// Client defaults to a client with a 30 second timeout.
+	Client *http.Client
+
+	// MaxRetries is the maximum number of retries of a request
+	// that fails with a network error, 429 or 5xx.
+	//
+	// Defaults to 3.
+	MaxRetries int
+
+	// Backoff is the delay before the first retry. It doubles
+	// for every following retry.
+	//
+	// Defaults to 500ms.
+	Backoff time.Duration
+
+	// Batch configures the batching of entries.
+	// See slogbatch.Options.
+	Batch *slogbatch.Options
+}
+
+var logTypeRegexp = regexp.MustCompile(`^[A-Za-z0-9_]{1,100}$`)
+
+// Sink creates a slog.Sink that posts batches of entries to Log Analytics.
+//
+// The returned sink implements io.Closer. Close posts the
+// remaining entries.
+func Sink(opts *Options) (slog.Sink, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	if opts.WorkspaceID == "" {
+		return nil, xerrors.New("workspace ID is required")
+	}
+	if !logTypeRegexp.MatchString(opts.LogType) {
+		return nil, xerrors.Errorf("invalid log type %q", opts.LogType)
+	}
+	key, err := base64.StdEncoding.DecodeString(opts.SharedKey)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to decode shared key: %w", err)
+	}
+
+	s := &azureSink{
+		workspaceID: opts.WorkspaceID,
+		key:         key,
+		logType:     opts.LogType,
+		timeField:   opts.TimeField,
+		url:         strings.TrimSuffix(opts.Endpoint, "/"),
+		opts: httpretry.Options{
+			Client:     opts.Client,
+			MaxRetries: opts.MaxRetries,
+			Backoff:    opts.Backoff,
+		},
+	}
+	if s.timeField == "" {
+		s.timeField = "ts"
+	}
+	if s.url == "" {
+		s.url = "https://" + s.workspaceID + ".ods.opinsights.azure.com"
+	}
+	s.url += "/api/logs?api-version=2016-04-01"
+
+	jsonOpts := opts.JSON
+	if jsonOpts == nil {
+		jsonOpts = []slogjson.Option{slogjson.WithFlatFields(slogjson.CollisionPrefix)}
+	}
+	s.enc = slogjson.Sink(&s.buf, jsonOpts...)
+	return slogbatch.Sink(s, opts.Batch), nil
+}
+
+type azureSink struct {
+	workspaceID string
+	key         []byte
+	logType     string
+	timeField   string
+	url         string
+	opts        httpretry.Options
+
+	mu  sync.Mutex
+	enc slog.Sink
+	buf bytes.Buffer
+}
+
+func (s *azureSink) LogEntry(ctx context.Context, ent slog.SinkEntry) {
+	s.LogEntries(ctx, []slog.SinkEntry{ent})
+}
+
+func (s *azureSink) LogEntries(ctx context.Context, ents []slog.SinkEntry) {
+	body := s.encode(ents)
+
+	date := time.Now().UTC().Format(http.TimeFormat)
+	header := http.Header{
+		"Content-Type":         []string{"application/json"},
+		"Log-Type":             []string{s.logType},
+		"X-Ms-Date":            []string{date},
+		"Time-Generated-Field": []string{s.timeField},
+		"Authorization":        []string{s.authorization(len(body), date)},
+	}
+	_, err := httpretry.Do(ctx, s.opts, http.MethodPost, s.url, header, body)
+	if err != nil {
+		slog.ReportError(ctx, xerrors.Errorf("slogazure: failed to post %v entries: %w", len(ents), err), ents...)
+	}
+}
+
+// encode returns the JSON array of ents.
+func (s *azureSink) encode(ents []slog.SinkEntry) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b := []byte{'['}
+	for i, ent := range ents {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		s.buf.Reset()
+		s.enc.LogEntry(context.Background(), ent)
+		b = append(b, bytes.TrimSpace(s.buf.Bytes())...)
+	}
+	return append(b, ']')
+}
+
+// authorization returns the SharedKey signature of a request.
+//
+// See https://learn.microsoft.com/azure/azure-monitor/logs/data-collector-api#authorization
+func (s *azureSink) authorization(contentLength int, date string) string {
+	stringToSign := "POST\n" + strconv.Itoa(contentLength) + "\napplication/json\nx-ms-date:" + date + "\n/api/logs"
+	h := hmac.New(sha256.New, s.key)
+	h.Write([]byte(stringToSign))
+	return "SharedKey " + s.workspaceID + ":" + base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func (s *azureSink) Sync() {}
@@ -0,0 +1,79 @@
+package slogazure_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+	"cdr.dev/slog/sloggers/slogazure"
+)
+
+var bg = context.Background()
+
+var kt = time.Date(2000, time.February, 5, 4, 4, 4, 123456789, time.UTC)
+
+func TestSink(t *testing.T) {
+	t.Parallel()
+
+	key := []byte("workspace key")
+	bodies := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "path", "/api/logs", r.URL.Path)
+		assert.Equal(t, "api version", "2016-04-01", r.URL.Query().Get("api-version"))
+		assert.Equal(t, "log type", "App", r.Header.Get("Log-Type"))
+		assert.Equal(t, "time field", "ts", r.Header.Get("Time-Generated-Field"))
+
+		b, err := ioutil.ReadAll(r.Body)
+		assert.Success(t, "read body", err)
+
+		h := hmac.New(sha256.New, key)
+		h.Write([]byte("POST\n" + strconv.Itoa(len(b)) + "\napplication/json\nx-ms-date:" + r.Header.Get("X-Ms-Date") + "\n/api/logs"))
+		assert.Equal(t, "authorization", "SharedKey ws:"+base64.StdEncoding.EncodeToString(h.Sum(nil)), r.Header.Get("Authorization"))
+
+		bodies <- string(b)
+	}))
+	defer srv.Close()
+
+	s, err := slogazure.Sink(&slogazure.Options{
+		WorkspaceID: "ws",
+		SharedKey:   base64.StdEncoding.EncodeToString(key),
+		LogType:     "App",
+		Endpoint:    srv.URL,
+	})
+	assert.Success(t, "sink", err)
+
+	s.LogEntry(bg, slog.SinkEntry{Time: kt, Message: "one", Fields: slog.M(slog.F("id", 1))})
+	s.LogEntry(bg, slog.SinkEntry{Time: kt, Level: slog.LevelWarn, Message: "two"})
+	s.Sync()
+
+	assert.Equal(t, "body", `[`+
+		`{"ts":"2000-02-05T04:04:04.123456789Z","level":"DEBUG","msg":"one","caller":":0","func":"","id":1},`+
+		`{"ts":"2000-02-05T04:04:04.123456789Z","level":"WARN","msg":"two","caller":":0","func":""}]`, <-bodies)
+}
+
+func TestSink_options(t *testing.T) {
+	t.Parallel()
+
+	_, err := slogazure.Sink(&slogazure.Options{
+		WorkspaceID: "ws",
+		SharedKey:   "a2V5",
+		LogType:     "my-app",
+	})
+	assert.Error(t, "sink", err)
+
+	_, err = slogazure.Sink(&slogazure.Options{
+		WorkspaceID: "ws",
+		SharedKey:   "not base64",
+		LogType:     "App",
+	})
+	assert.Error(t, "sink", err)
+}
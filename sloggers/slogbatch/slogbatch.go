@@ -0,0 +1,217 @@
+// Package slogbatch contains a slogger that accumulates entries
+// into batches for sinks that implement slog.BatchSink.
+package slogbatch // import "cdr.dev/slog/sloggers/slogbatch"
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"cdr.dev/slog"
+)
+
+// Options represents the options for the sink returned by Sink.
+type Options struct {
+	// MaxEntries is the maximum number of entries in a batch.
+	//
+	// Defaults to 100.
+	MaxEntries int
+
+	// MaxBytes is the approximate maximum size of a batch in bytes
+	// as measured by the length of the messages and encoded fields.
+	//
+	// Defaults to no limit.
+	MaxBytes int
+
+	// FlushInterval is the maximum duration an entry is held before
+	// its batch is written.
+	//
+	// Defaults to time.Second.
+	FlushInterval time.Duration
+}
+
+// Sink creates a slog.Sink that accumulates entries and writes them
+// to s in batches. A batch is written once it is full, after
+// FlushInterval or on Sync.
+//
+// Batches are written to s from a background goroutine so that
+// logging never waits for s. Sync waits until the entries logged
+// before it have been written and then syncs s.
+//
+// If s implements slog.BatchSink, every batch is passed to LogEntries.
+// Otherwise the entries are passed to s one at a time.
+//
+// Batches are written with context.Background as they contain entries
+// logged with different contexts.
+//
+// The returned sink implements io.Closer. Close writes the remaining
+// entries, stops the background goroutine and closes s if it implements
+// io.Closer.
+func Sink(s slog.Sink, opts *Options) slog.Sink {
+	if opts == nil {
+		opts = &Options{}
+	}
+	bs := &batchSink{
+		s:             s,
+		maxEntries:    opts.MaxEntries,
+		maxBytes:      opts.MaxBytes,
+		flushInterval: opts.FlushInterval,
+		wake:          make(chan struct{}, 1),
+		syncs:         make(chan chan struct{}),
+		closed:        make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	if bs.maxEntries <= 0 {
+		bs.maxEntries = 100
+	}
+	if bs.flushInterval <= 0 {
+		bs.flushInterval = time.Second
+	}
+	go bs.run()
+	return bs
+}
+
+type batchSink struct {
+	s             slog.Sink
+	maxEntries    int
+	maxBytes      int
+	flushInterval time.Duration
+
+	mu    sync.Mutex
+	batch []slog.SinkEntry
+	bytes int
+	// queue holds the batches waiting to be written.
+	queue [][]slog.SinkEntry
+
+	// writeMu ensures batches are written in order.
+	writeMu sync.Mutex
+
+	// wake signals the background goroutine that a batch was queued.
+	wake chan struct{}
+	// syncs receives a channel closed once the queued
+	// batches have been written.
+	syncs chan chan struct{}
+
+	closeOnce sync.Once
+	closeErr  error
+	closed    chan struct{}
+	done      chan struct{}
+}
+
+func (s *batchSink) run() {
+	defer close(s.done)
+
+	t := time.NewTicker(s.flushInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			s.mu.Lock()
+			s.queueLocked()
+			s.mu.Unlock()
+			s.write()
+		case <-s.wake:
+			s.write()
+		case synced := <-s.syncs:
+			s.write()
+			close(synced)
+		case <-s.closed:
+			s.mu.Lock()
+			s.queueLocked()
+			s.mu.Unlock()
+			s.write()
+			return
+		}
+	}
+}
+
+func (s *batchSink) LogEntry(ctx context.Context, ent slog.SinkEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.batch = append(s.batch, ent)
+	if s.maxBytes > 0 {
+		s.bytes += size(ent)
+	}
+	if len(s.batch) >= s.maxEntries || (s.maxBytes > 0 && s.bytes >= s.maxBytes) {
+		s.queueLocked()
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (s *batchSink) Sync() {
+	s.mu.Lock()
+	s.queueLocked()
+	s.mu.Unlock()
+
+	synced := make(chan struct{})
+	select {
+	case s.syncs <- synced:
+		<-synced
+	case <-s.done:
+		// The background goroutine has stopped after Close.
+		s.write()
+	}
+	s.s.Sync()
+}
+
+func (s *batchSink) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.closed)
+		<-s.done
+
+		s.s.Sync()
+
+		if c, ok := s.s.(io.Closer); ok {
+			s.closeErr = c.Close()
+		}
+	})
+	return s.closeErr
+}
+
+// queueLocked queues the current batch to be written.
+func (s *batchSink) queueLocked() {
+	if len(s.batch) == 0 {
+		return
+	}
+	s.queue = append(s.queue, s.batch)
+	s.batch = nil
+	s.bytes = 0
+}
+
+// write writes the queued batches.
+func (s *batchSink) write() {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	for {
+		s.mu.Lock()
+		if len(s.queue) == 0 {
+			s.mu.Unlock()
+			return
+		}
+		batch := s.queue[0]
+		s.queue[0] = nil
+		s.queue = s.queue[1:]
+		s.mu.Unlock()
+
+		ctx := context.Background()
+		if bs, ok := s.s.(slog.BatchSink); ok {
+			bs.LogEntries(ctx, batch)
+			continue
+		}
+		for _, ent := range batch {
+			s.s.LogEntry(ctx, ent)
+		}
+	}
+}
+
+func size(ent slog.SinkEntry) int {
+	fields, _ := ent.Fields.MarshalJSON()
+	return len(ent.Message) + len(fields)
+}
@@ -0,0 +1,135 @@
+package slogbatch_test
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+	"cdr.dev/slog/sloggers/slogbatch"
+)
+
+var bg = context.Background()
+
+type fakeSink struct {
+	mu      sync.Mutex
+	batches [][]slog.SinkEntry
+	syncs   int
+	closes  int
+}
+
+func (s *fakeSink) LogEntry(ctx context.Context, e slog.SinkEntry) {
+	s.LogEntries(ctx, []slog.SinkEntry{e})
+}
+
+func (s *fakeSink) LogEntries(_ context.Context, ents []slog.SinkEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = append(s.batches, ents)
+}
+
+func (s *fakeSink) Sync() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.syncs++
+}
+
+func (s *fakeSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closes++
+	return nil
+}
+
+func (s *fakeSink) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.batches)
+}
+
+func TestSink(t *testing.T) {
+	t.Parallel()
+
+	fs := &fakeSink{}
+	s := slogbatch.Sink(fs, &slogbatch.Options{
+		MaxEntries:    3,
+		FlushInterval: time.Hour,
+	})
+	l := slog.Make(s)
+
+	for i := 0; i < 7; i++ {
+		l.Info(bg, "hi")
+	}
+	l.Sync()
+	assert.Equal(t, "batches", 3, fs.len())
+	assert.Len(t, "batch", 3, fs.batches[0])
+	assert.Len(t, "batch", 3, fs.batches[1])
+	assert.Len(t, "batch", 1, fs.batches[2])
+	assert.Equal(t, "syncs", 1, fs.syncs)
+
+	l.Info(bg, "hi")
+	err := s.(io.Closer).Close()
+	assert.Success(t, "close", err)
+	assert.Equal(t, "batches", 4, fs.len())
+
+	err = s.(io.Closer).Close()
+	assert.Success(t, "close", err)
+	assert.Equal(t, "closes", 1, fs.closes)
+}
+
+type blockSink struct {
+	fakeSink
+	unblock chan struct{}
+}
+
+func (s *blockSink) LogEntries(ctx context.Context, ents []slog.SinkEntry) {
+	<-s.unblock
+	s.fakeSink.LogEntries(ctx, ents)
+}
+
+func TestSink_async(t *testing.T) {
+	t.Parallel()
+
+	bs := &blockSink{unblock: make(chan struct{})}
+	s := slogbatch.Sink(bs, &slogbatch.Options{
+		MaxEntries:    1,
+		FlushInterval: time.Hour,
+	})
+	l := slog.Make(s)
+
+	// Full batches are written in the background
+	// so logging does not wait for the blocked sink.
+	l.Info(bg, "1")
+	l.Info(bg, "2")
+	assert.Equal(t, "batches", 0, bs.len())
+
+	close(bs.unblock)
+	l.Sync()
+	assert.Equal(t, "batches", 2, bs.len())
+	assert.Equal(t, "msg", "1", bs.batches[0][0].Message)
+	assert.Equal(t, "msg", "2", bs.batches[1][0].Message)
+}
+
+func TestFlushInterval(t *testing.T) {
+	t.Parallel()
+
+	fs := &fakeSink{}
+	s := slogbatch.Sink(fs, &slogbatch.Options{
+		MaxBytes:      10,
+		FlushInterval: time.Millisecond,
+	})
+	defer s.(io.Closer).Close()
+	l := slog.Make(s)
+
+	l.Info(bg, "this message is over 10 bytes")
+	l.Sync()
+	assert.Equal(t, "batches", 1, fs.len())
+
+	l.Info(bg, "hi")
+	for fs.len() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,275 @@
+package slogcbor
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog/internal/entryjson"
+)
+
+// The subset of CBOR needed to represent JSON.
+// See https://www.rfc-editor.org/rfc/rfc8949.html
+
+const (
+	majorUint   = 0
+	majorNegInt = 1
+	majorBytes  = 2
+	majorText   = 3
+	majorArray  = 4
+	majorMap    = 5
+	majorTag    = 6
+	majorSimple = 7
+)
+
+func appendHead(b []byte, major byte, n uint64) []byte {
+	major <<= 5
+	switch {
+	case n < 24:
+		return append(b, major|byte(n))
+	case n <= math.MaxUint8:
+		return append(b, major|24, byte(n))
+	case n <= math.MaxUint16:
+		return append(b, major|25, byte(n>>8), byte(n))
+	case n <= math.MaxUint32:
+		b = append(b, major|26)
+		var buf [4]byte
+		binary.BigEndian.PutUint32(buf[:], uint32(n))
+		return append(b, buf[:]...)
+	default:
+		b = append(b, major|27)
+		var buf [8]byte
+		binary.BigEndian.PutUint64(buf[:], n)
+		return append(b, buf[:]...)
+	}
+}
+
+func appendText(b []byte, s string) []byte {
+	b = appendHead(b, majorText, uint64(len(s)))
+	return append(b, s...)
+}
+
+// appendFloat appends f as a single precision float
+// if that is lossless and as a double otherwise.
+func appendFloat(b []byte, f float64) []byte {
+	if f32 := float32(f); float64(f32) == f {
+		b = append(b, majorSimple<<5|26)
+		var buf [4]byte
+		binary.BigEndian.PutUint32(buf[:], math.Float32bits(f32))
+		return append(b, buf[:]...)
+	}
+	b = append(b, majorSimple<<5|27)
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], math.Float64bits(f))
+	return append(b, buf[:]...)
+}
+
+// appendValue appends a value decoded by entryjson.
+func appendValue(b []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case entryjson.Object:
+		b = appendHead(b, majorMap, uint64(len(v)))
+		for _, f := range v {
+			b = appendText(b, f.Key)
+			b = appendValue(b, f.Value)
+		}
+		return b
+	case []interface{}:
+		b = appendHead(b, majorArray, uint64(len(v)))
+		for _, el := range v {
+			b = appendValue(b, el)
+		}
+		return b
+	case string:
+		return appendText(b, v)
+	case json.Number:
+		if i, err := strconv.ParseInt(v.String(), 10, 64); err == nil {
+			if i < 0 {
+				return appendHead(b, majorNegInt, uint64(-1-i))
+			}
+			return appendHead(b, majorUint, uint64(i))
+		}
+		if u, err := strconv.ParseUint(v.String(), 10, 64); err == nil {
+			return appendHead(b, majorUint, u)
+		}
+		f, _ := strconv.ParseFloat(v.String(), 64)
+		return appendFloat(b, f)
+	case bool:
+		if v {
+			return append(b, 0xf5)
+		}
+		return append(b, 0xf4)
+	default:
+		return append(b, 0xf6)
+	}
+}
+
+// maxLength bounds the length of strings and containers
+// so that corrupt input cannot exhaust memory.
+const maxLength = 1 << 30
+
+// readHead reads the head of a data item.
+func readHead(r *bufio.Reader) (major, info byte, n uint64, err error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	major, info = c>>5, c&0x1f
+	switch {
+	case info < 24:
+		return major, info, uint64(info), nil
+	case info <= 27:
+		var buf [8]byte
+		size := 1 << (info - 24)
+		_, err = io.ReadFull(r, buf[8-size:])
+		if err != nil {
+			return 0, 0, 0, unexpectedEOF(err)
+		}
+		return major, info, binary.BigEndian.Uint64(buf[:]), nil
+	default:
+		return 0, 0, 0, xerrors.Errorf("unsupported additional information %v", info)
+	}
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// appendJSON reads a data item and appends it as JSON.
+func appendJSON(b []byte, r *bufio.Reader) ([]byte, error) {
+	major, info, n, err := readHead(r)
+	if err != nil {
+		return nil, err
+	}
+	return appendItemJSON(b, r, major, info, n)
+}
+
+func appendItemJSON(b []byte, r *bufio.Reader, major, info byte, n uint64) ([]byte, error) {
+	switch major {
+	case majorUint:
+		return strconv.AppendUint(b, n, 10), nil
+	case majorNegInt:
+		if n > math.MaxInt64 {
+			return nil, xerrors.New("negative integer out of range")
+		}
+		return strconv.AppendInt(b, -1-int64(n), 10), nil
+	case majorBytes, majorText:
+		if n > maxLength {
+			return nil, xerrors.Errorf("string of %v bytes is too long", n)
+		}
+		p := make([]byte, n)
+		_, err := io.ReadFull(r, p)
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		if major == majorBytes {
+			return strconv.AppendQuote(b, base64.StdEncoding.EncodeToString(p)), nil
+		}
+		s, _ := json.Marshal(string(p))
+		return append(b, s...), nil
+	case majorArray:
+		if n > maxLength {
+			return nil, xerrors.Errorf("array of %v items is too long", n)
+		}
+		b = append(b, '[')
+		for i := uint64(0); i < n; i++ {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			var err error
+			b, err = appendJSON(b, r)
+			if err != nil {
+				return nil, unexpectedEOF(err)
+			}
+		}
+		return append(b, ']'), nil
+	case majorMap:
+		if n > maxLength {
+			return nil, xerrors.Errorf("map of %v pairs is too long", n)
+		}
+		b = append(b, '{')
+		for i := uint64(0); i < n; i++ {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			major, info, n, err := readHead(r)
+			if err != nil {
+				return nil, unexpectedEOF(err)
+			}
+			if major != majorText {
+				return nil, xerrors.Errorf("unsupported map key of major type %v", major)
+			}
+			b, err = appendItemJSON(b, r, major, info, n)
+			if err != nil {
+				return nil, err
+			}
+			b = append(b, ':')
+			b, err = appendJSON(b, r)
+			if err != nil {
+				return nil, unexpectedEOF(err)
+			}
+		}
+		return append(b, '}'), nil
+	case majorTag:
+		// Tags only add semantics so the content is used as is.
+		b, err := appendJSON(b, r)
+		return b, unexpectedEOF(err)
+	default:
+		return appendSimpleJSON(b, info, n)
+	}
+}
+
+func appendSimpleJSON(b []byte, info byte, n uint64) ([]byte, error) {
+	var f float64
+	switch info {
+	case 20:
+		return append(b, "false"...), nil
+	case 21:
+		return append(b, "true"...), nil
+	case 22, 23:
+		return append(b, "null"...), nil
+	case 25:
+		f = halfToFloat(uint16(n))
+	case 26:
+		f = float64(math.Float32frombits(uint32(n)))
+	case 27:
+		f = math.Float64frombits(n)
+	default:
+		return nil, xerrors.Errorf("unsupported simple value %v", n)
+	}
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return strconv.AppendQuote(b, strconv.FormatFloat(f, 'g', -1, 64)), nil
+	}
+	j, _ := json.Marshal(f)
+	return append(b, j...), nil
+}
+
+func halfToFloat(h uint16) float64 {
+	exp := int(h>>10) & 0x1f
+	mant := float64(h & 0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 0x1f:
+		f = math.Inf(1)
+		if mant != 0 {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		f = -f
+	}
+	return f
+}
@@ -0,0 +1,97 @@
+// Package slogcbor contains the slogger that writes logs in CBOR.
+//
+// Every entry is a CBOR map with the same keys and values as the
+// JSON written by slogjson so the output is a CBOR sequence as
+// defined by RFC 8742. It is considerably more compact than JSON
+// which matters on bandwidth constrained devices.
+//
+// Use Decoder to convert the entries back to JSON:
+//
+//	d := slogcbor.NewDecoder(r)
+//	for {
+//		j, err := d.Decode()
+//		if err != nil {
+//			// io.EOF after the last entry.
+//			break
+//		}
+//		os.Stdout.Write(append(j, '\n'))
+//	}
+package slogcbor // import "cdr.dev/slog/sloggers/slogcbor"
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"sync"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/entryjson"
+	"cdr.dev/slog/internal/syncwriter"
+	"cdr.dev/slog/sloggers/slogjson"
+)
+
+// Sink creates a slog.Sink that writes CBOR entries
+// to the given writer. See package level docs
+// for the format.
+// If the writer implements Sync() error then
+// it will be called when syncing.
+//
+// The slogjson options customize the entries the same way.
+// Formatting options like slogjson.WithIndent have no effect.
+func Sink(w io.Writer, opts ...slogjson.Option) slog.Sink {
+	s := &cborSink{
+		w: syncwriter.New(w),
+	}
+	s.enc = slogjson.Sink(&s.buf, opts...)
+	return s
+}
+
+type cborSink struct {
+	w *syncwriter.Writer
+
+	mu  sync.Mutex
+	enc slog.Sink
+	buf bytes.Buffer
+}
+
+func (s *cborSink) LogEntry(ctx context.Context, ent slog.SinkEntry) {
+	err := s.w.Write(ctx, "slogcbor", s.encode(ent))
+	if err != nil {
+		slog.ReportError(ctx, err, ent)
+	}
+}
+
+func (s *cborSink) encode(ent slog.SinkEntry) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.buf.Reset()
+	s.enc.LogEntry(context.Background(), ent)
+	// No error is guaranteed due to slogjson writing valid JSON.
+	v, _ := entryjson.Decode(s.buf.Bytes())
+	return appendValue(nil, v)
+}
+
+func (s *cborSink) Sync() {
+	s.w.Sync("slogcbor")
+}
+
+// Decoder reads the entries written by Sink.
+type Decoder struct {
+	r *bufio.Reader
+}
+
+// NewDecoder creates a Decoder reading from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{
+		r: bufio.NewReader(r),
+	}
+}
+
+// Decode reads the next entry and returns it as JSON
+// with the keys in their original order.
+// It returns io.EOF once there are no more entries.
+func (d *Decoder) Decode() ([]byte, error) {
+	return appendJSON(nil, d.r)
+}
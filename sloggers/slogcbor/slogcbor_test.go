@@ -0,0 +1,102 @@
+package slogcbor_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"io"
+	"testing"
+	"time"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+	"cdr.dev/slog/sloggers/slogcbor"
+	"cdr.dev/slog/sloggers/slogjson"
+)
+
+var bg = context.Background()
+
+var kt = time.Date(2000, time.February, 5, 4, 4, 4, 123456789, time.UTC)
+
+func TestSink(t *testing.T) {
+	t.Parallel()
+
+	ent := slog.SinkEntry{
+		Time:        kt,
+		Level:       slog.LevelWarn,
+		LoggerNames: []string{"comp"},
+		Message:     "hi",
+		File:        "main.go",
+		Line:        62,
+		Func:        "main.main",
+		Fields: slog.M(
+			slog.F("id", 1),
+			slog.F("neg", -300),
+			slog.F("ratio", 0.5),
+			slog.F("pi", 3.14159),
+			slog.F("ok", true),
+			slog.F("none", nil),
+			slog.F("tags", []string{"a", "b"}),
+			slog.F("big", uint64(1<<63)),
+		),
+	}
+
+	j := &bytes.Buffer{}
+	slogjson.Sink(j).LogEntry(bg, ent)
+
+	b := &bytes.Buffer{}
+	s := slogcbor.Sink(b)
+	s.LogEntry(bg, ent)
+	assert.True(t, "compact", b.Len() < j.Len())
+	s.LogEntry(bg, slog.SinkEntry{Time: kt, Message: "bye"})
+	s.Sync()
+
+	d := slogcbor.NewDecoder(b)
+	got, err := d.Decode()
+	assert.Success(t, "decode", err)
+	assert.Equal(t, "entry", j.String(), string(got)+"\n")
+
+	got, err = d.Decode()
+	assert.Success(t, "decode", err)
+	assert.Equal(t, "entry", `{"ts":"2000-02-05T04:04:04.123456789Z","level":"DEBUG","msg":"bye","caller":":0","func":""}`, string(got))
+
+	_, err = d.Decode()
+	assert.Equal(t, "err", io.EOF, err)
+}
+
+func TestSinkEncoding(t *testing.T) {
+	t.Parallel()
+
+	b := &bytes.Buffer{}
+	s := slogcbor.Sink(b,
+		slogjson.WithTimeFormat(func(t time.Time) interface{} {
+			return t.Unix()
+		}),
+		slogjson.WithoutCaller(),
+		slogjson.WithoutFunc(),
+	)
+	s.LogEntry(bg, slog.SinkEntry{
+		Time:    kt,
+		Level:   slog.LevelInfo,
+		Message: "hi",
+		Fields:  slog.M(slog.F("n", 500)),
+	})
+
+	// {"ts": 949723444, "level": "INFO", "msg": "hi", "fields": {"n": 500}}
+	assert.Equal(t, "cbor", "a46274731a389ba134656c6576656c64494e464f636d7367626869666669656c6473a1616e1901f4", hex.EncodeToString(b.Bytes()))
+}
+
+func TestDecoder(t *testing.T) {
+	t.Parallel()
+
+	b, _ := hex.DecodeString(
+		// [1.5 as a half float, h'0102', tag 1 of 0, -1]
+		"84f93e00420102c10020",
+	)
+	got, err := slogcbor.NewDecoder(bytes.NewReader(b)).Decode()
+	assert.Success(t, "decode", err)
+	assert.Equal(t, "json", `[1.5,"AQI=",0,-1]`, string(got))
+
+	_, err = slogcbor.NewDecoder(bytes.NewReader(b[:3])).Decode()
+	assert.Equal(t, "err", io.ErrUnexpectedEOF, err)
+}
@@ -0,0 +1,99 @@
+package slogcloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog/internal/httpretry"
+)
+
+// Credentials are AWS credentials.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// envCredentials returns the credentials in the environment
+// as set in Lambda functions.
+func envCredentials() (Credentials, bool) {
+	creds := Credentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	return creds, creds.AccessKeyID != "" && creds.SecretAccessKey != ""
+}
+
+// containerCredentials fetches the credentials of the task role
+// from the container credentials endpoint in ECS and caches them
+// until shortly before they expire.
+//
+// See https://docs.aws.amazon.com/AmazonECS/latest/developerguide/task-iam-roles.html
+type containerCredentials struct {
+	mu      sync.Mutex
+	creds   Credentials
+	expires time.Time
+}
+
+func (c *containerCredentials) get(ctx context.Context) (Credentials, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Now().Before(c.expires) {
+		return c.creds, nil
+	}
+
+	url := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	if rel := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); rel != "" {
+		url = "http://169.254.170.2" + rel
+	}
+	if url == "" {
+		return Credentials{}, xerrors.New("no credentials in $AWS_ACCESS_KEY_ID or the container credentials endpoint")
+	}
+	header := http.Header{}
+	if token := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN"); token != "" {
+		header.Set("Authorization", token)
+	}
+
+	b, err := httpretry.Do(ctx, httpretry.Options{}, http.MethodGet, url, header, nil)
+	if err != nil {
+		return Credentials{}, xerrors.Errorf("failed to get container credentials: %w", err)
+	}
+	var resp struct {
+		AccessKeyID     string    `json:"AccessKeyId"`
+		SecretAccessKey string    `json:"SecretAccessKey"`
+		Token           string    `json:"Token"`
+		Expiration      time.Time `json:"Expiration"`
+	}
+	err = json.Unmarshal(b, &resp)
+	if err != nil {
+		return Credentials{}, xerrors.Errorf("failed to unmarshal container credentials: %w", err)
+	}
+
+	c.creds = Credentials{
+		AccessKeyID:     resp.AccessKeyID,
+		SecretAccessKey: resp.SecretAccessKey,
+		SessionToken:    resp.Token,
+	}
+	c.expires = resp.Expiration.Add(-5 * time.Minute)
+	return c.creds, nil
+}
+
+// defaultCredentials returns the credentials in the environment
+// or else those of the ECS task role.
+func defaultCredentials() func(ctx context.Context) (Credentials, error) {
+	c := &containerCredentials{}
+	return func(ctx context.Context) (Credentials, error) {
+		if creds, ok := envCredentials(); ok {
+			return creds, nil
+		}
+		return c.get(ctx)
+	}
+}
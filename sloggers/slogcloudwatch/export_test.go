@@ -0,0 +1,16 @@
+package slogcloudwatch
+
+import (
+	"cdr.dev/slog"
+	"cdr.dev/slog/sloggers/slogjson"
+)
+
+var Sign = sign
+
+const MaxEventBytes = maxEventBytes
+
+func Encode(ent slog.SinkEntry, opts ...slogjson.Option) string {
+	s := &cloudwatchSink{}
+	s.enc = newEncoder(&s.buf, opts)
+	return s.encode(ent)
+}
@@ -0,0 +1,84 @@
+package slogcloudwatch
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// sign adds the headers of AWS Signature Version 4 to header.
+//
+// See https://docs.aws.amazon.com/IAM/latest/UserGuide/create-signed-request.html
+func sign(method string, u *url.URL, header http.Header, body []byte, creds Credentials, region, service string, t time.Time) {
+	t = t.UTC()
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+
+	header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	canonical := make(map[string]string, len(header)+1)
+	canonical["host"] = u.Host
+	for k, v := range header {
+		canonical[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(canonical))
+	for k := range canonical {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var headers strings.Builder
+	for _, k := range names {
+		headers.WriteString(k + ":" + canonical[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	req := strings.Join([]string{
+		method,
+		path,
+		u.Query().Encode(),
+		headers.String(),
+		signedHeaders,
+		hexSHA256(body),
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hexSHA256([]byte(req)),
+	}, "\n")
+
+	key := []byte("AWS4" + creds.SecretAccessKey)
+	for _, s := range []string{date, region, service, "aws4_request"} {
+		key = hmacSHA256(key, s)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+creds.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hexSHA256(b []byte) string {
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+func hmacSHA256(key []byte, s string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(s))
+	return h.Sum(nil)
+}
@@ -0,0 +1,415 @@
+// Package slogcloudwatch contains the slogger that sends entries
+// to AWS CloudWatch Logs without the CloudWatch agent.
+//
+// Entries are encoded with slogjson so that CloudWatch Logs Insights
+// discovers their fields.
+//
+// See https://docs.aws.amazon.com/AmazonCloudWatchLogs/latest/APIReference/API_PutLogEvents.html
+package slogcloudwatch // import "cdr.dev/slog/sloggers/slogcloudwatch"
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/httpretry"
+	"cdr.dev/slog/sloggers/slogbatch"
+	"cdr.dev/slog/sloggers/slogjson"
+)
+
+// Options represents the options for the sink returned by Sink.
+type Options struct {
+	// Region defaults to $AWS_REGION or $AWS_DEFAULT_REGION.
+	Region string
+
+	// LogGroup is the name of the log group.
+	// It is created if it does not exist.
+	LogGroup string
+
+	// LogStream is the name of the log stream.
+	// It is created if it does not exist.
+	//
+	// Defaults to os.Hostname.
+	LogStream string
+
+	// Credentials returns the credentials that requests are signed with.
+	//
+	// Defaults to $AWS_ACCESS_KEY_ID, $AWS_SECRET_ACCESS_KEY and
+	// $AWS_SESSION_TOKEN as set in Lambda functions, or else the
+	// credentials of the task role in ECS.
+	Credentials func(ctx context.Context) (Credentials, error)
+
+	// Endpoint defaults to https://logs.<region>.amazonaws.com.
+	Endpoint string
+
+	// JSON customizes the encoding of entries.
+	JSON []slogjson.Option
+
+	// Client defaults to a client with a 30 second timeout.
+	Client *http.Client
+
+	// MaxRetries is the maximum number of retries of a request
+	// that fails with a network error, 5xx or is throttled.
+	//
+	// Defaults to 3.
+	MaxRetries int
+
+	// Backoff is the delay before the first retry. It doubles
+	// for every following retry.
+	//
+	// Defaults to 500ms.
+	Backoff time.Duration
+
+	// Batch configures the batching of entries.
+	// See slogbatch.Options.
+	Batch *slogbatch.Options
+}
+
+// The limits of PutLogEvents.
+const (
+	maxBatchEvents = 10000
+	maxBatchBytes  = 1048576
+	maxBatchSpan   = 24 * time.Hour
+	eventOverhead  = 26
+	maxEventBytes  = 256*1024 - eventOverhead
+)
+
+// Sink creates a slog.Sink that sends batches of entries
+// to CloudWatch Logs.
+//
+// Batches are split to fit the limits of PutLogEvents and entries
+// larger than 256 KB are truncated. The log group and stream are
+// created with the first batch.
+//
+// Sync writes the buffered entries and so must be called before
+// a Lambda function returns as it may be frozen afterwards.
+// The returned sink implements io.Closer. Close writes the
+// remaining entries.
+func Sink(opts *Options) (slog.Sink, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	s := &cloudwatchSink{
+		region: opts.Region,
+		group:  opts.LogGroup,
+		stream: opts.LogStream,
+		creds:  opts.Credentials,
+		opts: httpretry.Options{
+			Client:     opts.Client,
+			MaxRetries: opts.MaxRetries,
+			Backoff:    opts.Backoff,
+			Retry:      retry,
+		},
+	}
+	if s.group == "" {
+		return nil, xerrors.New("log group is required")
+	}
+	if s.region == "" {
+		s.region = os.Getenv("AWS_REGION")
+	}
+	if s.region == "" {
+		s.region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if s.region == "" {
+		return nil, xerrors.New("region is required")
+	}
+	if s.stream == "" {
+		var err error
+		s.stream, err = os.Hostname()
+		if err != nil {
+			return nil, xerrors.Errorf("failed to get hostname: %w", err)
+		}
+	}
+	if s.creds == nil {
+		s.creds = defaultCredentials()
+	}
+
+	endpoint := opts.Endpoint
+	if endpoint == "" {
+		endpoint = "https://logs." + s.region + ".amazonaws.com"
+	}
+	var err error
+	s.endpoint, err = url.Parse(endpoint)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to parse endpoint: %w", err)
+	}
+	if s.endpoint.Path == "" {
+		s.endpoint.Path = "/"
+	}
+
+	s.enc = newEncoder(&s.buf, opts.JSON)
+	return slogbatch.Sink(s, opts.Batch), nil
+}
+
+// newEncoder returns the slogjson sink encoding entries into w.
+// Entries over the event size limit have their fields dropped and
+// then their message truncated so that they stay valid JSON. A
+// smaller limit in opts takes precedence.
+func newEncoder(w io.Writer, opts []slogjson.Option) slog.Sink {
+	opts = append([]slogjson.Option{slogjson.WithMaxEntrySize(maxEventBytes)}, opts...)
+	return slogjson.Sink(w, opts...)
+}
+
+type cloudwatchSink struct {
+	endpoint *url.URL
+	region   string
+	group    string
+	stream   string
+	creds    func(ctx context.Context) (Credentials, error)
+	opts     httpretry.Options
+
+	mu      sync.Mutex
+	enc     slog.Sink
+	buf     bytes.Buffer
+	created bool
+	token   string
+}
+
+type logEvent struct {
+	Timestamp int64  `json:"timestamp"`
+	Message   string `json:"message"`
+}
+
+func (s *cloudwatchSink) LogEntry(ctx context.Context, ent slog.SinkEntry) {
+	s.LogEntries(ctx, []slog.SinkEntry{ent})
+}
+
+func (s *cloudwatchSink) LogEntries(ctx context.Context, ents []slog.SinkEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Events must be in chronological order.
+	ents = append([]slog.SinkEntry(nil), ents...)
+	sort.SliceStable(ents, func(i, j int) bool {
+		return ents[i].Time.Before(ents[j].Time)
+	})
+
+	events := make([]logEvent, len(ents))
+	for i, ent := range ents {
+		events[i] = logEvent{
+			Timestamp: ent.Time.UnixNano() / int64(time.Millisecond),
+			Message:   s.encode(ent),
+		}
+	}
+
+	for len(events) > 0 {
+		n := batchLen(events)
+		err := s.put(ctx, events[:n])
+		if err != nil {
+			slog.ReportError(ctx, xerrors.Errorf("slogcloudwatch: failed to put %v entries: %w", n, err), ents[:n]...)
+		}
+		ents = ents[n:]
+		events = events[n:]
+	}
+}
+
+// batchLen returns the number of events that fit in the next batch.
+func batchLen(events []logEvent) int {
+	var size int
+	for i, ev := range events {
+		size += len(ev.Message) + eventOverhead
+		if i == maxBatchEvents || size > maxBatchBytes ||
+			time.Duration(ev.Timestamp-events[0].Timestamp)*time.Millisecond >= maxBatchSpan {
+			return i
+		}
+	}
+	return len(events)
+}
+
+func (s *cloudwatchSink) encode(ent slog.SinkEntry) string {
+	s.buf.Reset()
+	s.enc.LogEntry(context.Background(), ent)
+	b := bytes.TrimSuffix(s.buf.Bytes(), []byte("\n"))
+	if len(b) > maxEventBytes {
+		// The entry does not fit even without its fields and message,
+		// e.g. due to WithIndent, so a valid marker replaces it.
+		b = slog.M(
+			slog.F("ts", ent.Time),
+			slog.F("level", ent.Level),
+			slog.F("msg", "slogcloudwatch: entry exceeds the event size limit"),
+			slog.F("truncated", true),
+		).AppendJSON(b[:0])
+	}
+	return string(b)
+}
+
+type putRequest struct {
+	LogGroupName  string     `json:"logGroupName"`
+	LogStreamName string     `json:"logStreamName"`
+	LogEvents     []logEvent `json:"logEvents"`
+	SequenceToken string     `json:"sequenceToken,omitempty"`
+}
+
+type putResponse struct {
+	NextSequenceToken     string `json:"nextSequenceToken"`
+	RejectedLogEventsInfo *struct {
+		TooNewLogEventStartIndex *int `json:"tooNewLogEventStartIndex"`
+		TooOldLogEventEndIndex   *int `json:"tooOldLogEventEndIndex"`
+		ExpiredLogEventEndIndex  *int `json:"expiredLogEventEndIndex"`
+	} `json:"rejectedLogEventsInfo"`
+}
+
+func (s *cloudwatchSink) put(ctx context.Context, events []logEvent) error {
+	const attempts = 3
+	for i := 0; i < attempts; i++ {
+		if !s.created {
+			err := s.createStream(ctx)
+			if err != nil {
+				return err
+			}
+			s.created = true
+		}
+
+		var resp putResponse
+		err := s.call(ctx, "PutLogEvents", putRequest{
+			LogGroupName:  s.group,
+			LogStreamName: s.stream,
+			LogEvents:     events,
+			SequenceToken: s.token,
+		}, &resp)
+		var aerr *apiError
+		if xerrors.As(err, &aerr) {
+			switch aerr.Code {
+			case "InvalidSequenceTokenException":
+				s.token = aerr.ExpectedSequenceToken
+				continue
+			case "DataAlreadyAcceptedException":
+				s.token = aerr.ExpectedSequenceToken
+				return nil
+			case "ResourceNotFoundException":
+				// The group or stream was deleted.
+				s.created = false
+				s.token = ""
+				continue
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		s.token = resp.NextSequenceToken
+		if info := resp.RejectedLogEventsInfo; info != nil {
+			switch {
+			case info.TooNewLogEventStartIndex != nil:
+				return xerrors.Errorf("events from index %v are too new", *info.TooNewLogEventStartIndex)
+			case info.TooOldLogEventEndIndex != nil:
+				return xerrors.Errorf("events up to index %v are too old", *info.TooOldLogEventEndIndex)
+			case info.ExpiredLogEventEndIndex != nil:
+				return xerrors.Errorf("events up to index %v are past the retention period", *info.ExpiredLogEventEndIndex)
+			}
+		}
+		return nil
+	}
+	return xerrors.Errorf("failed after %v attempts", attempts)
+}
+
+// createStream creates the log stream and the log group
+// if they do not exist.
+func (s *cloudwatchSink) createStream(ctx context.Context) error {
+	stream := map[string]string{
+		"logGroupName":  s.group,
+		"logStreamName": s.stream,
+	}
+	err := s.call(ctx, "CreateLogStream", stream, nil)
+	if isCode(err, "ResourceNotFoundException") {
+		err = s.call(ctx, "CreateLogGroup", map[string]string{
+			"logGroupName": s.group,
+		}, nil)
+		if err != nil && !isCode(err, "ResourceAlreadyExistsException") {
+			return xerrors.Errorf("failed to create log group: %w", err)
+		}
+		err = s.call(ctx, "CreateLogStream", stream, nil)
+	}
+	if err != nil && !isCode(err, "ResourceAlreadyExistsException") {
+		return xerrors.Errorf("failed to create log stream: %w", err)
+	}
+	return nil
+}
+
+// apiError is an error response of the CloudWatch Logs API.
+type apiError struct {
+	Code                  string
+	Message               string
+	ExpectedSequenceToken string
+}
+
+func (err *apiError) Error() string {
+	return err.Code + ": " + err.Message
+}
+
+func isCode(err error, code string) bool {
+	var aerr *apiError
+	return xerrors.As(err, &aerr) && aerr.Code == code
+}
+
+// retry retries throttled requests, which fail with 400,
+// in addition to the defaults.
+func retry(err *httpretry.StatusError) bool {
+	if err.StatusCode == http.StatusBadRequest {
+		return bytes.Contains(err.Body, []byte("ThrottlingException"))
+	}
+	return err.StatusCode == http.StatusTooManyRequests || err.StatusCode/100 == 5
+}
+
+func (s *cloudwatchSink) call(ctx context.Context, action string, req, resp interface{}) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return xerrors.Errorf("failed to marshal %v request: %w", action, err)
+	}
+	creds, err := s.creds(ctx)
+	if err != nil {
+		return xerrors.Errorf("failed to get credentials: %w", err)
+	}
+
+	header := http.Header{
+		"Content-Type": []string{"application/x-amz-json-1.1"},
+		"X-Amz-Target": []string{"Logs_20140328." + action},
+	}
+	opts := s.opts
+	// Every attempt is signed with the time it is sent at as
+	// requests with a stale X-Amz-Date are rejected.
+	opts.Prepare = func(req *http.Request) {
+		sign(req.Method, req.URL, req.Header, body, creds, s.region, "logs", time.Now())
+	}
+
+	b, err := httpretry.Do(ctx, opts, http.MethodPost, s.endpoint.String(), header, body)
+	if err != nil {
+		var serr *httpretry.StatusError
+		if xerrors.As(err, &serr) {
+			var r struct {
+				Type                  string `json:"__type"`
+				Message               string `json:"message"`
+				ExpectedSequenceToken string `json:"expectedSequenceToken"`
+			}
+			if json.Unmarshal(serr.Body, &r) == nil && r.Type != "" {
+				return &apiError{
+					Code:                  r.Type[strings.LastIndexByte(r.Type, '#')+1:],
+					Message:               r.Message,
+					ExpectedSequenceToken: r.ExpectedSequenceToken,
+				}
+			}
+		}
+		return err
+	}
+	if resp == nil {
+		return nil
+	}
+	err = json.Unmarshal(b, resp)
+	if err != nil {
+		return xerrors.Errorf("failed to unmarshal %v response: %w", action, err)
+	}
+	return nil
+}
+
+func (s *cloudwatchSink) Sync() {}
@@ -0,0 +1,186 @@
+package slogcloudwatch_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+	"cdr.dev/slog/sloggers/slogbatch"
+	"cdr.dev/slog/sloggers/slogcloudwatch"
+	"cdr.dev/slog/sloggers/slogjson"
+)
+
+var bg = context.Background()
+
+var kt = time.Date(2000, time.February, 5, 4, 4, 4, 123456789, time.UTC)
+
+func TestSign(t *testing.T) {
+	t.Parallel()
+
+	// get-vanilla from the AWS Signature Version 4 test suite.
+	u, err := url.Parse("https://example.amazonaws.com/")
+	assert.Success(t, "parse", err)
+	header := http.Header{}
+	creds := slogcloudwatch.Credentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+	slogcloudwatch.Sign(http.MethodGet, u, header, nil, creds, "us-east-1", "service", time.Date(2015, time.August, 30, 12, 36, 0, 0, time.UTC))
+
+	assert.Equal(t, "date", "20150830T123600Z", header.Get("X-Amz-Date"))
+	assert.Equal(t, "authorization", "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, "+
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31", header.Get("Authorization"))
+}
+
+// fakeCloudWatch emulates the CloudWatch Logs API. The log group does not
+// exist initially, the first put fails with an invalid sequence token and
+// the second is throttled.
+type fakeCloudWatch struct {
+	mu      sync.Mutex
+	actions []string
+	puts    []map[string]interface{}
+}
+
+func (f *fakeCloudWatch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	action := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "Logs_20140328.")
+	f.actions = append(f.actions, action)
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") ||
+		r.Header.Get("X-Amz-Security-Token") != "token" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	var req map[string]interface{}
+	b, _ := ioutil.ReadAll(r.Body)
+	json.Unmarshal(b, &req)
+
+	fail := func(typ string, extra string) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"__type":"com.amazonaws.logs#` + typ + `","message":"fake"` + extra + `}`))
+	}
+
+	switch action {
+	case "CreateLogStream":
+		if len(f.actions) == 1 {
+			fail("ResourceNotFoundException", "")
+			return
+		}
+		w.Write([]byte(`{}`))
+	case "CreateLogGroup":
+		w.Write([]byte(`{}`))
+	case "PutLogEvents":
+		if len(f.puts) == 0 && req["sequenceToken"] == nil {
+			f.puts = append(f.puts, nil)
+			fail("InvalidSequenceTokenException", `,"expectedSequenceToken":"1"`)
+			return
+		}
+		if len(f.puts) == 1 {
+			f.puts = append(f.puts, nil)
+			fail("ThrottlingException", "")
+			return
+		}
+		f.puts = append(f.puts, req)
+		w.Write([]byte(`{"nextSequenceToken":"2"}`))
+	}
+}
+
+func TestSink(t *testing.T) {
+	t.Parallel()
+
+	f := &fakeCloudWatch{}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+
+	s, err := slogcloudwatch.Sink(&slogcloudwatch.Options{
+		Region:    "us-east-1",
+		LogGroup:  "group",
+		LogStream: "stream",
+		Endpoint:  srv.URL,
+		Credentials: func(ctx context.Context) (slogcloudwatch.Credentials, error) {
+			return slogcloudwatch.Credentials{
+				AccessKeyID:     "AKID",
+				SecretAccessKey: "secret",
+				SessionToken:    "token",
+			}, nil
+		},
+		Backoff: time.Millisecond,
+		Batch: &slogbatch.Options{
+			FlushInterval: time.Hour,
+		},
+	})
+	assert.Success(t, "sink", err)
+
+	s.LogEntry(bg, slog.SinkEntry{Time: kt.Add(time.Second), Message: "two"})
+	s.LogEntry(bg, slog.SinkEntry{Time: kt, Message: "one"})
+	s.Sync()
+	s.LogEntry(bg, slog.SinkEntry{Time: kt, Message: "three"})
+	err = s.(interface{ Close() error }).Close()
+	assert.Success(t, "close", err)
+
+	assert.Equal(t, "actions", []string{
+		"CreateLogStream",
+		"CreateLogGroup",
+		"CreateLogStream",
+		"PutLogEvents",
+		"PutLogEvents",
+		"PutLogEvents",
+		"PutLogEvents",
+	}, f.actions)
+
+	assert.Len(t, "puts", 4, f.puts)
+	put := f.puts[2]
+	assert.Equal(t, "group", "group", put["logGroupName"])
+	assert.Equal(t, "stream", "stream", put["logStreamName"])
+	assert.Equal(t, "token", "1", put["sequenceToken"])
+	events := put["logEvents"].([]interface{})
+	assert.Len(t, "events", 2, events)
+	event := events[0].(map[string]interface{})
+	assert.Equal(t, "timestamp", float64(949723444123), event["timestamp"])
+	assert.Equal(t, "message", `{"ts":"2000-02-05T04:04:04.123456789Z","level":"DEBUG","msg":"one","caller":":0","func":""}`, event["message"])
+
+	assert.Equal(t, "token", "2", f.puts[3]["sequenceToken"])
+}
+
+func TestSink_options(t *testing.T) {
+	t.Parallel()
+
+	_, err := slogcloudwatch.Sink(&slogcloudwatch.Options{Region: "us-east-1"})
+	assert.Error(t, "sink", err)
+}
+
+func TestEncode_oversized(t *testing.T) {
+	t.Parallel()
+
+	ent := slog.SinkEntry{
+		Time:    kt,
+		Message: strings.Repeat("é", slogcloudwatch.MaxEventBytes),
+		Fields:  slog.M(slog.F("a", strings.Repeat("x", slogcloudwatch.MaxEventBytes))),
+	}
+	msg := slogcloudwatch.Encode(ent)
+	assert.True(t, "size", len(msg) <= slogcloudwatch.MaxEventBytes)
+	var m map[string]interface{}
+	err := json.Unmarshal([]byte(msg), &m)
+	assert.Success(t, "unmarshal", err)
+	assert.Equal(t, "truncated", true, m["truncated"])
+	assert.True(t, "msg", strings.HasSuffix(m["msg"].(string), "é..."))
+
+	// The indentation is not covered by the size limit
+	// so the entry is replaced.
+	msg = slogcloudwatch.Encode(ent, slogjson.WithIndent(strings.Repeat(" ", slogcloudwatch.MaxEventBytes/4)))
+	err = json.Unmarshal([]byte(msg), &m)
+	assert.Success(t, "unmarshal", err)
+	assert.Equal(t, "msg", "slogcloudwatch: entry exceeds the event size limit", m["msg"])
+}
@@ -0,0 +1,226 @@
+// Package slogdatadog contains the slogger that sends entries
+// to the Datadog Logs API without the Datadog agent.
+//
+// Entries are encoded with slogjson and the Datadog layout
+// by default and the reserved attributes ddsource, ddtags,
+// hostname and service are added to every entry.
+//
+// See https://docs.datadoghq.com/api/latest/logs/#send-logs
+package slogdatadog // import "cdr.dev/slog/sloggers/slogdatadog"
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/httpretry"
+	"cdr.dev/slog/sloggers/slogbatch"
+	"cdr.dev/slog/sloggers/slogjson"
+)
+
+// Options represents the options for the sink returned by Sink.
+type Options struct {
+	// APIKey is the API key of the Datadog organization.
+	APIKey string
+
+	// Site is the Datadog site of the organization,
+	// e.g. datadoghq.eu or us3.datadoghq.com.
+	//
+	// Defaults to datadoghq.com.
+	Site string
+
+	// URL defaults to https://http-intake.logs.<site>/api/v2/logs.
+	URL string
+
+	// Service is the name of the application.
+	Service string
+
+	// Source is the integration name of the entries.
+	//
+	// Defaults to go.
+	Source string
+
+	// Hostname defaults to os.Hostname.
+	Hostname string
+
+	// Tags are added to every entry, e.g. env:prod.
+	Tags []string
+
+	// JSON customizes the encoding of entries.
+	//
+	// Defaults to slogjson.Datadog().
+	JSON []slogjson.Option
+
+	// Client defaults to a client with a 30 second timeout.
+	Client *http.Client
+
+	// MaxRetries is the maximum number of retries of a request
+	// that fails with a network error, 429 or 5xx.
+	//
+	// Defaults to 3.
+	MaxRetries int
+
+	// Backoff is the delay before the first retry. It doubles
+	// for every following retry.
+	//
+	// Defaults to 500ms.
+	Backoff time.Duration
+
+	// Batch configures the batching of entries.
+	// See slogbatch.Options.
+	Batch *slogbatch.Options
+}
+
+// The limits of the intake API.
+const (
+	maxBatchEntries = 1000
+	maxBatchBytes   = 5 << 20
+)
+
+// Sink creates a slog.Sink that sends gzipped batches of entries
+// to Datadog.
+//
+// The returned sink implements io.Closer. Close sends the
+// remaining entries.
+func Sink(opts *Options) (slog.Sink, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	if opts.APIKey == "" {
+		return nil, xerrors.New("API key is required")
+	}
+
+	s := &datadogSink{
+		url: opts.URL,
+		header: http.Header{
+			"Content-Type":     []string{"application/json"},
+			"Content-Encoding": []string{"gzip"},
+			"Dd-Api-Key":       []string{opts.APIKey},
+		},
+		opts: httpretry.Options{
+			Client:     opts.Client,
+			MaxRetries: opts.MaxRetries,
+			Backoff:    opts.Backoff,
+		},
+	}
+	if s.url == "" {
+		site := opts.Site
+		if site == "" {
+			site = "datadoghq.com"
+		}
+		s.url = "https://http-intake.logs." + site + "/api/v2/logs"
+	}
+
+	reserved := map[string]string{
+		"ddsource": opts.Source,
+		"service":  opts.Service,
+		"hostname": opts.Hostname,
+		"ddtags":   strings.Join(opts.Tags, ","),
+	}
+	if reserved["ddsource"] == "" {
+		reserved["ddsource"] = "go"
+	}
+	if reserved["hostname"] == "" {
+		reserved["hostname"], _ = os.Hostname()
+	}
+	for _, k := range []string{"ddsource", "ddtags", "hostname", "service"} {
+		if reserved[k] == "" {
+			continue
+		}
+		b, _ := json.Marshal(reserved[k])
+		s.reserved = append(s.reserved, `"`+k+`":`...)
+		s.reserved = append(s.reserved, b...)
+		s.reserved = append(s.reserved, ',')
+	}
+
+	jsonOpts := opts.JSON
+	if jsonOpts == nil {
+		jsonOpts = []slogjson.Option{slogjson.Datadog()}
+	}
+	s.enc = slogjson.Sink(&s.buf, jsonOpts...)
+	return slogbatch.Sink(s, opts.Batch), nil
+}
+
+type datadogSink struct {
+	url    string
+	header http.Header
+	opts   httpretry.Options
+
+	// reserved holds the reserved attributes as JSON members
+	// followed by a comma.
+	reserved []byte
+
+	mu  sync.Mutex
+	enc slog.Sink
+	buf bytes.Buffer
+}
+
+func (s *datadogSink) LogEntry(ctx context.Context, ent slog.SinkEntry) {
+	s.LogEntries(ctx, []slog.SinkEntry{ent})
+}
+
+func (s *datadogSink) LogEntries(ctx context.Context, ents []slog.SinkEntry) {
+	logs := make([][]byte, len(ents))
+	for i, ent := range ents {
+		logs[i] = s.encode(ent)
+	}
+
+	for len(logs) > 0 {
+		n, size := 0, 2
+		for n < len(logs) && n < maxBatchEntries {
+			size += len(logs[n]) + 1
+			if n > 0 && size > maxBatchBytes {
+				break
+			}
+			n++
+		}
+
+		err := s.send(ctx, logs[:n])
+		if err != nil {
+			slog.ReportError(ctx, xerrors.Errorf("slogdatadog: failed to send %v entries: %w", n, err), ents[:n]...)
+		}
+		ents = ents[n:]
+		logs = logs[n:]
+	}
+}
+
+func (s *datadogSink) send(ctx context.Context, logs [][]byte) error {
+	var body bytes.Buffer
+	zw := gzip.NewWriter(&body)
+	zw.Write([]byte{'['})
+	zw.Write(bytes.Join(logs, []byte{','}))
+	zw.Write([]byte{']'})
+	err := zw.Close()
+	if err != nil {
+		return xerrors.Errorf("failed to gzip: %w", err)
+	}
+
+	_, err = httpretry.Do(ctx, s.opts, http.MethodPost, s.url, s.header, body.Bytes())
+	return err
+}
+
+// encode encodes ent with the reserved attributes.
+func (s *datadogSink) encode(ent slog.SinkEntry) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.buf.Reset()
+	s.enc.LogEntry(context.Background(), ent)
+	line := bytes.TrimSpace(s.buf.Bytes())
+
+	b := make([]byte, 0, len(s.reserved)+len(line))
+	b = append(b, '{')
+	b = append(b, s.reserved...)
+	return append(b, line[1:]...)
+}
+
+func (s *datadogSink) Sync() {}
@@ -0,0 +1,71 @@
+package slogdatadog_test
+
+import (
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+	"cdr.dev/slog/sloggers/slogdatadog"
+)
+
+var bg = context.Background()
+
+var kt = time.Date(2000, time.February, 5, 4, 4, 4, 123456789, time.UTC)
+
+func TestSink(t *testing.T) {
+	t.Parallel()
+
+	bodies := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "api key", "key", r.Header.Get("DD-API-KEY"))
+		assert.Equal(t, "encoding", "gzip", r.Header.Get("Content-Encoding"))
+
+		zr, err := gzip.NewReader(r.Body)
+		assert.Success(t, "gzip", err)
+		b, err := ioutil.ReadAll(zr)
+		assert.Success(t, "read body", err)
+		bodies <- string(b)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	s, err := slogdatadog.Sink(&slogdatadog.Options{
+		APIKey:   "key",
+		URL:      srv.URL,
+		Service:  "api",
+		Hostname: "host",
+		Tags:     []string{"env:prod", "team:core"},
+	})
+	assert.Success(t, "sink", err)
+
+	s.LogEntry(bg, slog.SinkEntry{
+		Time:        kt,
+		Level:       slog.LevelWarn,
+		LoggerNames: []string{"comp"},
+		Message:     "one",
+		File:        "main.go",
+		Line:        62,
+		Func:        "main.main",
+	})
+	s.LogEntry(bg, slog.SinkEntry{Time: kt, Message: "two"})
+	s.Sync()
+
+	assert.Equal(t, "body", `[`+
+		`{"ddsource":"go","ddtags":"env:prod,team:core","hostname":"host","service":"api",`+
+		`"timestamp":"2000-02-05T04:04:04.123456789Z","status":"warn","message":"one","caller":"main.go:62","func":"main.main","logger.name":"comp"},`+
+		`{"ddsource":"go","ddtags":"env:prod,team:core","hostname":"host","service":"api",`+
+		`"timestamp":"2000-02-05T04:04:04.123456789Z","status":"debug","message":"two","caller":":0","func":""}]`, <-bodies)
+}
+
+func TestSink_noAPIKey(t *testing.T) {
+	t.Parallel()
+
+	_, err := slogdatadog.Sink(nil)
+	assert.Error(t, "sink", err)
+}
@@ -0,0 +1,11 @@
+package slogdedup
+
+import (
+	"time"
+
+	"cdr.dev/slog"
+)
+
+func SetNow(s slog.Sink, now func() time.Time) {
+	s.(*dedupSink).now = now
+}
@@ -0,0 +1,176 @@
+// Package slogdedup contains a slogger that collapses identical
+// consecutive entries.
+package slogdedup // import "cdr.dev/slog/sloggers/slogdedup"
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/detach"
+)
+
+// Options represents the options for the sink returned by Sink.
+type Options struct {
+	// Window is the maximum duration over which identical entries
+	// are collapsed.
+	//
+	// Defaults to 10 seconds.
+	Window time.Duration
+}
+
+// Sink creates a slog.Sink that collapses identical consecutive
+// entries passed to s.
+//
+// Entries are identical if they have the same level, logger names,
+// message and fields. The first entry is logged immediately. Repeats
+// within the window are held back until a different entry is logged,
+// the window elapses or Sync or Close is called. Then the last repeat
+// is logged with a "repeated" field containing the number of repeats.
+func Sink(s slog.Sink, opts *Options) slog.Sink {
+	if opts == nil {
+		opts = &Options{}
+	}
+	ds := &dedupSink{
+		s:      s,
+		window: opts.Window,
+		now:    time.Now,
+	}
+	if ds.window <= 0 {
+		ds.window = time.Second * 10
+	}
+	return ds
+}
+
+type dedupSink struct {
+	s      slog.Sink
+	window time.Duration
+
+	mu         sync.Mutex
+	last       string
+	lastTime   time.Time
+	repeated   int
+	pendingCtx context.Context
+	pending    slog.SinkEntry
+	// timer flushes the pending repeats when the window elapses.
+	timer *time.Timer
+	// gen is incremented on every flush so that a timer
+	// that fires late does not flush a later window.
+	gen int
+
+	now func() time.Time
+}
+
+func (s *dedupSink) LogEntry(ctx context.Context, ent slog.SinkEntry) {
+	k := key(ent)
+	now := s.now()
+
+	s.mu.Lock()
+	if k == s.last && now.Sub(s.lastTime) < s.window {
+		if s.repeated == 0 {
+			gen := s.gen
+			s.timer = time.AfterFunc(s.window-now.Sub(s.lastTime), func() {
+				s.expire(gen)
+			})
+		}
+		s.repeated++
+		s.pendingCtx = detach.Context(ctx)
+		s.pending = ent
+		s.mu.Unlock()
+		return
+	}
+
+	pctx, pent, ok := s.take()
+	s.last = k
+	s.lastTime = now
+	s.mu.Unlock()
+
+	// s is called without holding mu so that a sink that logs
+	// back into this one, e.g. through ReportError, does not deadlock.
+	if ok {
+		s.s.LogEntry(pctx, pent)
+	}
+	s.s.LogEntry(ctx, ent)
+}
+
+func (s *dedupSink) Sync() {
+	s.mu.Lock()
+	pctx, pent, ok := s.take()
+	s.last = ""
+	s.mu.Unlock()
+
+	if ok {
+		s.s.LogEntry(pctx, pent)
+	}
+	s.s.Sync()
+}
+
+// Close logs the pending repeats before closing s.
+func (s *dedupSink) Close() error {
+	s.flush()
+
+	if c, ok := s.s.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func (s *dedupSink) expire(gen int) {
+	s.mu.Lock()
+	if gen != s.gen {
+		s.mu.Unlock()
+		return
+	}
+	pctx, pent, ok := s.take()
+	s.mu.Unlock()
+
+	if ok {
+		s.s.LogEntry(pctx, pent)
+	}
+}
+
+func (s *dedupSink) flush() {
+	s.mu.Lock()
+	pctx, pent, ok := s.take()
+	s.mu.Unlock()
+
+	if ok {
+		s.s.LogEntry(pctx, pent)
+	}
+}
+
+// take returns the last repeat with the number of repeats
+// and resets them. It must be called with mu held.
+func (s *dedupSink) take() (context.Context, slog.SinkEntry, bool) {
+	if s.repeated == 0 {
+		return nil, slog.SinkEntry{}, false
+	}
+	s.timer.Stop()
+	s.gen++
+
+	ctx := s.pendingCtx
+	ent := s.pending
+	ent.Fields = append(ent.Fields[:len(ent.Fields):len(ent.Fields)], slog.F("repeated", s.repeated))
+
+	s.repeated = 0
+	s.pendingCtx = nil
+	s.pending = slog.SinkEntry{}
+	return ctx, ent, true
+}
+
+func key(ent slog.SinkEntry) string {
+	fields, _ := ent.Fields.MarshalJSON()
+
+	var b strings.Builder
+	b.WriteString(ent.Level.String())
+	b.WriteByte(0)
+	b.WriteString(strings.Join(ent.LoggerNames, "."))
+	b.WriteByte(0)
+	b.WriteString(ent.Message)
+	b.WriteByte(0)
+	b.Write(fields)
+	return b.String()
+}
@@ -0,0 +1,147 @@
+package slogdedup_test
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+	"cdr.dev/slog/sloggers/slogdedup"
+)
+
+var bg = context.Background()
+
+type fakeSink struct {
+	entries []slog.SinkEntry
+	syncs   int
+}
+
+func (s *fakeSink) LogEntry(_ context.Context, e slog.SinkEntry) {
+	s.entries = append(s.entries, e)
+}
+
+func (s *fakeSink) Sync() {
+	s.syncs++
+}
+
+func TestSink(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(0, 0)
+	fs := &fakeSink{}
+	s := slogdedup.Sink(fs, &slogdedup.Options{
+		Window: time.Minute,
+	})
+	slogdedup.SetNow(s, func() time.Time {
+		return now
+	})
+	l := slog.Make(s)
+
+	for i := 0; i < 4; i++ {
+		l.Info(bg, "hi", slog.F("a", 1))
+	}
+	assert.Len(t, "entries", 1, fs.entries)
+
+	l.Info(bg, "hi", slog.F("a", 2))
+	assert.Len(t, "entries", 3, fs.entries)
+	assert.Equal(t, "fields", slog.M(slog.F("a", 1), slog.F("repeated", 3)), fs.entries[1].Fields)
+	assert.Equal(t, "fields", slog.M(slog.F("a", 2)), fs.entries[2].Fields)
+
+	l.Info(bg, "hi", slog.F("a", 2))
+	now = now.Add(time.Minute)
+	l.Info(bg, "hi", slog.F("a", 2))
+	assert.Len(t, "entries", 5, fs.entries)
+	assert.Equal(t, "fields", slog.M(slog.F("a", 2), slog.F("repeated", 1)), fs.entries[3].Fields)
+
+	l.Info(bg, "hi", slog.F("a", 2))
+	l.Sync()
+	assert.Len(t, "entries", 6, fs.entries)
+	assert.Equal(t, "syncs", 1, fs.syncs)
+}
+
+type chanSink chan slog.SinkEntry
+
+func (s chanSink) LogEntry(_ context.Context, e slog.SinkEntry) {
+	s <- e
+}
+
+func (s chanSink) Sync() {}
+
+func TestSink_window(t *testing.T) {
+	t.Parallel()
+
+	cs := make(chanSink, 4)
+	l := slog.Make(slogdedup.Sink(cs, &slogdedup.Options{
+		Window: time.Millisecond * 10,
+	}))
+
+	l.Info(bg, "hi")
+	l.Info(bg, "hi")
+	l.Info(bg, "hi")
+	assert.Len(t, "first fields", 0, (<-cs).Fields)
+
+	// The repeats are flushed by the timer without another entry.
+	select {
+	case ent := <-cs:
+		assert.Equal(t, "fields", slog.M(slog.F("repeated", 2)), ent.Fields)
+	case <-time.After(time.Second * 5):
+		t.Fatal("repeats were not flushed when the window elapsed")
+	}
+}
+
+func TestSink_close(t *testing.T) {
+	t.Parallel()
+
+	fs := &fakeSink{}
+	s := slogdedup.Sink(fs, &slogdedup.Options{
+		Window: time.Hour,
+	})
+	l := slog.Make(s)
+
+	l.Info(bg, "hi")
+	l.Info(bg, "hi")
+	err := s.(io.Closer).Close()
+	assert.Success(t, "close", err)
+
+	assert.Len(t, "entries", 2, fs.entries)
+	assert.Equal(t, "fields", slog.M(slog.F("repeated", 1)), fs.entries[1].Fields)
+}
+
+// reentrantSink logs into l on the first entry like a sink
+// reporting an error to a fallback that leads back to l.
+type reentrantSink struct {
+	fakeSink
+	l slog.Logger
+}
+
+func (s *reentrantSink) LogEntry(ctx context.Context, e slog.SinkEntry) {
+	s.fakeSink.LogEntry(ctx, e)
+	if len(s.entries) == 1 {
+		s.l.Info(ctx, "from sink")
+	}
+}
+
+func TestSink_reentrant(t *testing.T) {
+	t.Parallel()
+
+	rs := &reentrantSink{}
+	rs.l = slog.Make(slogdedup.Sink(rs, nil))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		rs.l.Info(bg, "hi")
+		rs.l.Info(bg, "hi")
+		rs.l.Sync()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second * 5):
+		t.Fatal("sink deadlocked logging back into itself")
+	}
+	assert.Len(t, "entries", 3, rs.entries)
+	assert.Equal(t, "message", "from sink", rs.entries[1].Message)
+}
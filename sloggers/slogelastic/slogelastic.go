@@ -0,0 +1,258 @@
+// Package slogelastic contains the slogger that indexes entries
+// into Elasticsearch with the bulk API.
+//
+// Documents are encoded with slogjson and the ECS layout by default.
+//
+// See https://www.elastic.co/guide/en/elasticsearch/reference/current/docs-bulk.html
+package slogelastic // import "cdr.dev/slog/sloggers/slogelastic"
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/httpretry"
+	"cdr.dev/slog/sloggers/slogbatch"
+	"cdr.dev/slog/sloggers/slogjson"
+)
+
+// Options represents the options for the sink returned by Sink.
+type Options struct {
+	// URL is the base URL of Elasticsearch, e.g. http://elasticsearch:9200.
+	URL string
+
+	// Header is added to every request, e.g. Authorization
+	// with an API key.
+	Header http.Header
+
+	// Index is the name of the index. The part between braces
+	// is a time layout that is replaced with the UTC time of the
+	// entry so that indices can be rotated, e.g. logs-{2006.01.02}
+	// for daily indices.
+	//
+	// Defaults to slog-{2006.01.02}.
+	Index string
+
+	// JSON customizes the encoding of documents.
+	//
+	// Defaults to slogjson.ECS().
+	JSON []slogjson.Option
+
+	// Client defaults to a client with a 30 second timeout.
+	Client *http.Client
+
+	// MaxRetries is the maximum number of retries of a bulk request
+	// that fails with a network error, 429 or 5xx and of documents
+	// that are rejected with 429 because Elasticsearch is overloaded.
+	//
+	// Defaults to 3.
+	MaxRetries int
+
+	// Backoff is the delay before the first retry. It doubles
+	// for every following retry.
+	//
+	// Defaults to 500ms.
+	Backoff time.Duration
+
+	// Batch configures the batching of entries.
+	// See slogbatch.Options.
+	//
+	// MaxBytes defaults to 5 MB.
+	Batch *slogbatch.Options
+}
+
+// Sink creates a slog.Sink that indexes batches of entries.
+//
+// A full batch is written by the goroutine that logs the entry that
+// fills it, which applies backpressure to the application when
+// Elasticsearch is slow instead of buffering without bound.
+// Documents that fail for reasons other than 429 are reported
+// and not retried.
+//
+// The returned sink implements io.Closer. Close writes the
+// remaining entries.
+func Sink(opts *Options) (slog.Sink, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to parse URL: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, xerrors.Errorf("invalid URL %q", opts.URL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/_bulk"
+
+	s := &elasticSink{
+		url:   u.String(),
+		index: opts.Index,
+		opts: httpretry.Options{
+			Client:     opts.Client,
+			MaxRetries: opts.MaxRetries,
+			Backoff:    opts.Backoff,
+		}.WithDefaults(),
+		header: http.Header{
+			"Content-Type": []string{"application/x-ndjson"},
+		},
+	}
+	for k, v := range opts.Header {
+		s.header[k] = v
+	}
+	if s.index == "" {
+		s.index = "slog-{2006.01.02}"
+	}
+	jsonOpts := opts.JSON
+	if jsonOpts == nil {
+		jsonOpts = []slogjson.Option{slogjson.ECS()}
+	}
+	s.enc = slogjson.Sink(&s.buf, jsonOpts...)
+
+	batch := slogbatch.Options{}
+	if opts.Batch != nil {
+		batch = *opts.Batch
+	}
+	if batch.MaxBytes <= 0 {
+		batch.MaxBytes = 5 << 20
+	}
+	return slogbatch.Sink(s, &batch), nil
+}
+
+type elasticSink struct {
+	url    string
+	header http.Header
+	index  string
+	opts   httpretry.Options
+
+	mu  sync.Mutex
+	enc slog.Sink
+	buf bytes.Buffer
+}
+
+func (s *elasticSink) LogEntry(ctx context.Context, ent slog.SinkEntry) {
+	s.LogEntries(ctx, []slog.SinkEntry{ent})
+}
+
+func (s *elasticSink) LogEntries(ctx context.Context, ents []slog.SinkEntry) {
+	docs := make([][]byte, len(ents))
+	for i, ent := range ents {
+		docs[i] = s.doc(ent)
+	}
+
+	backoff := s.opts.Backoff
+	for i := 0; ; i++ {
+		var body []byte
+		for _, doc := range docs {
+			body = append(body, doc...)
+		}
+
+		resp, err := httpretry.Do(ctx, s.opts, http.MethodPost, s.url, s.header, body)
+		if err != nil {
+			slog.ReportError(ctx, xerrors.Errorf("slogelastic: failed to index %v entries: %w", len(ents), err), ents...)
+			return
+		}
+
+		rejected, err := s.handleResponse(ctx, resp, ents)
+		if err != nil {
+			slog.ReportError(ctx, xerrors.Errorf("slogelastic: failed to index %v entries: %w", len(ents), err), ents...)
+			return
+		}
+		if len(rejected) == 0 {
+			return
+		}
+
+		ents2 := make([]slog.SinkEntry, 0, len(rejected))
+		docs2 := make([][]byte, 0, len(rejected))
+		for _, j := range rejected {
+			ents2 = append(ents2, ents[j])
+			docs2 = append(docs2, docs[j])
+		}
+		ents, docs = ents2, docs2
+
+		if i == s.opts.MaxRetries {
+			slog.ReportError(ctx, xerrors.Errorf("slogelastic: %v entries were rejected with 429 after %v retries", len(ents), i), ents...)
+			return
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int `json:"status"`
+		Error  struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+// handleResponse reports the entries that failed to be indexed
+// and returns the indices of the entries rejected with 429.
+func (s *elasticSink) handleResponse(ctx context.Context, b []byte, ents []slog.SinkEntry) ([]int, error) {
+	var resp bulkResponse
+	err := json.Unmarshal(b, &resp)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to unmarshal bulk response: %w", err)
+	}
+	if !resp.Errors {
+		return nil, nil
+	}
+	if len(resp.Items) != len(ents) {
+		return nil, xerrors.Errorf("bulk response has %v items for %v entries", len(resp.Items), len(ents))
+	}
+
+	var rejected []int
+	for i, item := range resp.Items {
+		for _, res := range item {
+			switch {
+			case res.Status == http.StatusTooManyRequests:
+				rejected = append(rejected, i)
+			case res.Status/100 != 2:
+				slog.ReportError(ctx, xerrors.Errorf("slogelastic: failed to index entry: %v: %v: %v", res.Status, res.Error.Type, res.Error.Reason), ents[i])
+			}
+		}
+	}
+	return rejected, nil
+}
+
+// doc returns the action and document lines of ent.
+func (s *elasticSink) doc(ent slog.SinkEntry) []byte {
+	action, _ := json.Marshal(map[string]interface{}{
+		"create": map[string]string{
+			"_index": s.indexOf(ent.Time),
+		},
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.buf.Reset()
+	s.enc.LogEntry(context.Background(), ent)
+
+	b := make([]byte, 0, len(action)+1+s.buf.Len())
+	b = append(b, action...)
+	b = append(b, '\n')
+	return append(b, s.buf.Bytes()...)
+}
+
+func (s *elasticSink) indexOf(t time.Time) string {
+	i := strings.IndexByte(s.index, '{')
+	j := strings.IndexByte(s.index, '}')
+	if i < 0 || j < i {
+		return s.index
+	}
+	return s.index[:i] + t.UTC().Format(s.index[i+1:j]) + s.index[j+1:]
+}
+
+func (s *elasticSink) Sync() {}
@@ -0,0 +1,85 @@
+package slogelastic_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+	"cdr.dev/slog/sloggers/slogelastic"
+)
+
+var bg = context.Background()
+
+var kt = time.Date(2000, time.February, 5, 4, 4, 4, 123456789, time.UTC)
+
+func TestSink(t *testing.T) {
+	t.Parallel()
+
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "path", "/_bulk", r.URL.Path)
+		assert.Equal(t, "content type", "application/x-ndjson", r.Header.Get("Content-Type"))
+		b, err := ioutil.ReadAll(r.Body)
+		assert.Success(t, "read body", err)
+		bodies = append(bodies, string(b))
+
+		if len(bodies) == 1 {
+			w.Write([]byte(`{"errors":true,"items":[` +
+				`{"create":{"status":201}},` +
+				`{"create":{"status":429,"error":{"type":"es_rejected_execution_exception"}}},` +
+				`{"create":{"status":400,"error":{"type":"mapper_parsing_exception"}}}]}`))
+			return
+		}
+		w.Write([]byte(`{"errors":false,"items":[{"create":{"status":201}}]}`))
+	}))
+	defer srv.Close()
+
+	s, err := slogelastic.Sink(&slogelastic.Options{
+		URL:     srv.URL,
+		Index:   "logs-{2006.01.02}-app",
+		Backoff: time.Millisecond,
+	})
+	assert.Success(t, "sink", err)
+
+	s.LogEntry(bg, slog.SinkEntry{Time: kt, Message: "one"})
+	s.LogEntry(bg, slog.SinkEntry{Time: kt.Add(24 * time.Hour), Message: "two"})
+	s.LogEntry(bg, slog.SinkEntry{Time: kt, Message: "three"})
+	err = s.(interface{ Close() error }).Close()
+	assert.Success(t, "close", err)
+
+	assert.Len(t, "requests", 2, bodies)
+
+	lines := readLines(bodies[0])
+	assert.Len(t, "lines", 6, lines)
+	assert.Equal(t, "action", `{"create":{"_index":"logs-2000.02.05-app"}}`, lines[0])
+	assert.Equal(t, "action", `{"create":{"_index":"logs-2000.02.06-app"}}`, lines[2])
+
+	lines = readLines(bodies[1])
+	assert.Len(t, "lines", 2, lines)
+	assert.Equal(t, "action", `{"create":{"_index":"logs-2000.02.06-app"}}`, lines[0])
+	assert.Equal(t, "doc", `{"@timestamp":"2000-02-06T04:04:04.123456789Z","log.level":"debug","message":"two",`+
+		`"log.origin.file.name":"","log.origin.file.line":0,"log.origin.function":"","ecs.version":"1.6.0"}`, lines[1])
+}
+
+func TestSink_invalidURL(t *testing.T) {
+	t.Parallel()
+
+	_, err := slogelastic.Sink(&slogelastic.Options{URL: "elasticsearch:9200"})
+	assert.Error(t, "sink", err)
+}
+
+func readLines(s string) []string {
+	var lines []string
+	sc := bufio.NewScanner(bytes.NewReader([]byte(s)))
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+	return lines
+}
@@ -0,0 +1,3 @@
+package slogeventlog
+
+var NewSink = newSink
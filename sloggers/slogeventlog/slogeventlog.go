@@ -0,0 +1,95 @@
+// Package slogeventlog contains the slogger that writes entries
+// to the Windows Event Log for Windows services, which have no
+// console to write to.
+//
+// Entries are formatted like sloghuman without the time and level
+// as the Event Log records them itself.
+package slogeventlog // import "cdr.dev/slog/sloggers/slogeventlog"
+
+import (
+	"context"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/entryhuman"
+)
+
+// Options represents the options for the sink returned by Sink.
+type Options struct {
+	// Source is the name of the event source that entries
+	// are reported as.
+	//
+	// Defaults to the name of the executable without
+	// the .exe extension.
+	Source string
+
+	// EventID is the ID of every event. It must be between
+	// 1 and 1000 for sources registered with EventCreate.exe.
+	//
+	// Defaults to 1.
+	EventID uint32
+
+	// Install registers Source with EventCreate.exe as its message
+	// file if it is not registered yet. Registering requires
+	// administrator rights and so is usually done by the installer
+	// of the service instead.
+	Install bool
+}
+
+// eventLog is the subset of *eventlog.Log used by the sink.
+type eventLog interface {
+	Info(eid uint32, msg string) error
+	Warning(eid uint32, msg string) error
+	Error(eid uint32, msg string) error
+	Close() error
+}
+
+func newSink(l eventLog, eid uint32) slog.Sink {
+	if eid == 0 {
+		eid = 1
+	}
+	return eventlogSink{
+		l:   l,
+		eid: eid,
+	}
+}
+
+type eventlogSink struct {
+	l   eventLog
+	eid uint32
+}
+
+var layout = []entryhuman.Part{
+	entryhuman.PartName,
+	entryhuman.PartMessage,
+	entryhuman.PartCaller,
+	entryhuman.PartFields,
+}
+
+func (s eventlogSink) LogEntry(ctx context.Context, ent slog.SinkEntry) {
+	msg := entryhuman.FmtWith(nil, ent, entryhuman.Options{
+		Layout: layout,
+		Color:  entryhuman.ColorNever,
+		Pretty: true,
+	})
+
+	var err error
+	switch {
+	case ent.Level < slog.LevelWarn:
+		err = s.l.Info(s.eid, msg)
+	case ent.Level < slog.LevelError:
+		err = s.l.Warning(s.eid, msg)
+	default:
+		err = s.l.Error(s.eid, msg)
+	}
+	if err != nil {
+		slog.ReportError(ctx, xerrors.Errorf("slogeventlog: failed to report event: %w", err), ent)
+	}
+}
+
+func (s eventlogSink) Sync() {}
+
+func (s eventlogSink) Close() error {
+	return s.l.Close()
+}
@@ -0,0 +1,15 @@
+//go:build !windows
+// +build !windows
+
+package slogeventlog
+
+import (
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+)
+
+// Sink is only supported on Windows.
+func Sink(opts *Options) (slog.Sink, error) {
+	return nil, xerrors.New("the event log is only supported on Windows")
+}
@@ -0,0 +1,79 @@
+package slogeventlog_test
+
+import (
+	"context"
+	"runtime"
+	"testing"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+	"cdr.dev/slog/sloggers/slogeventlog"
+)
+
+var bg = context.Background()
+
+type event struct {
+	typ string
+	eid uint32
+	msg string
+}
+
+type fakeLog struct {
+	events []event
+}
+
+func (l *fakeLog) Info(eid uint32, msg string) error {
+	l.events = append(l.events, event{"info", eid, msg})
+	return nil
+}
+
+func (l *fakeLog) Warning(eid uint32, msg string) error {
+	l.events = append(l.events, event{"warning", eid, msg})
+	return nil
+}
+
+func (l *fakeLog) Error(eid uint32, msg string) error {
+	l.events = append(l.events, event{"error", eid, msg})
+	return nil
+}
+
+func (l *fakeLog) Close() error {
+	return nil
+}
+
+func TestSink(t *testing.T) {
+	t.Parallel()
+
+	l := &fakeLog{}
+	s := slogeventlog.NewSink(l, 0)
+
+	s.LogEntry(bg, slog.SinkEntry{
+		Level:       slog.LevelInfo,
+		LoggerNames: []string{"svc"},
+		Message:     "started",
+		File:        "main.go",
+		Line:        62,
+		Fields:      slog.M(slog.F("port", 8080)),
+	})
+	s.LogEntry(bg, slog.SinkEntry{Level: slog.LevelDebug, Message: "debug"})
+	s.LogEntry(bg, slog.SinkEntry{Level: slog.LevelWarn, Message: "warn"})
+	s.LogEntry(bg, slog.SinkEntry{Level: slog.LevelError, Message: "error"})
+	s.LogEntry(bg, slog.SinkEntry{Level: slog.LevelCritical, Message: "critical"})
+
+	assert.Len(t, "events", 5, l.events)
+	assert.Equal(t, "event", event{"info", 1, "(svc)\tstarted\t<main.go:62>\nport: 8080"}, l.events[0])
+	assert.Equal(t, "type", "info", l.events[1].typ)
+	assert.Equal(t, "type", "warning", l.events[2].typ)
+	assert.Equal(t, "type", "error", l.events[3].typ)
+	assert.Equal(t, "type", "error", l.events[4].typ)
+}
+
+func TestSink_platform(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("opening the event log requires a registered source")
+	}
+	_, err := slogeventlog.Sink(nil)
+	assert.Error(t, "sink", err)
+}
@@ -0,0 +1,45 @@
+package slogeventlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/windows/svc/eventlog"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+)
+
+// Sink creates a slog.Sink that reports entries as events of the
+// source. Debug and Info entries are information events, Warn
+// entries are warning events and the rest are error events.
+//
+// The returned sink implements io.Closer.
+func Sink(opts *Options) (slog.Sink, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	source := opts.Source
+	if source == "" {
+		exe, err := os.Executable()
+		if err != nil {
+			return nil, xerrors.Errorf("failed to get executable: %w", err)
+		}
+		source = strings.TrimSuffix(filepath.Base(exe), filepath.Ext(exe))
+	}
+
+	if opts.Install {
+		err := eventlog.InstallAsEventCreate(source, eventlog.Error|eventlog.Warning|eventlog.Info)
+		// Install only reports an existing source by its message.
+		if err != nil && !strings.Contains(err.Error(), "registry key already exists") {
+			return nil, xerrors.Errorf("failed to install event source %q: %w", source, err)
+		}
+	}
+
+	l, err := eventlog.Open(source)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to open event log of %q: %w", source, err)
+	}
+	return newSink(l, opts.EventID), nil
+}
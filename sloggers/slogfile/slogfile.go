@@ -0,0 +1,404 @@
+// Package slogfile contains a rotating log file to use
+// as the writer of the sloghuman and slogjson sinks.
+//
+//	f, err := slogfile.Open("/var/log/app.log", &slogfile.Options{
+//		MaxSize:    100 << 20,
+//		MaxBackups: 10,
+//		Compress:   true,
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	defer f.Close()
+//	l := slog.Make(slogjson.Sink(f))
+//	defer l.Close()
+//
+// The sinks do not close their writer as it is often os.Stderr
+// so the file must be closed after the Logger.
+//
+// Rotated files are renamed with the time of the rotation, e.g.
+// app-2019-09-10T20-19-07.159852000.log, next to the file.
+package slogfile // import "cdr.dev/slog/sloggers/slogfile"
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+)
+
+// Options represents the options of Open.
+type Options struct {
+	// MaxSize is the size in bytes after which the file is rotated.
+	//
+	// Defaults to no limit.
+	MaxSize int64
+
+	// Interval rotates the file at every multiple of the interval
+	// since the zero time in UTC, e.g. daily at midnight UTC
+	// for 24 hours.
+	//
+	// Defaults to never.
+	Interval time.Duration
+
+	// MaxBackups is the number of rotated files to keep.
+	//
+	// Defaults to keeping all of them.
+	MaxBackups int
+
+	// MaxAge is the duration after which rotated files are removed.
+	//
+	// Defaults to keeping all of them.
+	MaxAge time.Duration
+
+	// Compress gzips rotated files.
+	Compress bool
+
+	// ReopenOnSIGHUP reopens the file when the process receives
+	// SIGHUP so that external tools like logrotate can move it.
+	// It does nothing on Windows.
+	ReopenOnSIGHUP bool
+
+	// Mode defaults to 0644.
+	Mode os.FileMode
+}
+
+// File is a log file that rotates itself.
+// It is safe for concurrent use.
+type File struct {
+	path string
+	opts Options
+
+	mu sync.Mutex
+	// f is nil if the file could not be reopened
+	// after a rotation. The next write retries.
+	f      *os.File
+	closed bool
+	size   int64
+	rotate time.Time
+
+	stop func()
+	// cleaning serializes the background compression
+	// and removal of rotated files.
+	cleaning sync.Mutex
+	cleanWG  sync.WaitGroup
+}
+
+const backupTimeLayout = "2006-01-02T15-04-05.000000000"
+
+// Open opens or creates the file at path for appending.
+func Open(path string, opts *Options) (*File, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	f := &File{
+		path: path,
+		opts: *opts,
+		stop: func() {},
+	}
+	if f.opts.Mode == 0 {
+		f.opts.Mode = 0644
+	}
+
+	err := f.open()
+	if err != nil {
+		return nil, err
+	}
+	if f.opts.ReopenOnSIGHUP {
+		f.stop = notifyReopen(f)
+	}
+	return f, nil
+}
+
+func (f *File) open() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, f.opts.Mode)
+	if err != nil {
+		return xerrors.Errorf("failed to open log file: %w", err)
+	}
+	fi, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return xerrors.Errorf("failed to stat log file: %w", err)
+	}
+
+	f.f = file
+	f.size = fi.Size()
+	if f.opts.Interval > 0 {
+		f.rotate = time.Now().UTC().Truncate(f.opts.Interval).Add(f.opts.Interval)
+	}
+	return nil
+}
+
+// Write writes p to the file after rotating it if p would
+// exceed MaxSize or the interval has passed.
+//
+// If the file could not be reopened after a rotation,
+// Write tries to open it again.
+func (f *File) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	err := f.checkLocked()
+	if err != nil {
+		return 0, err
+	}
+
+	full := f.opts.MaxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.opts.MaxSize
+	due := !f.rotate.IsZero() && !time.Now().Before(f.rotate)
+	if full || due {
+		err := f.rotateLocked()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.f.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate rotates the file.
+func (f *File) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	err := f.checkLocked()
+	if err != nil {
+		return err
+	}
+	return f.rotateLocked()
+}
+
+// checkLocked returns an error if the file is closed
+// and reopens it if a previous attempt failed.
+func (f *File) checkLocked() error {
+	if f.closed {
+		return xerrors.New("log file is closed")
+	}
+	if f.f == nil {
+		return f.open()
+	}
+	return nil
+}
+
+func (f *File) rotateLocked() error {
+	err := f.f.Close()
+	if err != nil {
+		return xerrors.Errorf("failed to close log file: %w", err)
+	}
+	f.f = nil
+
+	renameErr := os.Rename(f.path, f.backupPath())
+	if os.IsNotExist(renameErr) {
+		renameErr = nil
+	}
+	// The file is reopened even if it could not be renamed
+	// so that logging continues.
+	err = f.open()
+	if err != nil {
+		return err
+	}
+	if renameErr != nil {
+		return xerrors.Errorf("failed to rename log file: %w", renameErr)
+	}
+
+	f.cleanWG.Add(1)
+	go func() {
+		defer f.cleanWG.Done()
+		f.clean()
+	}()
+	return nil
+}
+
+// Reopen closes and reopens the file so that the
+// file is created again if it was moved.
+func (f *File) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return xerrors.New("log file is closed")
+	}
+	if f.f != nil {
+		err := f.f.Close()
+		f.f = nil
+		if err != nil {
+			return xerrors.Errorf("failed to close log file: %w", err)
+		}
+	}
+	return f.open()
+}
+
+// Sync syncs the file to disk.
+func (f *File) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.f == nil {
+		return nil
+	}
+	return f.f.Sync()
+}
+
+// Close closes the file and waits for rotated
+// files to be compressed and removed.
+func (f *File) Close() error {
+	f.stop()
+
+	f.mu.Lock()
+	var err error
+	if f.f != nil {
+		err = f.f.Close()
+		f.f = nil
+	}
+	f.closed = true
+	f.mu.Unlock()
+
+	f.cleanWG.Wait()
+	return err
+}
+
+// backupPath returns an unused path for the rotated file.
+func (f *File) backupPath() string {
+	dir, prefix, ext := f.split()
+	t := time.Now().UTC()
+	for {
+		path := filepath.Join(dir, prefix+t.Format(backupTimeLayout)+ext)
+		_, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			_, err = os.Stat(path + ".gz")
+			if os.IsNotExist(err) {
+				return path
+			}
+		}
+		t = t.Add(time.Nanosecond)
+	}
+}
+
+// split returns the directory of the file and the prefix
+// and extension of the rotated files.
+func (f *File) split() (dir, prefix, ext string) {
+	dir, name := filepath.Split(f.path)
+	ext = filepath.Ext(name)
+	return dir, strings.TrimSuffix(name, ext) + "-", ext
+}
+
+type backup struct {
+	path string
+	t    time.Time
+}
+
+// backups returns the rotated files from the newest to the oldest.
+func (f *File) backups() ([]backup, error) {
+	dir, prefix, ext := f.split()
+	if dir == "" {
+		dir = "."
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	names, err := d.Readdirnames(-1)
+	d.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []backup
+	for _, name := range names {
+		ts := strings.TrimPrefix(name, prefix)
+		if ts == name {
+			continue
+		}
+		ts = strings.TrimSuffix(ts, ".gz")
+		if !strings.HasSuffix(ts, ext) {
+			continue
+		}
+		t, err := time.Parse(backupTimeLayout, strings.TrimSuffix(ts, ext))
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{
+			path: filepath.Join(dir, name),
+			t:    t,
+		})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].t.After(backups[j].t)
+	})
+	return backups, nil
+}
+
+// clean compresses and removes rotated files.
+// It runs in the background so errors are reported
+// with slog.ReportError.
+func (f *File) clean() {
+	f.cleaning.Lock()
+	defer f.cleaning.Unlock()
+
+	backups, err := f.backups()
+	if err != nil {
+		slog.ReportError(context.Background(), xerrors.Errorf("slogfile: failed to list rotated files: %w", err))
+		return
+	}
+	for i, b := range backups {
+		expired := f.opts.MaxAge > 0 && time.Since(b.t) > f.opts.MaxAge
+		if (f.opts.MaxBackups > 0 && i >= f.opts.MaxBackups) || expired {
+			err = os.Remove(b.path)
+			if err != nil && !os.IsNotExist(err) {
+				slog.ReportError(context.Background(), xerrors.Errorf("slogfile: failed to remove rotated file: %w", err))
+			}
+			continue
+		}
+		if f.opts.Compress && !strings.HasSuffix(b.path, ".gz") {
+			err = compress(b.path)
+			if err != nil {
+				slog.ReportError(context.Background(), xerrors.Errorf("slogfile: failed to compress %v: %w", b.path, err))
+			}
+		}
+	}
+}
+
+// compress gzips the file at path into path.gz
+// and removes it.
+func compress(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	fi, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	dst, err := os.OpenFile(path+".gz", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fi.Mode())
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	zw := gzip.NewWriter(dst)
+	_, err = io.Copy(zw, src)
+	if err == nil {
+		err = zw.Close()
+	}
+	if err == nil {
+		err = dst.Close()
+	}
+	if err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+	src.Close()
+	return os.Remove(path)
+}
@@ -0,0 +1,199 @@
+package slogfile_test
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"cdr.dev/slog/internal/assert"
+	"cdr.dev/slog/sloggers/slogfile"
+)
+
+func TestFile_maxSize(t *testing.T) {
+	t.Parallel()
+
+	dir := tempDir(t)
+	path := filepath.Join(dir, "app.log")
+	f, err := slogfile.Open(path, &slogfile.Options{
+		MaxSize:    8,
+		MaxBackups: 2,
+	})
+	assert.Success(t, "open", err)
+
+	for _, line := range []string{"one\n", "two\n", "three\n", "four\n", "five\n"} {
+		_, err = f.Write([]byte(line))
+		assert.Success(t, "write", err)
+	}
+	err = f.Close()
+	assert.Success(t, "close", err)
+
+	assert.Equal(t, "file", "five\n", readFile(t, path))
+
+	backups := backups(t, dir)
+	assert.Len(t, "backups", 2, backups)
+	assert.True(t, "name", strings.HasPrefix(filepath.Base(backups[0]), "app-"))
+	assert.True(t, "ext", strings.HasSuffix(backups[0], ".log"))
+	assert.Equal(t, "older backup", "three\n", readFile(t, backups[0]))
+	assert.Equal(t, "newer backup", "four\n", readFile(t, backups[1]))
+}
+
+func TestFile_compress(t *testing.T) {
+	t.Parallel()
+
+	dir := tempDir(t)
+	path := filepath.Join(dir, "app.log")
+	f, err := slogfile.Open(path, &slogfile.Options{
+		Compress: true,
+	})
+	assert.Success(t, "open", err)
+
+	_, err = f.Write([]byte("one\n"))
+	assert.Success(t, "write", err)
+	err = f.Rotate()
+	assert.Success(t, "rotate", err)
+	_, err = f.Write([]byte("two\n"))
+	assert.Success(t, "write", err)
+	err = f.Close()
+	assert.Success(t, "close", err)
+
+	backups := backups(t, dir)
+	assert.Len(t, "backups", 1, backups)
+	assert.True(t, "gzipped", strings.HasSuffix(backups[0], ".log.gz"))
+
+	gz, err := os.Open(backups[0])
+	assert.Success(t, "open backup", err)
+	defer gz.Close()
+	zr, err := gzip.NewReader(gz)
+	assert.Success(t, "gzip", err)
+	b, err := ioutil.ReadAll(zr)
+	assert.Success(t, "read backup", err)
+	assert.Equal(t, "backup", "one\n", string(b))
+	assert.Equal(t, "file", "two\n", readFile(t, path))
+}
+
+func TestFile_interval(t *testing.T) {
+	t.Parallel()
+
+	dir := tempDir(t)
+	path := filepath.Join(dir, "app.log")
+	f, err := slogfile.Open(path, &slogfile.Options{
+		Interval: 50 * time.Millisecond,
+	})
+	assert.Success(t, "open", err)
+	defer f.Close()
+
+	_, err = f.Write([]byte("one\n"))
+	assert.Success(t, "write", err)
+	time.Sleep(100 * time.Millisecond)
+	_, err = f.Write([]byte("two\n"))
+	assert.Success(t, "write", err)
+
+	assert.Equal(t, "file", "two\n", readFile(t, path))
+	assert.Len(t, "backups", 1, backups(t, dir))
+}
+
+func TestFile_reopen(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("windows lacks SIGHUP and cannot rename open files")
+	}
+
+	dir := tempDir(t)
+	path := filepath.Join(dir, "app.log")
+	f, err := slogfile.Open(path, &slogfile.Options{
+		ReopenOnSIGHUP: true,
+	})
+	assert.Success(t, "open", err)
+	defer f.Close()
+
+	_, err = f.Write([]byte("one\n"))
+	assert.Success(t, "write", err)
+
+	// Emulate logrotate.
+	err = os.Rename(path, path+".1")
+	assert.Success(t, "rename", err)
+	p, err := os.FindProcess(os.Getpid())
+	assert.Success(t, "find process", err)
+	err = p.Signal(syscall.SIGHUP)
+	assert.Success(t, "signal", err)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		_, err = os.Stat(path)
+		if err == nil || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	assert.Success(t, "reopened", err)
+
+	_, err = f.Write([]byte("two\n"))
+	assert.Success(t, "write", err)
+	assert.Equal(t, "file", "two\n", readFile(t, path))
+	assert.Equal(t, "moved file", "one\n", readFile(t, path+".1"))
+}
+
+func tempDir(t *testing.T) string {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "slogfile")
+	assert.Success(t, "temp dir", err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+
+	b, err := ioutil.ReadFile(path)
+	assert.Success(t, "read file", err)
+	return string(b)
+}
+
+// backups returns the rotated files from the oldest to the newest.
+func backups(t *testing.T, dir string) []string {
+	t.Helper()
+
+	paths, err := filepath.Glob(filepath.Join(dir, "app-*"))
+	assert.Success(t, "glob", err)
+	sort.Strings(paths)
+	return paths
+}
+
+func TestFile_rotateOpenFailure(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("windows cannot remove the directory of an open file")
+	}
+
+	dir := filepath.Join(tempDir(t), "logs")
+	err := os.Mkdir(dir, 0755)
+	assert.Success(t, "mkdir", err)
+	path := filepath.Join(dir, "app.log")
+	f, err := slogfile.Open(path, nil)
+	assert.Success(t, "open", err)
+	defer f.Close()
+
+	err = os.RemoveAll(dir)
+	assert.Success(t, "remove dir", err)
+	err = f.Rotate()
+	assert.Error(t, "rotate", err)
+	_, err = f.Write([]byte("one\n"))
+	assert.Error(t, "write", err)
+
+	// The file is opened again once it can be.
+	err = os.Mkdir(dir, 0755)
+	assert.Success(t, "mkdir", err)
+	_, err = f.Write([]byte("two\n"))
+	assert.Success(t, "write", err)
+	assert.Equal(t, "file", "two\n", readFile(t, path))
+}
@@ -0,0 +1,45 @@
+//go:build !windows
+// +build !windows
+
+package slogfile
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+)
+
+// notifyReopen reopens f on SIGHUP until stop is called.
+func notifyReopen(f *File) (stop func()) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGHUP)
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-c:
+				err := f.Reopen()
+				if err != nil {
+					slog.ReportError(context.Background(), xerrors.Errorf("slogfile: failed to reopen on SIGHUP: %w", err))
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(c)
+			close(done)
+		})
+	}
+}
@@ -0,0 +1,6 @@
+package slogfile
+
+// notifyReopen does nothing as Windows lacks SIGHUP.
+func notifyReopen(f *File) (stop func()) {
+	return func() {}
+}
@@ -0,0 +1,121 @@
+// Package slogflag contains helpers to configure a Logger
+// from command line flags.
+package slogflag // import "cdr.dev/slog/sloggers/slogflag"
+
+import (
+	"flag"
+	"io"
+	"os"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/sloggers/sloghuman"
+	"cdr.dev/slog/sloggers/slogjson"
+)
+
+// Config holds the values of the flags registered by Register.
+type Config struct {
+	// Level is parsed with slog.ParseLevels so it may configure
+	// components individually, e.g. "comp.db=debug,default=warn".
+	//
+	// Defaults to "info".
+	Level string
+	// Format is either "human" or "json".
+	//
+	// Defaults to "human".
+	Format string
+	// Output is "stderr", "stdout" or the path of a file
+	// that entries are appended to.
+	//
+	// Defaults to "stderr".
+	Output string
+}
+
+// Register registers the -log-level, -log-format and -log-output
+// flags on fs and returns the Config they are parsed into.
+//
+// If fs is nil, flag.CommandLine is used.
+func Register(fs *flag.FlagSet) *Config {
+	if fs == nil {
+		fs = flag.CommandLine
+	}
+
+	c := &Config{}
+	fs.StringVar(&c.Level, "log-level", "info", `log level, e.g. "debug" or "comp.db=debug,default=warn"`)
+	fs.StringVar(&c.Format, "log-format", "human", `log format, "human" or "json"`)
+	fs.StringVar(&c.Output, "log-output", "stderr", `log output, "stderr", "stdout" or a file path`)
+	return c
+}
+
+// Logger creates a Logger from the Config.
+//
+// Call Close on the Logger before the program exits
+// to close the output file.
+func (c *Config) Logger() (slog.Logger, error) {
+	levels, err := slog.ParseLevels(c.Level)
+	if err != nil {
+		return slog.Logger{}, xerrors.Errorf("invalid -log-level: %w", err)
+	}
+
+	var w io.Writer
+	var closer io.Closer
+	switch c.Output {
+	case "", "stderr":
+		w = os.Stderr
+	case "stdout":
+		w = os.Stdout
+	default:
+		f, err := os.OpenFile(c.Output, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			return slog.Logger{}, xerrors.Errorf("failed to open -log-output: %w", err)
+		}
+		w = f
+		closer = f
+	}
+
+	var s slog.Sink
+	switch c.Format {
+	case "", "human":
+		s = sloghuman.Sink(w)
+	case "json":
+		s = slogjson.Sink(w)
+	default:
+		if closer != nil {
+			closer.Close()
+		}
+		return slog.Logger{}, xerrors.Errorf("invalid -log-format %q", c.Format)
+	}
+
+	if closer != nil {
+		s = closeSink{
+			Sink:   s,
+			closer: closer,
+		}
+	}
+
+	s = slog.ComponentFilter(s, levels)
+	return slog.Make(s).Leveled(minLevel(levels)), nil
+}
+
+// minLevel returns the most verbose configured level
+// so that the Logger does not drop entries a component
+// is configured to log.
+func minLevel(c *slog.ComponentLevels) slog.Level {
+	min := slog.LevelFatal
+	for _, level := range c.Levels() {
+		if level < min {
+			min = level
+		}
+	}
+	return min
+}
+
+type closeSink struct {
+	slog.Sink
+	closer io.Closer
+}
+
+func (s closeSink) Close() error {
+	return s.closer.Close()
+}
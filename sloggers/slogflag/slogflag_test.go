@@ -0,0 +1,60 @@
+package slogflag_test
+
+import (
+	"context"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"cdr.dev/slog/internal/assert"
+	"cdr.dev/slog/sloggers/slogflag"
+)
+
+var bg = context.Background()
+
+func TestConfig(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "slogflag")
+	assert.Success(t, "tempdir", err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "log.json")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	c := slogflag.Register(fs)
+	err = fs.Parse([]string{
+		"-log-level", "comp.db=debug,default=warn",
+		"-log-format", "json",
+		"-log-output", path,
+	})
+	assert.Success(t, "parse", err)
+
+	l, err := c.Logger()
+	assert.Success(t, "logger", err)
+
+	l.Info(bg, "info")
+	l.Named("comp").Named("db").Debug(bg, "debug")
+	l.Warn(bg, "warn")
+	err = l.Close()
+	assert.Success(t, "close", err)
+
+	b, err := ioutil.ReadFile(path)
+	assert.Success(t, "read", err)
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	assert.Len(t, "lines", 2, lines)
+	assert.True(t, "debug", strings.Contains(lines[0], `"msg":"debug"`))
+	assert.True(t, "warn", strings.Contains(lines[1], `"msg":"warn"`))
+}
+
+func TestConfigInvalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := (&slogflag.Config{Level: "loud"}).Logger()
+	assert.Error(t, "level", err)
+
+	_, err = (&slogflag.Config{Format: "xml"}).Logger()
+	assert.Error(t, "format", err)
+}
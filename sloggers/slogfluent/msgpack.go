@@ -0,0 +1,206 @@
+package slogfluent
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog/internal/entryjson"
+)
+
+// The subset of MessagePack needed for the forward protocol.
+// See https://github.com/msgpack/msgpack/blob/master/spec.md
+
+func appendArrayHeader(b []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(b, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		return append(b, 0xdc, byte(n>>8), byte(n))
+	default:
+		b = append(b, 0xdd)
+		return appendUint32(b, uint32(n))
+	}
+}
+
+func appendMapHeader(b []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(b, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		return append(b, 0xde, byte(n>>8), byte(n))
+	default:
+		b = append(b, 0xdf)
+		return appendUint32(b, uint32(n))
+	}
+}
+
+func appendString(b []byte, s string) []byte {
+	n := len(s)
+	switch {
+	case n < 32:
+		b = append(b, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		b = append(b, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		b = append(b, 0xda, byte(n>>8), byte(n))
+	default:
+		b = append(b, 0xdb)
+		b = appendUint32(b, uint32(n))
+	}
+	return append(b, s...)
+}
+
+func appendInt(b []byte, i int64) []byte {
+	switch {
+	case i >= 0 && i < 128:
+		return append(b, byte(i))
+	case i < 0 && i >= -32:
+		return append(b, byte(i))
+	default:
+		b = append(b, 0xd3)
+		var buf [8]byte
+		binary.BigEndian.PutUint64(buf[:], uint64(i))
+		return append(b, buf[:]...)
+	}
+}
+
+func appendFloat(b []byte, f float64) []byte {
+	b = append(b, 0xcb)
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], math.Float64bits(f))
+	return append(b, buf[:]...)
+}
+
+func appendUint32(b []byte, n uint32) []byte {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], n)
+	return append(b, buf[:]...)
+}
+
+// appendEventTime appends the EventTime extension
+// with nanosecond precision.
+func appendEventTime(b []byte, sec, nsec int64) []byte {
+	// fixext 8 with type 0.
+	b = append(b, 0xd7, 0x00)
+	b = appendUint32(b, uint32(sec))
+	return appendUint32(b, uint32(nsec))
+}
+
+// appendValue appends a value decoded by entryjson.
+func appendValue(b []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case entryjson.Object:
+		b = appendMapHeader(b, len(v))
+		for _, f := range v {
+			b = appendString(b, f.Key)
+			b = appendValue(b, f.Value)
+		}
+		return b
+	case []interface{}:
+		b = appendArrayHeader(b, len(v))
+		for _, el := range v {
+			b = appendValue(b, el)
+		}
+		return b
+	case string:
+		return appendString(b, v)
+	case json.Number:
+		if i, err := strconv.ParseInt(v.String(), 10, 64); err == nil {
+			return appendInt(b, i)
+		}
+		f, _ := strconv.ParseFloat(v.String(), 64)
+		return appendFloat(b, f)
+	case bool:
+		if v {
+			return append(b, 0xc3)
+		}
+		return append(b, 0xc2)
+	default:
+		return append(b, 0xc0)
+	}
+}
+
+// readAck reads the response of the server and
+// returns the value of its ack key.
+func readAck(r *bufio.Reader) (string, error) {
+	n, err := readMapHeader(r)
+	if err != nil {
+		return "", err
+	}
+	var ack string
+	for i := 0; i < n; i++ {
+		k, err := readString(r)
+		if err != nil {
+			return "", err
+		}
+		v, err := readString(r)
+		if err != nil {
+			return "", err
+		}
+		if k == "ack" {
+			ack = v
+		}
+	}
+	return ack, nil
+}
+
+func readMapHeader(r *bufio.Reader) (int, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case c&0xf0 == 0x80:
+		return int(c & 0x0f), nil
+	case c == 0xde:
+		return readLength(r, 2)
+	case c == 0xdf:
+		return readLength(r, 4)
+	}
+	return 0, xerrors.Errorf("expected a map but got 0x%x", c)
+}
+
+func readString(r *bufio.Reader) (string, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	var n int
+	switch {
+	case c&0xe0 == 0xa0:
+		n = int(c & 0x1f)
+	case c == 0xd9, c == 0xc4:
+		n, err = readLength(r, 1)
+	case c == 0xda, c == 0xc5:
+		n, err = readLength(r, 2)
+	case c == 0xdb, c == 0xc6:
+		n, err = readLength(r, 4)
+	default:
+		return "", xerrors.Errorf("expected a string but got 0x%x", c)
+	}
+	if err != nil {
+		return "", err
+	}
+	b := make([]byte, n)
+	_, err = io.ReadFull(r, b)
+	return string(b), err
+}
+
+func readLength(r *bufio.Reader, size int) (int, error) {
+	b := make([]byte, size)
+	_, err := io.ReadFull(r, b)
+	if err != nil {
+		return 0, err
+	}
+	var n int
+	for _, c := range b {
+		n = n<<8 | int(c)
+	}
+	return n, nil
+}
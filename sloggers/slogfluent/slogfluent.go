@@ -0,0 +1,248 @@
+// Package slogfluent contains the slogger that sends entries to
+// Fluentd or Fluent Bit with the forward protocol.
+//
+// Every record has the same keys as slogjson:
+//
+//	{
+//	  "level": "INFO",
+//	  "logger_names": ["comp", "subcomp"],
+//	  "msg": "hi",
+//	  "caller": "slog/examples_test.go:62",
+//	  "func": "cdr.dev/slog/sloggers/slogtest_test.TestExampleTest",
+//	  "trace": "<traceid>",
+//	  "span": "<spanid>",
+//	  "fields": {
+//	    "my_field": "field value"
+//	  }
+//	}
+//
+// See https://github.com/fluent/fluentd/wiki/Forward-Protocol-Specification-v1
+package slogfluent // import "cdr.dev/slog/sloggers/slogfluent"
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opencensus.io/trace"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/entryjson"
+)
+
+// Options represents the options for the sink returned by Sink.
+type Options struct {
+	// Addr is the address of the forward input.
+	//
+	// Defaults to localhost:24224.
+	Addr string
+
+	// Tag is the tag of every entry. {logger} is replaced
+	// with the dotted logger names of the entry, or removed
+	// along with the dot before it if the entry has none.
+	//
+	// Defaults to slog.{logger}.
+	Tag string
+
+	// RequireAck waits for the server to acknowledge every
+	// write for at least once delivery. Unacknowledged writes
+	// are retried once on a new connection.
+	RequireAck bool
+
+	// Timeout is the timeout of connecting and of waiting
+	// for an acknowledgement.
+	//
+	// Defaults to 5 seconds.
+	Timeout time.Duration
+}
+
+// Sink creates a slog.Sink that sends entries with the forward protocol.
+//
+// The returned sink implements slog.BatchSink so that it can be wrapped
+// with slogbatch to send many entries per write and io.Closer.
+func Sink(opts *Options) (slog.Sink, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	s := &fluentSink{
+		addr:       opts.Addr,
+		tag:        opts.Tag,
+		requireAck: opts.RequireAck,
+		timeout:    opts.Timeout,
+	}
+	if s.addr == "" {
+		s.addr = "localhost:24224"
+	}
+	if s.tag == "" {
+		s.tag = "slog.{logger}"
+	}
+	if s.timeout <= 0 {
+		s.timeout = 5 * time.Second
+	}
+
+	err := s.connect()
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+type fluentSink struct {
+	addr       string
+	tag        string
+	requireAck bool
+	timeout    time.Duration
+
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func (s *fluentSink) connect() error {
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	var err error
+	s.conn, err = net.DialTimeout("tcp", s.addr, s.timeout)
+	if err != nil {
+		return xerrors.Errorf("failed to connect to %v: %w", s.addr, err)
+	}
+	s.r = bufio.NewReader(s.conn)
+	return nil
+}
+
+func (s *fluentSink) LogEntry(ctx context.Context, ent slog.SinkEntry) {
+	s.LogEntries(ctx, []slog.SinkEntry{ent})
+}
+
+// LogEntries sends ents in Forward mode messages,
+// one per run of entries with the same tag.
+func (s *fluentSink) LogEntries(ctx context.Context, ents []slog.SinkEntry) {
+	for len(ents) > 0 {
+		tag := s.tagOf(ents[0])
+		n := 1
+		for n < len(ents) && s.tagOf(ents[n]) == tag {
+			n++
+		}
+
+		err := s.send(tag, ents[:n])
+		if err != nil {
+			slog.ReportError(ctx, xerrors.Errorf("slogfluent: failed to write %v entries: %w", n, err), ents[:n]...)
+		}
+		ents = ents[n:]
+	}
+}
+
+func (s *fluentSink) send(tag string, ents []slog.SinkEntry) error {
+	// [tag, [[time, record], ...], option]
+	b := make([]byte, 0, 256*len(ents))
+	b = appendArrayHeader(b, 3)
+	b = appendString(b, tag)
+	b = appendArrayHeader(b, len(ents))
+	for _, ent := range ents {
+		b = appendArrayHeader(b, 2)
+		b = appendEventTime(b, ent.Time.Unix(), int64(ent.Time.Nanosecond()))
+		b = appendRecord(b, ent)
+	}
+
+	var chunk string
+	if s.requireAck {
+		var id [16]byte
+		_, err := rand.Read(id[:])
+		if err != nil {
+			return xerrors.Errorf("failed to generate chunk id: %w", err)
+		}
+		chunk = base64.StdEncoding.EncodeToString(id[:])
+		b = appendMapHeader(b, 1)
+		b = appendString(b, "chunk")
+		b = appendString(b, chunk)
+	} else {
+		b = appendMapHeader(b, 0)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.write(b, chunk)
+	if err != nil {
+		err = s.connect()
+		if err != nil {
+			return err
+		}
+		err = s.write(b, chunk)
+	}
+	return err
+}
+
+func (s *fluentSink) write(b []byte, chunk string) error {
+	_, err := s.conn.Write(b)
+	if err != nil {
+		return err
+	}
+	if chunk == "" {
+		return nil
+	}
+
+	s.conn.SetReadDeadline(time.Now().Add(s.timeout))
+	defer s.conn.SetReadDeadline(time.Time{})
+	ack, err := readAck(s.r)
+	if err != nil {
+		return xerrors.Errorf("failed to read ack: %w", err)
+	}
+	if ack != chunk {
+		return xerrors.Errorf("unexpected ack %q for chunk %q", ack, chunk)
+	}
+	return nil
+}
+
+func (s *fluentSink) tagOf(ent slog.SinkEntry) string {
+	names := strings.Join(ent.LoggerNames, ".")
+	if names == "" {
+		tag := strings.Replace(s.tag, ".{logger}", "", -1)
+		return strings.Replace(tag, "{logger}", "", -1)
+	}
+	return strings.Replace(s.tag, "{logger}", names, -1)
+}
+
+func appendRecord(b []byte, ent slog.SinkEntry) []byte {
+	m := slog.M(
+		slog.F("level", ent.Level),
+	)
+	if len(ent.LoggerNames) > 0 {
+		m = append(m, slog.F("logger_names", ent.LoggerNames))
+	}
+	m = append(m,
+		slog.F("msg", ent.Message),
+		slog.F("caller", ent.File+":"+strconv.Itoa(ent.Line)),
+		slog.F("func", ent.Func),
+	)
+	if ent.SpanContext != (trace.SpanContext{}) {
+		m = append(m,
+			slog.F("trace", ent.SpanContext.TraceID.String()),
+			slog.F("span", ent.SpanContext.SpanID.String()),
+		)
+	}
+	if len(ent.Fields) > 0 {
+		m = append(m, slog.F("fields", ent.Fields))
+	}
+
+	// No error is guaranteed due to slog.Map handling errors itself.
+	j, _ := m.MarshalJSON()
+	v, _ := entryjson.Decode(j)
+	return appendValue(b, v)
+}
+
+func (s *fluentSink) Sync() {}
+
+func (s *fluentSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conn.Close()
+}
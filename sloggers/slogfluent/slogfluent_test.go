@@ -0,0 +1,239 @@
+package slogfluent_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+	"cdr.dev/slog/sloggers/slogfluent"
+)
+
+var bg = context.Background()
+
+var kt = time.Date(2000, time.February, 5, 4, 4, 4, 123456789, time.UTC)
+
+func TestSink(t *testing.T) {
+	t.Parallel()
+
+	msgs, addr := listen(t, false)
+
+	s, err := slogfluent.Sink(&slogfluent.Options{
+		Addr: addr,
+		Tag:  "app.{logger}",
+	})
+	assert.Success(t, "sink", err)
+	defer s.(interface{ Close() error }).Close()
+
+	s.LogEntry(bg, slog.SinkEntry{
+		Time:        kt,
+		Level:       slog.LevelWarn,
+		LoggerNames: []string{"comp", "db"},
+		Message:     "hi",
+		File:        "main.go",
+		Line:        62,
+		Func:        "main.main",
+		Fields: slog.M(
+			slog.F("id", 1),
+			slog.F("ratio", 0.5),
+			slog.F("req", slog.M(slog.F("ok", true))),
+		),
+	})
+
+	assert.Equal(t, "message", []interface{}{
+		"app.comp.db",
+		[]interface{}{
+			[]interface{}{
+				eventTime{949723444, 123456789},
+				map[string]interface{}{
+					"level":        "WARN",
+					"logger_names": []interface{}{"comp", "db"},
+					"msg":          "hi",
+					"caller":       "main.go:62",
+					"func":         "main.main",
+					"fields": map[string]interface{}{
+						"id":    int64(1),
+						"ratio": 0.5,
+						"req":   map[string]interface{}{"ok": true},
+					},
+				},
+			},
+		},
+		map[string]interface{}{},
+	}, <-msgs)
+}
+
+func TestSink_batch(t *testing.T) {
+	t.Parallel()
+
+	msgs, addr := listen(t, true)
+
+	s, err := slogfluent.Sink(&slogfluent.Options{
+		Addr:       addr,
+		RequireAck: true,
+	})
+	assert.Success(t, "sink", err)
+	defer s.(interface{ Close() error }).Close()
+
+	s.(slog.BatchSink).LogEntries(bg, []slog.SinkEntry{
+		{Time: kt, Message: "1"},
+		{Time: kt, Message: "2"},
+		{Time: kt, Message: "3", LoggerNames: []string{"comp"}},
+	})
+
+	msg := (<-msgs).([]interface{})
+	assert.Equal(t, "tag", "slog", msg[0])
+	assert.Len(t, "entries", 2, msg[1])
+	assert.Len(t, "option", 1, msg[2])
+
+	msg = (<-msgs).([]interface{})
+	assert.Equal(t, "tag", "slog.comp", msg[0])
+	assert.Len(t, "entries", 1, msg[1])
+}
+
+type eventTime struct {
+	sec, nsec uint32
+}
+
+// listen accepts a single connection and decodes every message
+// into msgs. If ack is set, every chunk is acknowledged.
+func listen(t *testing.T, ack bool) (<-chan interface{}, string) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Success(t, "listen", err)
+	t.Cleanup(func() { ln.Close() })
+
+	msgs := make(chan interface{}, 16)
+	go func() {
+		c, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+
+		r := bufio.NewReader(c)
+		for {
+			msg, err := decode(r)
+			if err != nil {
+				return
+			}
+			msgs <- msg
+
+			if ack {
+				option := msg.([]interface{})[2].(map[string]interface{})
+				chunk := option["chunk"].(string)
+				_, err = c.Write(append([]byte{0x81, 0xa3, 'a', 'c', 'k', 0xa0 | byte(len(chunk))}, chunk...))
+				if err != nil {
+					return
+				}
+			}
+		}
+	}()
+	return msgs, ln.Addr().String()
+}
+
+// decode decodes the subset of msgpack that the sink writes.
+func decode(r *bufio.Reader) (interface{}, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xf0 == 0x80:
+		return decodeMap(r, int(c&0x0f))
+	case c&0xf0 == 0x90:
+		return decodeArray(r, int(c&0x0f))
+	case c&0xe0 == 0xa0:
+		return decodeString(r, int(c&0x1f))
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xcb:
+		b, err := read(r, 8)
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), err
+	case 0xd3:
+		b, err := read(r, 8)
+		return int64(binary.BigEndian.Uint64(b)), err
+	case 0xd7:
+		b, err := read(r, 9)
+		if err != nil {
+			return nil, err
+		}
+		return eventTime{binary.BigEndian.Uint32(b[1:]), binary.BigEndian.Uint32(b[5:])}, nil
+	case 0xd9:
+		n, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		return decodeString(r, int(n))
+	case 0xdc:
+		b, err := read(r, 2)
+		if err != nil {
+			return nil, err
+		}
+		return decodeArray(r, int(binary.BigEndian.Uint16(b)))
+	case 0xde:
+		b, err := read(r, 2)
+		if err != nil {
+			return nil, err
+		}
+		return decodeMap(r, int(binary.BigEndian.Uint16(b)))
+	}
+	return nil, io.ErrUnexpectedEOF
+}
+
+func decodeMap(r *bufio.Reader, n int) (interface{}, error) {
+	m := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		k, err := decode(r)
+		if err != nil {
+			return nil, err
+		}
+		v, err := decode(r)
+		if err != nil {
+			return nil, err
+		}
+		m[k.(string)] = v
+	}
+	return m, nil
+}
+
+func decodeArray(r *bufio.Reader, n int) (interface{}, error) {
+	a := make([]interface{}, n)
+	for i := range a {
+		v, err := decode(r)
+		if err != nil {
+			return nil, err
+		}
+		a[i] = v
+	}
+	return a, nil
+}
+
+func decodeString(r *bufio.Reader, n int) (interface{}, error) {
+	b, err := read(r, n)
+	return string(b), err
+}
+
+func read(r *bufio.Reader, n int) ([]byte, error) {
+	b := make([]byte, n)
+	_, err := io.ReadFull(r, b)
+	return b, err
+}
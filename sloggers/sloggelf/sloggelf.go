@@ -0,0 +1,291 @@
+// Package sloggelf contains the slogger that sends
+// GELF messages to Graylog.
+//
+// Format
+//
+//	{
+//	  "version": "1.1",
+//	  "host": "myhost",
+//	  "short_message": "hi",
+//	  "timestamp": 1568146747.159,
+//	  "level": 6,
+//	  "_logger": "comp.subcomp",
+//	  "_file": "main.go",
+//	  "_line": 62,
+//	  "_func": "main.run",
+//	  "_trace_id": "<traceid>",
+//	  "_span_id": "<spanid>",
+//	  "_my_field": "field value"
+//	}
+//
+// See https://go2docs.graylog.org/current/getting_in_log_data/gelf.html
+package sloggelf // import "cdr.dev/slog/sloggers/sloggelf"
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opencensus.io/trace"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/entryjson"
+	"cdr.dev/slog/internal/severity"
+)
+
+// Options represents the options for the sink returned by Sink.
+type Options struct {
+	// Network is "udp" or "tcp".
+	//
+	// Defaults to "udp".
+	Network string
+
+	// Addr is the address of the GELF input, e.g. graylog:12201.
+	Addr string
+
+	// Host defaults to os.Hostname.
+	Host string
+
+	// ChunkSize is the maximum size of a UDP datagram.
+	// Larger messages are chunked.
+	//
+	// Defaults to 1420 which fits in the MTU of most networks.
+	ChunkSize int
+
+	// Compress gzips UDP messages.
+	Compress bool
+
+	// DialTimeout defaults to 5 seconds.
+	DialTimeout time.Duration
+}
+
+// Sink creates a slog.Sink that sends GELF messages.
+//
+// Levels are mapped to syslog severities like slogsyslog. Multiline
+// messages are sent as the full_message with their first line as the
+// short_message. Nested fields are flattened into dotted names and
+// prefixed with an underscore as additional fields.
+//
+// Over UDP, messages larger than ChunkSize are split into at most 128
+// chunks and larger messages are dropped. Over TCP, messages are
+// delimited by a null byte and the sink reconnects and retries once
+// if a write fails.
+//
+// The returned sink implements io.Closer.
+func Sink(opts *Options) (slog.Sink, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	s := &gelfSink{
+		network:     opts.Network,
+		addr:        opts.Addr,
+		host:        opts.Host,
+		chunkSize:   opts.ChunkSize,
+		compress:    opts.Compress,
+		dialTimeout: opts.DialTimeout,
+	}
+	if s.network == "" {
+		s.network = "udp"
+	}
+	if s.network != "udp" && s.network != "tcp" {
+		return nil, xerrors.Errorf("unsupported network %q", s.network)
+	}
+	if s.host == "" {
+		s.host, _ = os.Hostname()
+	}
+	if s.chunkSize <= chunkHeaderSize {
+		s.chunkSize = 1420
+	}
+	if s.dialTimeout <= 0 {
+		s.dialTimeout = 5 * time.Second
+	}
+
+	err := s.connect()
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+type gelfSink struct {
+	network     string
+	addr        string
+	host        string
+	chunkSize   int
+	compress    bool
+	dialTimeout time.Duration
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+func (s *gelfSink) connect() error {
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	var err error
+	s.conn, err = net.DialTimeout(s.network, s.addr, s.dialTimeout)
+	if err != nil {
+		return xerrors.Errorf("failed to connect to %v %v: %w", s.network, s.addr, err)
+	}
+	return nil
+}
+
+func (s *gelfSink) LogEntry(ctx context.Context, ent slog.SinkEntry) {
+	msg, err := message(s.host, ent).MarshalJSON()
+	if err != nil {
+		slog.ReportError(ctx, xerrors.Errorf("sloggelf: failed to encode entry: %w", err), ent)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.network == "udp" {
+		err = s.writeUDP(msg)
+	} else {
+		err = s.writeTCP(msg)
+	}
+	if err != nil {
+		slog.ReportError(ctx, xerrors.Errorf("sloggelf: failed to write entry: %w", err), ent)
+	}
+}
+
+func (s *gelfSink) writeTCP(msg []byte) error {
+	msg = append(msg, 0)
+	_, err := s.conn.Write(msg)
+	if err != nil {
+		err = s.connect()
+		if err != nil {
+			return err
+		}
+		_, err = s.conn.Write(msg)
+	}
+	return err
+}
+
+const (
+	chunkHeaderSize = 12
+	maxChunks       = 128
+)
+
+func (s *gelfSink) writeUDP(msg []byte) error {
+	if s.compress {
+		var b bytes.Buffer
+		w := gzip.NewWriter(&b)
+		w.Write(msg)
+		w.Close()
+		msg = b.Bytes()
+	}
+
+	if len(msg) <= s.chunkSize {
+		_, err := s.conn.Write(msg)
+		return err
+	}
+
+	size := s.chunkSize - chunkHeaderSize
+	n := (len(msg) + size - 1) / size
+	if n > maxChunks {
+		return xerrors.Errorf("message of %v bytes needs more than %v chunks", len(msg), maxChunks)
+	}
+
+	var id [8]byte
+	_, err := rand.Read(id[:])
+	if err != nil {
+		return xerrors.Errorf("failed to generate message id: %w", err)
+	}
+
+	chunk := make([]byte, 0, s.chunkSize)
+	for i := 0; i < n; i++ {
+		end := (i + 1) * size
+		if end > len(msg) {
+			end = len(msg)
+		}
+		chunk = append(chunk[:0], 0x1e, 0x0f)
+		chunk = append(chunk, id[:]...)
+		chunk = append(chunk, byte(i), byte(n))
+		chunk = append(chunk, msg[i*size:end]...)
+		_, err := s.conn.Write(chunk)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *gelfSink) Sync() {}
+
+func (s *gelfSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conn.Close()
+}
+
+// message returns the GELF message of ent.
+func message(host string, ent slog.SinkEntry) slog.Map {
+	short := ent.Message
+	var full string
+	if i := strings.IndexByte(short, '\n'); i >= 0 {
+		full = short
+		short = strings.TrimSpace(short[:i])
+	}
+	if short == "" {
+		// short_message is required to be non empty.
+		short = "-"
+	}
+
+	m := slog.M(
+		slog.F("version", "1.1"),
+		slog.F("host", host),
+		slog.F("short_message", short),
+	)
+	if full != "" {
+		m = append(m, slog.F("full_message", full))
+	}
+	m = append(m,
+		slog.F("timestamp", float64(ent.Time.UnixNano()/int64(time.Millisecond))/1e3),
+		slog.F("level", severity.Syslog(ent.Level)),
+	)
+	if len(ent.LoggerNames) > 0 {
+		m = append(m, slog.F("_logger", strings.Join(ent.LoggerNames, ".")))
+	}
+	if ent.File != "" {
+		m = append(m,
+			slog.F("_file", ent.File),
+			slog.F("_line", ent.Line),
+		)
+	}
+	if ent.Func != "" {
+		m = append(m, slog.F("_func", ent.Func))
+	}
+	if ent.SpanContext != (trace.SpanContext{}) {
+		m = append(m,
+			slog.F("_trace_id", ent.SpanContext.TraceID.String()),
+			slog.F("_span_id", ent.SpanContext.SpanID.String()),
+		)
+	}
+	for _, f := range entryjson.Flatten(ent.Fields, ".") {
+		m = append(m, slog.F(fieldName(f.Key), f.Value))
+	}
+	return m
+}
+
+var invalidFieldChars = regexp.MustCompile(`[^\w.\-]`)
+
+// fieldName converts name into a valid additional field name.
+func fieldName(name string) string {
+	name = "_" + invalidFieldChars.ReplaceAllString(name, "_")
+	if name == "_id" {
+		// _id is reserved.
+		name = "_id_"
+	}
+	return name
+}
@@ -0,0 +1,143 @@
+package sloggelf_test
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+	"cdr.dev/slog/sloggers/sloggelf"
+)
+
+var bg = context.Background()
+
+var kt = time.Date(2000, time.February, 5, 4, 4, 4, 123456789, time.UTC)
+
+func TestUDP(t *testing.T) {
+	t.Parallel()
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Success(t, "listen", err)
+	defer pc.Close()
+
+	s, err := sloggelf.Sink(&sloggelf.Options{
+		Addr: pc.LocalAddr().String(),
+		Host: "myhost",
+	})
+	assert.Success(t, "sink", err)
+	defer s.(interface{ Close() error }).Close()
+
+	s.LogEntry(bg, slog.SinkEntry{
+		Time:        kt,
+		Level:       slog.LevelWarn,
+		LoggerNames: []string{"comp"},
+		Message:     "line1\nline2",
+		File:        "main.go",
+		Line:        62,
+		Fields: slog.M(
+			slog.F("id", 1),
+			slog.F("req", slog.M(slog.F("bad key", true))),
+		),
+	})
+
+	b := make([]byte, 2048)
+	n, _, err := pc.ReadFrom(b)
+	assert.Success(t, "read", err)
+	assert.Equal(t, "message", `{"version":"1.1","host":"myhost","short_message":"line1","full_message":"line1\nline2",`+
+		`"timestamp":949723444.123,"level":4,"_logger":"comp","_file":"main.go","_line":62,"_id_":1,"_req.bad_key":true}`, string(b[:n]))
+}
+
+func TestUDP_chunked(t *testing.T) {
+	t.Parallel()
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Success(t, "listen", err)
+	defer pc.Close()
+
+	s, err := sloggelf.Sink(&sloggelf.Options{
+		Addr:      pc.LocalAddr().String(),
+		Host:      "myhost",
+		ChunkSize: 100,
+		Compress:  true,
+	})
+	assert.Success(t, "sink", err)
+	defer s.(interface{ Close() error }).Close()
+
+	msg := strings.Repeat("abcdefghijklmnopqrstuvwxyz", 100)
+	s.LogEntry(bg, slog.SinkEntry{Time: kt, Message: msg})
+
+	var chunks [][]byte
+	var id []byte
+	for {
+		b := make([]byte, 2048)
+		n, _, err := pc.ReadFrom(b)
+		assert.Success(t, "read", err)
+		b = b[:n]
+
+		assert.True(t, "size", n <= 100)
+		assert.Equal(t, "magic", []byte{0x1e, 0x0f}, b[:2])
+		if id == nil {
+			id = b[2:10]
+		}
+		assert.Equal(t, "id", id, b[2:10])
+		assert.Equal(t, "seq", len(chunks), int(b[10]))
+		chunks = append(chunks, b[12:])
+		if len(chunks) == int(b[11]) {
+			break
+		}
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(bytes.Join(chunks, nil)))
+	assert.Success(t, "gzip", err)
+	b, err := ioutil.ReadAll(r)
+	assert.Success(t, "read gzip", err)
+	assert.True(t, "message", strings.Contains(string(b), `"short_message":"`+msg+`"`))
+}
+
+func TestTCP(t *testing.T) {
+	t.Parallel()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Success(t, "listen", err)
+	defer ln.Close()
+
+	msgs := make(chan string)
+	go func() {
+		c, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		r := bufio.NewReader(c)
+		for {
+			msg, err := r.ReadString(0)
+			if err != nil {
+				return
+			}
+			msgs <- msg
+		}
+	}()
+
+	s, err := sloggelf.Sink(&sloggelf.Options{
+		Network: "tcp",
+		Addr:    ln.Addr().String(),
+		Host:    "myhost",
+	})
+	assert.Success(t, "sink", err)
+	defer s.(interface{ Close() error }).Close()
+
+	l := slog.Make(s)
+	l.Info(bg, "a")
+	l.Info(bg, "b")
+	assert.True(t, "a", strings.Contains(<-msgs, `"short_message":"a"`))
+	msg := <-msgs
+	assert.True(t, "b", strings.Contains(msg, `"short_message":"b"`))
+	assert.True(t, "null byte", strings.HasSuffix(msg, "}\x00"))
+}
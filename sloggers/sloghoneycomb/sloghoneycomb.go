@@ -0,0 +1,187 @@
+// Package sloghoneycomb contains the slogger that sends
+// entries to Honeycomb as events.
+//
+// Format
+//
+//	{
+//	  "level": "INFO",
+//	  "msg": "hi",
+//	  "logger": "comp.subcomp",
+//	  "caller": "slog/examples_test.go:62",
+//	  "func": "cdr.dev/slog/sloggers/slogtest_test.TestExampleTest",
+//	  "trace.trace_id": "<traceid>",
+//	  "trace.parent_id": "<spanid>",
+//	  "meta.annotation_type": "span_event",
+//	  "name": "hi",
+//	  "my_field": "field value"
+//	}
+//
+// See https://docs.honeycomb.io/api/tag/Events#operation/createEvents
+package sloghoneycomb // import "cdr.dev/slog/sloggers/sloghoneycomb"
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opencensus.io/trace"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/entryjson"
+	"cdr.dev/slog/internal/httpretry"
+	"cdr.dev/slog/sloggers/slogbatch"
+)
+
+// Options represents the options for the sink returned by Sink.
+type Options struct {
+	// APIKey is the ingest key of the Honeycomb environment.
+	APIKey string
+
+	// Dataset is the name of the dataset of the events.
+	Dataset string
+
+	// APIHost defaults to https://api.honeycomb.io.
+	// Use https://api.eu1.honeycomb.io for the EU region.
+	APIHost string
+
+	// Client defaults to a client with a 30 second timeout.
+	Client *http.Client
+
+	// MaxRetries is the maximum number of retries of a batch
+	// that fails with a network error, 429 or 5xx.
+	//
+	// Defaults to 3.
+	MaxRetries int
+
+	// Backoff is the delay before the first retry. It doubles
+	// for every following retry.
+	//
+	// Defaults to 500ms.
+	Backoff time.Duration
+
+	// Batch configures the batching of entries.
+	// See slogbatch.Options.
+	Batch *slogbatch.Options
+}
+
+// Sink creates a slog.Sink that sends batches of entries to Honeycomb.
+//
+// Nested fields are flattened into dotted names. Entries logged with
+// a span are sent as span events of the span so that they appear in
+// the trace waterfall.
+//
+// The returned sink implements io.Closer. Close sends the
+// remaining entries.
+func Sink(opts *Options) (slog.Sink, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	if opts.APIKey == "" {
+		return nil, xerrors.New("API key is required")
+	}
+	if opts.Dataset == "" {
+		return nil, xerrors.New("dataset is required")
+	}
+	host := opts.APIHost
+	if host == "" {
+		host = "https://api.honeycomb.io"
+	}
+
+	s := &honeycombSink{
+		url: strings.TrimSuffix(host, "/") + "/1/batch/" + url.PathEscape(opts.Dataset),
+		header: http.Header{
+			"Content-Type":     []string{"application/json"},
+			"X-Honeycomb-Team": []string{opts.APIKey},
+		},
+		opts: httpretry.Options{
+			Client:     opts.Client,
+			MaxRetries: opts.MaxRetries,
+			Backoff:    opts.Backoff,
+		},
+	}
+	return slogbatch.Sink(s, opts.Batch), nil
+}
+
+type honeycombSink struct {
+	url    string
+	header http.Header
+	opts   httpretry.Options
+}
+
+type batchEvent struct {
+	Time string   `json:"time"`
+	Data slog.Map `json:"data"`
+}
+
+func (s *honeycombSink) LogEntry(ctx context.Context, ent slog.SinkEntry) {
+	s.LogEntries(ctx, []slog.SinkEntry{ent})
+}
+
+func (s *honeycombSink) LogEntries(ctx context.Context, ents []slog.SinkEntry) {
+	events := make([]batchEvent, len(ents))
+	for i, ent := range ents {
+		events[i] = batchEvent{
+			Time: ent.Time.UTC().Format(time.RFC3339Nano),
+			Data: data(ent),
+		}
+	}
+	body, err := json.Marshal(events)
+	if err != nil {
+		slog.ReportError(ctx, xerrors.Errorf("sloghoneycomb: failed to marshal events: %w", err), ents...)
+		return
+	}
+
+	b, err := httpretry.Do(ctx, s.opts, http.MethodPost, s.url, s.header, body)
+	if err != nil {
+		slog.ReportError(ctx, xerrors.Errorf("sloghoneycomb: failed to send %v entries: %w", len(ents), err), ents...)
+		return
+	}
+
+	var resp []struct {
+		Status int    `json:"status"`
+		Error  string `json:"error"`
+	}
+	err = json.Unmarshal(b, &resp)
+	if err != nil {
+		slog.ReportError(ctx, xerrors.Errorf("sloghoneycomb: failed to unmarshal batch response: %w", err), ents...)
+		return
+	}
+	for i, r := range resp {
+		if i < len(ents) && r.Status/100 != 2 {
+			slog.ReportError(ctx, xerrors.Errorf("sloghoneycomb: failed to send entry: %v: %v", r.Status, r.Error), ents[i])
+		}
+	}
+}
+
+func data(ent slog.SinkEntry) slog.Map {
+	m := slog.M(
+		slog.F("level", ent.Level),
+		slog.F("msg", ent.Message),
+	)
+	if len(ent.LoggerNames) > 0 {
+		m = append(m, slog.F("logger", strings.Join(ent.LoggerNames, ".")))
+	}
+	m = append(m,
+		slog.F("caller", ent.File+":"+strconv.Itoa(ent.Line)),
+		slog.F("func", ent.Func),
+	)
+	if ent.SpanContext != (trace.SpanContext{}) {
+		m = append(m,
+			slog.F("trace.trace_id", ent.SpanContext.TraceID.String()),
+			slog.F("trace.parent_id", ent.SpanContext.SpanID.String()),
+			slog.F("meta.annotation_type", "span_event"),
+			slog.F("name", ent.Message),
+		)
+	}
+	for _, f := range entryjson.Flatten(ent.Fields, ".") {
+		m = append(m, slog.F(f.Key, f.Value))
+	}
+	return m
+}
+
+func (s *honeycombSink) Sync() {}
@@ -0,0 +1,75 @@
+package sloghoneycomb_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.opencensus.io/trace"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+	"cdr.dev/slog/sloggers/sloghoneycomb"
+)
+
+var bg = context.Background()
+
+var kt = time.Date(2000, time.February, 5, 4, 4, 4, 123456789, time.UTC)
+
+func TestSink(t *testing.T) {
+	t.Parallel()
+
+	bodies := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "path", "/1/batch/my%20logs", r.URL.EscapedPath())
+		assert.Equal(t, "api key", "key", r.Header.Get("X-Honeycomb-Team"))
+		b, err := ioutil.ReadAll(r.Body)
+		assert.Success(t, "read body", err)
+		bodies <- string(b)
+		w.Write([]byte(`[{"status":202},{"status":202}]`))
+	}))
+	defer srv.Close()
+
+	s, err := sloghoneycomb.Sink(&sloghoneycomb.Options{
+		APIKey:  "key",
+		Dataset: "my logs",
+		APIHost: srv.URL,
+	})
+	assert.Success(t, "sink", err)
+
+	s.LogEntry(bg, slog.SinkEntry{
+		Time:        kt,
+		Level:       slog.LevelInfo,
+		LoggerNames: []string{"comp"},
+		Message:     "hi",
+		File:        "main.go",
+		Line:        62,
+		Func:        "main.main",
+		Fields: slog.M(
+			slog.F("req", slog.M(slog.F("id", 1))),
+		),
+	})
+	s.LogEntry(bg, slog.SinkEntry{
+		Time:        kt,
+		Message:     "in span",
+		SpanContext: trace.SpanContext{TraceID: trace.TraceID{1}, SpanID: trace.SpanID{2}},
+	})
+	s.Sync()
+
+	assert.Equal(t, "body", `[`+
+		`{"time":"2000-02-05T04:04:04.123456789Z","data":{"level":"INFO","msg":"hi","logger":"comp",`+
+		`"caller":"main.go:62","func":"main.main","req.id":1}},`+
+		`{"time":"2000-02-05T04:04:04.123456789Z","data":{"level":"DEBUG","msg":"in span","caller":":0","func":"",`+
+		`"trace.trace_id":"01000000000000000000000000000000","trace.parent_id":"0200000000000000",`+
+		`"meta.annotation_type":"span_event","name":"in span"}}]`, <-bodies)
+}
+
+func TestSink_options(t *testing.T) {
+	t.Parallel()
+
+	_, err := sloghoneycomb.Sink(&sloghoneycomb.Options{APIKey: "key"})
+	assert.Error(t, "sink", err)
+}
@@ -0,0 +1,51 @@
+package sloghuman
+
+import (
+	"cdr.dev/slog/internal/entryhuman"
+)
+
+// Part is a part of a formatted entry.
+type Part int
+
+// The parts of a formatted entry.
+const (
+	// PartTime is the time of the entry.
+	PartTime = Part(entryhuman.PartTime)
+	// PartLevel is the level, e.g. [INFO].
+	PartLevel = Part(entryhuman.PartLevel)
+	// PartName is the dotted logger name, e.g. (comp.db).
+	// It is omitted for entries without a name.
+	PartName = Part(entryhuman.PartName)
+	// PartCaller is the file and line, e.g. <main.go:21>.
+	PartCaller = Part(entryhuman.PartCaller)
+	// PartFunc is the package qualified function, e.g. main.run.
+	PartFunc = Part(entryhuman.PartFunc)
+	// PartMessage is the message.
+	PartMessage = Part(entryhuman.PartMessage)
+	// PartFields is the fields as JSON.
+	// It is omitted for entries without fields.
+	PartFields = Part(entryhuman.PartFields)
+)
+
+// WithLayout sets which parts every line has and in what order.
+// Parts that are not passed are not formatted.
+//
+// The default layout is:
+//
+//	PartTime, PartLevel, PartName, PartCaller, PartMessage, PartFields
+//
+// For example, to move the caller to the end and show the function:
+//
+//	sloghuman.WithLayout(
+//		sloghuman.PartTime, sloghuman.PartLevel, sloghuman.PartName,
+//		sloghuman.PartMessage, sloghuman.PartFields,
+//		sloghuman.PartFunc, sloghuman.PartCaller,
+//	)
+func WithLayout(parts ...Part) Option {
+	return func(o *options) {
+		o.fmt.Layout = make([]entryhuman.Part, len(parts))
+		for i, p := range parts {
+			o.fmt.Layout[i] = entryhuman.Part(p)
+		}
+	}
+}
@@ -1,5 +1,8 @@
 // Package sloghuman contains the slogger
 // that writes logs in a human readable format.
+//
+// The format is customized with Options passed to Sink like
+// WithTheme, WithLayout, WithTimeFormat, WithColor and WithWidth.
 package sloghuman // import "cdr.dev/slog/sloggers/sloghuman"
 
 import (
@@ -8,6 +11,7 @@ import (
 	"strings"
 
 	"cdr.dev/slog"
+	"cdr.dev/slog/internal/callerpath"
 	"cdr.dev/slog/internal/entryhuman"
 	"cdr.dev/slog/internal/syncwriter"
 )
@@ -17,20 +21,192 @@ import (
 //
 // If the writer implements Sync() error then
 // it will be called when syncing.
-func Sink(w io.Writer) slog.Sink {
-	return &humanSink{
-		w:  syncwriter.New(w),
+//
+// On Windows consoles, colors are enabled with virtual terminal
+// processing or translated into console API calls on older
+// versions.
+func Sink(w io.Writer, opts ...Option) slog.Sink {
+	s := &humanSink{
+		w:  syncwriter.New(entryhuman.ConsoleWriter(w)),
 		w2: w,
 	}
+	for _, opt := range opts {
+		opt(&s.opts)
+	}
+	if s.opts.delta {
+		s.delta = &deltaClock{}
+	}
+	return s
+}
+
+// Option configures the Sink.
+type Option func(o *options)
+
+type options struct {
+	fmt entryhuman.Options
+
+	fitWidth bool
+	width    int
+	overflow Overflow
+
+	delta bool
+}
+
+// WithPrettyFields formats the fields as an indented
+// block under the message instead of inline JSON so that
+// deeply nested fields stay readable. Multiline strings
+// and errors are printed verbatim.
+//
+//	2000-02-05 04:04:04.000 [INFO]	<main.go:21>	request done
+//	  req:
+//	    method: GET
+//	    path: /
+//	  err: |
+//	    failed to read:
+//	        main.read
+//	            /src/main.go:42
+func WithPrettyFields() Option {
+	return func(o *options) {
+		o.fmt.Pretty = true
+	}
+}
+
+// WithAlignedColumns pads the level to the width of [CRITICAL]
+// and the logger name and caller to the given widths so that
+// consecutive lines line up when tailing output. Longer names
+// and callers are not truncated. A width of 0 disables padding
+// for that column.
+func WithAlignedColumns(nameWidth, callerWidth int) Option {
+	return func(o *options) {
+		o.fmt.LevelWidth = len("[CRITICAL]")
+		o.fmt.NameWidth = nameWidth
+		o.fmt.CallerWidth = callerWidth
+	}
+}
+
+// ColorMode controls whether output is colored.
+type ColorMode int
+
+// The color modes.
+const (
+	// ColorAuto colors output written to a TTY. The NO_COLOR
+	// environment variable disables color and FORCE_COLOR
+	// enables it regardless of the writer.
+	ColorAuto = ColorMode(entryhuman.ColorAuto)
+	// ColorAlways always colors output.
+	ColorAlways = ColorMode(entryhuman.ColorAlways)
+	// ColorNever never colors output.
+	ColorNever = ColorMode(entryhuman.ColorNever)
+)
+
+// WithColor sets whether output is colored.
+// The default is ColorAuto.
+func WithColor(mode ColorMode) Option {
+	return func(o *options) {
+		o.fmt.Color = entryhuman.ColorMode(mode)
+	}
+}
+
+// CallerFormat is how the file of the caller is formatted.
+type CallerFormat int
+
+// The caller formats.
+const (
+	// CallerFull is the full path of the file.
+	CallerFull = CallerFormat(callerpath.Full)
+	// CallerBase is only the file name, e.g. main.go.
+	CallerBase = CallerFormat(callerpath.Base)
+	// CallerShort is the last two path components, e.g. cmd/main.go.
+	CallerShort = CallerFormat(callerpath.Short)
+	// CallerModule is the path relative to the root of the module
+	// that contains the file, e.g. internal/cmd/main.go. Files
+	// outside of a module are formatted like CallerShort.
+	CallerModule = CallerFormat(callerpath.Module)
+)
+
+// WithCallerFormat sets how the file of the caller is formatted.
+// The default is CallerBase.
+func WithCallerFormat(f CallerFormat) Option {
+	return func(o *options) {
+		o.fmt.Caller = func(file string) string {
+			return callerpath.Trim(file, callerpath.Format(f))
+		}
+	}
+}
+
+// WithLevelGlyphs replaces the level names with short glyphs:
+// • for debug, ✓ for info, ⚠ for warn and ✗ for error and above.
+func WithLevelGlyphs() Option {
+	return func(o *options) {
+		o.fmt.Level = levelGlyph
+	}
+}
+
+func levelGlyph(level slog.Level) string {
+	switch {
+	case level < slog.LevelInfo:
+		return "•"
+	case level < slog.LevelWarn:
+		return "✓"
+	case level < slog.LevelError:
+		return "⚠"
+	default:
+		return "✗"
+	}
+}
+
+// Minimal is a preset for CLI tools that use slog for user facing
+// progress output. Every line only has a level glyph, the message
+// and the fields:
+//
+//	✓	downloaded	{"file": "go.tar.gz"}
+//	✗	failed to extract	{"err": "unexpected EOF"}
+//
+// Later options override it.
+func Minimal() Option {
+	return func(o *options) {
+		WithLevelGlyphs()(o)
+		WithLayout(PartLevel, PartMessage, PartFields)(o)
+	}
+}
+
+// WithRichErrors formats errors with frames, like those created
+// with xerrors.Errorf, as an indented cause chain with the function
+// and location of every frame instead of a flattened string.
+//
+//	"err": failed to run
+//	         main.run
+//	           main.go:42
+//	       caused by: unexpected EOF
+func WithRichErrors() Option {
+	return func(o *options) {
+		o.fmt.RichErrors = true
+	}
+}
+
+// WithDimDebug renders debug entries in a faint style when
+// colored so that interleaved debug output does not visually
+// compete with the rest.
+func WithDimDebug() Option {
+	return func(o *options) {
+		o.fmt.DimDebug = true
+	}
 }
 
 type humanSink struct {
-	w  *syncwriter.Writer
-	w2 io.Writer
+	w     *syncwriter.Writer
+	w2    io.Writer
+	opts  options
+	delta *deltaClock
 }
 
 func (s humanSink) LogEntry(ctx context.Context, ent slog.SinkEntry) {
-	str := entryhuman.Fmt(s.w2, ent)
+	fmtOpts := s.opts.fmt
+	if s.delta != nil {
+		fmtOpts.Time = appendDelta(fmtOpts.Time, s.delta.since(ent.Time))
+	}
+
+	str := entryhuman.FmtWith(s.w2, ent, fmtOpts)
 	lines := strings.Split(str, "\n")
 
 	// We need to add 4 spaces before every field line for readability.
@@ -46,7 +222,14 @@ func (s humanSink) LogEntry(ctx context.Context, ent slog.SinkEntry) {
 
 	str = strings.Join(lines, "\n")
 
-	s.w.Write("sloghuman", []byte(str+"\n"))
+	if width := s.lineWidth(); width > 0 {
+		str = fitLines(str, width, s.opts.overflow)
+	}
+
+	err := s.w.Write(ctx, "sloghuman", []byte(str+"\n"))
+	if err != nil {
+		slog.ReportError(ctx, err, ent)
+	}
 }
 
 func (s humanSink) Sync() {
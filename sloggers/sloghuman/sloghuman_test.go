@@ -3,7 +3,13 @@ package sloghuman_test
 import (
 	"bytes"
 	"context"
+	"io"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/fatih/color"
+	"golang.org/x/xerrors"
 
 	"cdr.dev/slog"
 	"cdr.dev/slog/internal/assert"
@@ -24,5 +30,244 @@ func TestMake(t *testing.T) {
 	et, rest, err := entryhuman.StripTimestamp(b.String())
 	assert.Success(t, "strip timestamp", err)
 	assert.False(t, "timestamp", et.IsZero())
-	assert.Equal(t, "entry", " [INFO]\t<sloghuman_test.go:21>\t...\t{\"wowow\": \"me\\nyou\"}\n  \"msg\": line1\n\n         line2\n", rest)
+	assert.Equal(t, "entry", " [INFO]\t<sloghuman_test.go:27>\t...\t{\"wowow\": \"me\\nyou\"}\n  \"msg\": line1\n\n         line2\n", rest)
+}
+
+func TestLayout(t *testing.T) {
+	t.Parallel()
+
+	b := &bytes.Buffer{}
+	l := slog.Make(sloghuman.Sink(b, sloghuman.WithLayout(
+		sloghuman.PartLevel,
+		sloghuman.PartMessage,
+		sloghuman.PartFields,
+		sloghuman.PartFunc,
+	))).Named("comp")
+	l.Info(bg, "hi", slog.F("a", 1))
+	l.Info(bg, "line1\nline2")
+	l.Sync()
+
+	assert.Equal(t, "entries", "[INFO]\thi\t{\"a\": 1}\tsloghuman_test.TestLayout\n"+
+		"[INFO]\t...\tsloghuman_test.TestLayout\n  \"msg\": line1\n         line2\n", b.String())
+}
+
+func TestTimeFormat(t *testing.T) {
+	t.Parallel()
+
+	b := &bytes.Buffer{}
+	l := slog.Make(sloghuman.Sink(b, sloghuman.WithTimeFormat(sloghuman.TimeShort)))
+	l.Info(bg, "hi")
+	l.Sync()
+
+	_, err := time.Parse(sloghuman.TimeShort, b.String()[:len(sloghuman.TimeShort)])
+	assert.Success(t, "parse time", err)
+
+	b.Reset()
+	l = slog.Make(sloghuman.Sink(b, sloghuman.WithTimeFormat(sloghuman.TimeNone)))
+	l.Info(bg, "hi")
+	l.Sync()
+
+	assert.Equal(t, "entry", "[INFO]\t<sloghuman_test.go:67>\thi\n", b.String())
+}
+
+func TestPrettyFields(t *testing.T) {
+	t.Parallel()
+
+	b := &bytes.Buffer{}
+	l := slog.Make(sloghuman.Sink(b,
+		sloghuman.WithLayout(sloghuman.PartLevel, sloghuman.PartMessage, sloghuman.PartFields),
+		sloghuman.WithPrettyFields(),
+	))
+	l.Info(bg, "hi",
+		slog.F("req", slog.M(
+			slog.F("method", "GET"),
+			slog.F("tags", []string{"a", "b c"}),
+			slog.F("empty", []string{}),
+		)),
+		slog.F("stack", "line1\n\nline2"),
+		slog.F("ok", true),
+	)
+	l.Sync()
+
+	assert.Equal(t, "entry", `[INFO]	hi
+  req:
+    method: GET
+    tags:
+      - a
+      - b c
+    empty: []
+  stack: |
+    line1
+
+    line2
+  ok: true
+`, b.String())
+}
+
+func TestAlignedColumns(t *testing.T) {
+	t.Parallel()
+
+	b := &bytes.Buffer{}
+	l := slog.Make(sloghuman.Sink(b,
+		sloghuman.WithLayout(sloghuman.PartLevel, sloghuman.PartName, sloghuman.PartMessage),
+		sloghuman.WithAlignedColumns(8, 0),
+	))
+	l.Info(bg, "a")
+	l.Named("db").Error(bg, "b")
+	l.Named("compute").Warn(bg, "c")
+
+	assert.Equal(t, "entries", "[INFO]    \t        \ta\n"+
+		"[ERROR]   \t(db)    \tb\n"+
+		"[WARN]    \t(compute)\tc\n", b.String())
+}
+
+func TestColor(t *testing.T) {
+	t.Parallel()
+
+	b := &bytes.Buffer{}
+	l := slog.Make(sloghuman.Sink(b,
+		sloghuman.WithLayout(sloghuman.PartLevel, sloghuman.PartMessage),
+		sloghuman.WithColor(sloghuman.ColorAlways),
+		sloghuman.WithTheme(sloghuman.SolarizedTheme),
+	))
+	l.Warn(bg, "hi")
+
+	assert.Equal(t, "entry", "\x1b[38;5;136m[WARN]\x1b[0m\thi\n", b.String())
+}
+
+func TestWidth(t *testing.T) {
+	t.Parallel()
+
+	b := &bytes.Buffer{}
+	layout := sloghuman.WithLayout(sloghuman.PartLevel, sloghuman.PartMessage)
+	l := slog.Make(sloghuman.Sink(b, layout, sloghuman.WithWidth(16, sloghuman.OverflowTruncate)))
+	l.Info(bg, "hello world")
+	l.Info(bg, "hi")
+	assert.Equal(t, "entries", "[INFO]\thello w…\n[INFO]\thi\n", b.String())
+
+	b.Reset()
+	l = slog.Make(sloghuman.Sink(b, layout, sloghuman.WithWidth(16, sloghuman.OverflowWrap)))
+	l.Info(bg, "hello world, how are you")
+	assert.Equal(t, "entries", "[INFO]\thello wo\n  rld, how are y\n  ou\n", b.String())
+
+	b.Reset()
+	l = slog.Make(sloghuman.Sink(b, layout,
+		sloghuman.WithColor(sloghuman.ColorAlways),
+		sloghuman.WithWidth(16, sloghuman.OverflowTruncate),
+	))
+	l.Info(bg, "hello world")
+	assert.Equal(t, "entries", "\x1b[34m[INFO]\x1b[0m\thello w\x1b[0m…\n", b.String())
+}
+
+func TestElapsedTime(t *testing.T) {
+	t.Parallel()
+
+	b := &bytes.Buffer{}
+	l := slog.Make(sloghuman.Sink(b,
+		sloghuman.WithLayout(sloghuman.PartTime, sloghuman.PartMessage),
+		sloghuman.WithElapsedTime(),
+	))
+	l.Info(bg, "hi")
+
+	assert.True(t, "elapsed", strings.HasPrefix(b.String(), "+0."))
+	assert.True(t, "elapsed", strings.HasSuffix(b.String(), "s hi\n"))
+}
+
+func TestDelta(t *testing.T) {
+	t.Parallel()
+
+	b := &bytes.Buffer{}
+	s := sloghuman.Sink(b,
+		sloghuman.WithLayout(sloghuman.PartTime, sloghuman.PartMessage),
+		sloghuman.WithTimeFormat(sloghuman.TimeShort),
+		sloghuman.WithDelta(),
+	)
+	kt := time.Date(2000, time.February, 5, 4, 4, 4, 0, time.UTC)
+	s.LogEntry(bg, slog.SinkEntry{Time: kt, Message: "a"})
+	s.LogEntry(bg, slog.SinkEntry{Time: kt.Add(35 * time.Millisecond), Message: "b"})
+	s.LogEntry(bg, slog.SinkEntry{Time: kt.Add(2 * time.Second), Message: "c"})
+
+	assert.Equal(t, "entries", "04:04:04.000 (+0s) a\n"+
+		"04:04:04.035 (+35ms) b\n"+
+		"04:04:06.000 (+1.965s) c\n", b.String())
+}
+
+func TestCallerFormat(t *testing.T) {
+	t.Parallel()
+
+	b := &bytes.Buffer{}
+	s := sloghuman.Sink(b,
+		sloghuman.WithLayout(sloghuman.PartCaller),
+		sloghuman.WithCallerFormat(sloghuman.CallerShort),
+	)
+	s.LogEntry(bg, slog.SinkEntry{File: "/src/cmd/app/main.go", Line: 42})
+	s.LogEntry(bg, slog.SinkEntry{File: "main.go", Line: 42})
+
+	assert.Equal(t, "entries", "<app/main.go:42>\n<main.go:42>\n", b.String())
+}
+
+func TestMinimal(t *testing.T) {
+	t.Parallel()
+
+	b := &bytes.Buffer{}
+	l := slog.Make(sloghuman.Sink(b, sloghuman.Minimal())).Named("cli")
+	l.Info(bg, "downloaded", slog.F("file", "go.tar.gz"))
+	l.Warn(bg, "slow")
+	l.Error(bg, "failed")
+
+	assert.Equal(t, "entries", "✓\tdownloaded\t{\"file\": \"go.tar.gz\"}\n⚠\tslow\n✗\tfailed\n", b.String())
+}
+
+func TestRichErrors(t *testing.T) {
+	t.Parallel()
+
+	b := &bytes.Buffer{}
+	l := slog.Make(sloghuman.Sink(b,
+		sloghuman.WithLayout(sloghuman.PartMessage, sloghuman.PartFields),
+		sloghuman.WithRichErrors(),
+	))
+	err := xerrors.Errorf("failed to run: %w", io.EOF)
+	l.Info(bg, "hi", slog.Error(err))
+
+	assert.Equal(t, "entry", `hi ...
+  "error": failed to run
+             cdr.dev/slog/sloggers/sloghuman_test.TestRichErrors
+               sloghuman_test.go:229
+           caused by: EOF
+`, b.String())
+}
+
+func TestDimDebug(t *testing.T) {
+	t.Parallel()
+
+	b := &bytes.Buffer{}
+	l := slog.Make(sloghuman.Sink(b,
+		sloghuman.WithLayout(sloghuman.PartLevel, sloghuman.PartMessage),
+		sloghuman.WithColor(sloghuman.ColorAlways),
+		sloghuman.WithTheme(sloghuman.Theme{Info: []color.Attribute{color.FgBlue}}),
+		sloghuman.WithDimDebug(),
+	)).Leveled(slog.LevelDebug)
+	l.Debug(bg, "a")
+	l.Info(bg, "b")
+
+	assert.Equal(t, "entries", "\x1b[2m[DEBUG]\ta\x1b[0m\n\x1b[34m[INFO]\x1b[0m\tb\n", b.String())
+}
+
+// TestDarkTheme is not parallel as it modifies DarkTheme.
+func TestDarkTheme(t *testing.T) {
+	info := sloghuman.DarkTheme.Info[0]
+	sloghuman.DarkTheme.Info[0] = color.FgRed
+	defer func() {
+		sloghuman.DarkTheme.Info[0] = info
+	}()
+
+	b := &bytes.Buffer{}
+	l := slog.Make(sloghuman.Sink(b,
+		sloghuman.WithLayout(sloghuman.PartLevel, sloghuman.PartMessage),
+		sloghuman.WithColor(sloghuman.ColorAlways),
+	))
+	l.Info(bg, "hi")
+
+	// The default theme is not changed with DarkTheme.
+	assert.Equal(t, "entry", "\x1b[34m[INFO]\x1b[0m\thi\n", b.String())
 }
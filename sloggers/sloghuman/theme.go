@@ -0,0 +1,115 @@
+package sloghuman
+
+import (
+	"github.com/fatih/color"
+
+	"cdr.dev/slog/internal/entryhuman"
+)
+
+// Theme holds the colors used for every part of an entry
+// when the writer is colored. A nil color leaves the part
+// uncolored.
+type Theme struct {
+	Time []color.Attribute
+
+	Debug []color.Attribute
+	Info  []color.Attribute
+	Warn  []color.Attribute
+	Error []color.Attribute
+	// Critical is also used for slog.LevelFatal.
+	Critical []color.Attribute
+
+	Name   []color.Attribute
+	Caller []color.Attribute
+
+	// The colors of the JSON fields.
+	Key     []color.Attribute
+	String  []color.Attribute
+	Number  []color.Attribute
+	Keyword []color.Attribute
+}
+
+// DarkTheme is the default theme. It uses the basic ANSI
+// colors and suits terminals with a dark background.
+var DarkTheme = copyTheme(entryhuman.DefaultTheme)
+
+// copyTheme deep copies t so that changes to DarkTheme
+// do not change the default of the Sink.
+func copyTheme(t entryhuman.Theme) Theme {
+	c := func(attrs []color.Attribute) []color.Attribute {
+		if attrs == nil {
+			return nil
+		}
+		return append([]color.Attribute(nil), attrs...)
+	}
+	return Theme{
+		Time: c(t.Time),
+
+		Debug:    c(t.Debug),
+		Info:     c(t.Info),
+		Warn:     c(t.Warn),
+		Error:    c(t.Error),
+		Critical: c(t.Critical),
+
+		Name:   c(t.Name),
+		Caller: c(t.Caller),
+
+		Key:     c(t.Key),
+		String:  c(t.String),
+		Number:  c(t.Number),
+		Keyword: c(t.Keyword),
+	}
+}
+
+// LightTheme suits terminals with a light background.
+var LightTheme = Theme{
+	Time: []color.Attribute{color.FgHiBlack},
+
+	Debug:    []color.Attribute{color.FgHiBlack},
+	Info:     []color.Attribute{color.FgBlue},
+	Warn:     []color.Attribute{color.FgYellow, color.Bold},
+	Error:    []color.Attribute{color.FgRed},
+	Critical: []color.Attribute{color.FgRed, color.Bold},
+
+	Name:   []color.Attribute{color.FgMagenta},
+	Caller: []color.Attribute{color.FgCyan},
+
+	Key:     []color.Attribute{color.FgBlue},
+	String:  []color.Attribute{color.FgGreen},
+	Number:  []color.Attribute{color.FgMagenta},
+	Keyword: []color.Attribute{color.FgMagenta},
+}
+
+// SolarizedTheme uses the Solarized accent colors.
+// It requires a terminal with 256 color support.
+var SolarizedTheme = Theme{
+	Time: solarized(245),
+
+	Debug:    solarized(240),
+	Info:     solarized(33),
+	Warn:     solarized(136),
+	Error:    solarized(166),
+	Critical: solarized(160),
+
+	Name:   solarized(61),
+	Caller: solarized(37),
+
+	Key:     solarized(33),
+	String:  solarized(64),
+	Number:  solarized(125),
+	Keyword: solarized(125),
+}
+
+// solarized returns the attributes of the 256 color foreground n.
+func solarized(n color.Attribute) []color.Attribute {
+	return []color.Attribute{38, 5, n}
+}
+
+// WithTheme sets the colors of the Sink.
+// The default is DarkTheme.
+func WithTheme(t Theme) Option {
+	return func(o *options) {
+		et := entryhuman.Theme(t)
+		o.fmt.Theme = &et
+	}
+}
@@ -0,0 +1,95 @@
+package sloghuman
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"cdr.dev/slog/internal/entryhuman"
+)
+
+// Time layouts for WithTimeFormat.
+const (
+	// TimeDefault is the default simplified RFC3339 layout.
+	TimeDefault = entryhuman.TimeFormat
+	// TimeShort is only the time of day, for local development.
+	TimeShort = "15:04:05.000"
+	// TimeNone omits the time.
+	TimeNone = ""
+)
+
+// WithTimeFormat sets the layout used to format the time
+// of every entry. See the time package for the syntax.
+// The default is TimeDefault.
+func WithTimeFormat(layout string) Option {
+	return func(o *options) {
+		o.fmt.Time = func(t time.Time) string {
+			return t.Format(layout)
+		}
+	}
+}
+
+// start approximates the start of the program.
+var start = time.Now()
+
+// WithElapsedTime formats the time of every entry as the time
+// since the program started, e.g. +1.203s, which is easier to
+// follow than the wall clock in startup sequences and tests.
+func WithElapsedTime() Option {
+	return func(o *options) {
+		o.fmt.Time = func(t time.Time) string {
+			return fmt.Sprintf("+%.3fs", t.Sub(start).Seconds())
+		}
+	}
+}
+
+// WithDelta appends the time since the previous entry
+// to the time of every entry, e.g. (+35ms), to make slow
+// steps easy to spot.
+func WithDelta() Option {
+	return func(o *options) {
+		o.delta = true
+	}
+}
+
+// deltaClock tracks the time of the previous entry.
+type deltaClock struct {
+	mu   sync.Mutex
+	last time.Time
+}
+
+func (c *deltaClock) since(t time.Time) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var d time.Duration
+	if !c.last.IsZero() {
+		d = t.Sub(c.last)
+	}
+	c.last = t
+	return d
+}
+
+// appendDelta wraps fmtTime to append d.
+func appendDelta(fmtTime func(t time.Time) string, d time.Duration) func(t time.Time) string {
+	if d < time.Millisecond && d > -time.Millisecond {
+		d = d.Round(time.Microsecond)
+	} else {
+		d = d.Round(time.Millisecond)
+	}
+	delta := fmt.Sprintf("(+%v)", d)
+	if d < 0 {
+		delta = fmt.Sprintf("(%v)", d)
+	}
+
+	return func(t time.Time) string {
+		ts := t.Format(entryhuman.TimeFormat)
+		if fmtTime != nil {
+			ts = fmtTime(t)
+		}
+		if ts == "" {
+			return delta
+		}
+		return ts + " " + delta
+	}
+}
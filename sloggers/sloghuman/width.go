@@ -0,0 +1,181 @@
+package sloghuman
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// Overflow controls what happens to lines that are
+// wider than the width set with WithWidth.
+type Overflow int
+
+const (
+	// OverflowTruncate cuts lines off with an ellipsis.
+	OverflowTruncate Overflow = iota
+	// OverflowWrap breaks lines into indented continuation lines.
+	OverflowWrap
+)
+
+// WithWidth limits every line to width columns.
+// If width is 0, the width of the terminal is used
+// and lines are not limited if the writer is not a
+// terminal.
+//
+// Truncation hides content so it is best to only enable it when
+// a verbose flag is not set:
+//
+//	if !*verbose {
+//		opts = append(opts, sloghuman.WithWidth(0, sloghuman.OverflowTruncate))
+//	}
+func WithWidth(width int, overflow Overflow) Option {
+	return func(o *options) {
+		o.fitWidth = true
+		o.width = width
+		o.overflow = overflow
+	}
+}
+
+// lineWidth returns the width lines should fit in or 0
+// if they are not limited.
+func (s humanSink) lineWidth() int {
+	if !s.opts.fitWidth {
+		return 0
+	}
+	if s.opts.width > 0 {
+		return s.opts.width
+	}
+	f, ok := s.w2.(interface {
+		Fd() uintptr
+	})
+	if !ok {
+		return 0
+	}
+	width, _, err := terminal.GetSize(int(f.Fd()))
+	if err != nil {
+		return 0
+	}
+	return width
+}
+
+// fitLines fits every line in str into width columns.
+func fitLines(str string, width int, overflow Overflow) string {
+	lines := strings.Split(str, "\n")
+	fitted := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if overflow == OverflowWrap {
+			fitted = append(fitted, wrapLine(line, width)...)
+		} else {
+			fitted = append(fitted, truncateLine(line, width))
+		}
+	}
+	return strings.Join(fitted, "\n")
+}
+
+// lineScanner walks a line rune by rune, skipping ANSI
+// escapes and tracking the visible column.
+type lineScanner struct {
+	line string
+	i    int
+	col  int
+}
+
+// next returns the next piece of the line, the column after it and
+// whether it is visible. Invisible pieces are ANSI escapes.
+func (ls *lineScanner) next() (piece string, col int, visible bool) {
+	start := ls.i
+	if ls.line[ls.i] == '\x1b' {
+		ls.i++
+		if ls.i < len(ls.line) && ls.line[ls.i] == '[' {
+			ls.i++
+		}
+		for ls.i < len(ls.line) {
+			c := ls.line[ls.i]
+			ls.i++
+			if c >= '@' && c <= '~' {
+				break
+			}
+		}
+		return ls.line[start:ls.i], ls.col, false
+	}
+
+	r, size := utf8.DecodeRuneInString(ls.line[ls.i:])
+	ls.i += size
+	col = ls.col + 1
+	if r == '\t' {
+		col = (ls.col/8 + 1) * 8
+	}
+	return ls.line[start:ls.i], col, true
+}
+
+func (ls *lineScanner) done() bool {
+	return ls.i >= len(ls.line)
+}
+
+func visibleWidth(line string) int {
+	ls := &lineScanner{line: line}
+	for !ls.done() {
+		_, col, visible := ls.next()
+		if visible {
+			ls.col = col
+		}
+	}
+	return ls.col
+}
+
+func truncateLine(line string, width int) string {
+	if visibleWidth(line) <= width {
+		return line
+	}
+
+	var b strings.Builder
+	var escaped bool
+	ls := &lineScanner{line: line}
+	for !ls.done() {
+		piece, col, visible := ls.next()
+		if !visible {
+			escaped = true
+			b.WriteString(piece)
+			continue
+		}
+		if col > width-1 {
+			break
+		}
+		b.WriteString(piece)
+		ls.col = col
+	}
+	if escaped {
+		// Reset so the colors do not leak into the ellipsis.
+		b.WriteString("\x1b[0m")
+	}
+	b.WriteString("…")
+	return b.String()
+}
+
+// wrapLine breaks line into lines of width. Continuation
+// lines are indented by two spaces like the field lines.
+func wrapLine(line string, width int) []string {
+	var lines []string
+	var b strings.Builder
+	ls := &lineScanner{line: line}
+	for !ls.done() {
+		piece, col, visible := ls.next()
+		if visible && col > width && ls.col > 0 {
+			lines = append(lines, b.String())
+			b.Reset()
+			b.WriteString("  ")
+			ls.col = 2
+			if piece == "\t" {
+				col = 8
+			} else {
+				col = 3
+			}
+		}
+		b.WriteString(piece)
+		if visible {
+			ls.col = col
+		}
+	}
+	return append(lines, b.String())
+}
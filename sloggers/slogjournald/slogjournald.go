@@ -0,0 +1,160 @@
+// Package slogjournald contains the slogger that writes structured
+// entries to the systemd journal with its native protocol.
+//
+// See https://systemd.io/JOURNAL_NATIVE_PROTOCOL/
+package slogjournald // import "cdr.dev/slog/sloggers/slogjournald"
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"go.opencensus.io/trace"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/entryjson"
+	"cdr.dev/slog/internal/severity"
+)
+
+// DefaultSocket is the path of journald's native protocol socket.
+const DefaultSocket = "/run/systemd/journal/socket"
+
+// Options represents the options for the sink returned by Sink.
+type Options struct {
+	// Socket defaults to DefaultSocket.
+	Socket string
+
+	// Identifier is the SYSLOG_IDENTIFIER of every entry.
+	//
+	// Defaults to the name of the executable.
+	Identifier string
+}
+
+// Sink creates a slog.Sink that writes to the journal.
+//
+// Every entry has the MESSAGE, PRIORITY, CODE_FILE, CODE_LINE,
+// CODE_FUNC and SYSLOG_IDENTIFIER fields. The logger names are
+// written to SLOG_LOGGER and the trace to TRACE_ID and SPAN_ID.
+// Nested fields are flattened and every field name is converted
+// into a valid journal field name, e.g. req.id becomes REQ_ID.
+//
+// The returned sink implements io.Closer.
+func Sink(opts *Options) (slog.Sink, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	s := &journaldSink{
+		identifier: opts.Identifier,
+	}
+	if s.identifier == "" {
+		s.identifier = filepath.Base(os.Args[0])
+	}
+
+	socket := opts.Socket
+	if socket == "" {
+		socket = DefaultSocket
+	}
+	var err error
+	s.conn, err = net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return nil, xerrors.Errorf("failed to connect to journald at %v: %w", socket, err)
+	}
+	return s, nil
+}
+
+type journaldSink struct {
+	identifier string
+	conn       *net.UnixConn
+}
+
+func (s *journaldSink) LogEntry(ctx context.Context, ent slog.SinkEntry) {
+	b := s.format(ent)
+
+	_, err := s.conn.Write(b)
+	if xerrors.Is(err, syscall.EMSGSIZE) || xerrors.Is(err, syscall.ENOBUFS) {
+		err = sendLarge(s.conn, b)
+	}
+	if err != nil {
+		slog.ReportError(ctx, xerrors.Errorf("slogjournald: failed to write entry: %w", err), ent)
+	}
+}
+
+func (s *journaldSink) Sync() {}
+
+func (s *journaldSink) Close() error {
+	return s.conn.Close()
+}
+
+func (s *journaldSink) format(ent slog.SinkEntry) []byte {
+	b := make([]byte, 0, 512)
+	b = appendField(b, "MESSAGE", ent.Message)
+	b = appendField(b, "PRIORITY", strconv.Itoa(severity.Syslog(ent.Level)))
+	b = appendField(b, "SYSLOG_IDENTIFIER", s.identifier)
+	if ent.File != "" {
+		b = appendField(b, "CODE_FILE", ent.File)
+		b = appendField(b, "CODE_LINE", strconv.Itoa(ent.Line))
+	}
+	if ent.Func != "" {
+		b = appendField(b, "CODE_FUNC", ent.Func)
+	}
+	if len(ent.LoggerNames) > 0 {
+		b = appendField(b, "SLOG_LOGGER", strings.Join(ent.LoggerNames, "."))
+	}
+	if ent.SpanContext != (trace.SpanContext{}) {
+		b = appendField(b, "TRACE_ID", ent.SpanContext.TraceID.String())
+		b = appendField(b, "SPAN_ID", ent.SpanContext.SpanID.String())
+	}
+	for _, f := range entryjson.Flatten(ent.Fields, ".") {
+		b = appendField(b, fieldName(f.Key), entryjson.String(f.Value))
+	}
+	return b
+}
+
+// appendField appends a field in the native protocol. Values
+// with newlines are written with an explicit length.
+func appendField(b []byte, name, val string) []byte {
+	b = append(b, name...)
+	if !strings.Contains(val, "\n") {
+		b = append(b, '=')
+		b = append(b, val...)
+		return append(b, '\n')
+	}
+
+	b = append(b, '\n')
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(val)))
+	b = append(b, size[:]...)
+	b = append(b, val...)
+	return append(b, '\n')
+}
+
+// fieldName converts name into a valid journal field name. It may
+// only contain uppercase letters, digits and underscores, must start
+// with a letter and be at most 64 characters long.
+func fieldName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+	if name == "" || name[0] < 'A' || name[0] > 'Z' {
+		// Fields starting with an underscore are reserved
+		// for the journal itself.
+		name = "F" + name
+	}
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
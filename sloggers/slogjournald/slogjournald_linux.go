@@ -0,0 +1,39 @@
+package slogjournald
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"syscall"
+)
+
+// sendLarge sends an entry that does not fit in a datagram by writing
+// it to a file in /dev/shm and passing its descriptor to journald.
+func sendLarge(conn *net.UnixConn, b []byte) error {
+	f, err := ioutil.TempFile("/dev/shm", "slogjournald.")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	os.Remove(f.Name())
+
+	_, err = f.Write(b)
+	if err != nil {
+		return err
+	}
+
+	// WriteMsgUnix refuses connected sockets so sendmsg is called directly.
+	rc, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	rights := syscall.UnixRights(int(f.Fd()))
+	werr := rc.Write(func(fd uintptr) bool {
+		err = syscall.Sendmsg(int(fd), nil, rights, nil, 0)
+		return err != syscall.EAGAIN
+	})
+	if werr != nil {
+		return werr
+	}
+	return err
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+// +build !linux
+
+package slogjournald
+
+import (
+	"net"
+
+	"golang.org/x/xerrors"
+)
+
+// sendLarge is only supported on Linux.
+func sendLarge(conn *net.UnixConn, b []byte) error {
+	return xerrors.New("entry is too large for a datagram")
+}
@@ -0,0 +1,108 @@
+//go:build linux
+// +build linux
+
+package slogjournald_test
+
+import (
+	"context"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+	"cdr.dev/slog/sloggers/slogjournald"
+)
+
+var bg = context.Background()
+
+func listen(t *testing.T) (*net.UnixConn, string, func()) {
+	dir, err := ioutil.TempDir("", "slogjournald")
+	assert.Success(t, "temp dir", err)
+	socket := filepath.Join(dir, "socket")
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	assert.Success(t, "listen", err)
+	conn.SetReadDeadline(time.Now().Add(time.Minute))
+	return conn, socket, func() {
+		conn.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestSink(t *testing.T) {
+	t.Parallel()
+
+	conn, socket, cleanup := listen(t)
+	defer cleanup()
+
+	s, err := slogjournald.Sink(&slogjournald.Options{
+		Socket:     socket,
+		Identifier: "myapp",
+	})
+	assert.Success(t, "sink", err)
+	defer s.(interface{ Close() error }).Close()
+
+	s.LogEntry(bg, slog.SinkEntry{
+		Level:       slog.LevelError,
+		LoggerNames: []string{"comp"},
+		Message:     "line1\nline2",
+		File:        "main.go",
+		Line:        42,
+		Func:        "main.run",
+		Fields: slog.M(
+			slog.F("req", slog.M(slog.F("id", 1))),
+			slog.F("_secret", "x"),
+		),
+	})
+
+	b := make([]byte, 4096)
+	n, err := conn.Read(b)
+	assert.Success(t, "read", err)
+
+	msg := "line1\nline2"
+	size := make([]byte, 8)
+	binary.LittleEndian.PutUint64(size, uint64(len(msg)))
+	exp := "MESSAGE\n" + string(size) + msg + "\n" +
+		"PRIORITY=3\nSYSLOG_IDENTIFIER=myapp\nCODE_FILE=main.go\nCODE_LINE=42\nCODE_FUNC=main.run\n" +
+		"SLOG_LOGGER=comp\nREQ_ID=1\nF_SECRET=x\n"
+	assert.Equal(t, "entry", exp, string(b[:n]))
+}
+
+func TestSink_large(t *testing.T) {
+	t.Parallel()
+
+	conn, socket, cleanup := listen(t)
+	defer cleanup()
+
+	s, err := slogjournald.Sink(&slogjournald.Options{
+		Socket: socket,
+	})
+	assert.Success(t, "sink", err)
+	defer s.(interface{ Close() error }).Close()
+
+	msg := strings.Repeat("a", 1<<20)
+	s.LogEntry(bg, slog.SinkEntry{Message: msg})
+
+	oob := make([]byte, syscall.CmsgSpace(4))
+	_, oobn, _, _, err := conn.ReadMsgUnix(nil, oob)
+	assert.Success(t, "read", err)
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	assert.Success(t, "parse control message", err)
+	assert.Len(t, "control messages", 1, msgs)
+	fds, err := syscall.ParseUnixRights(&msgs[0])
+	assert.Success(t, "parse rights", err)
+
+	f := os.NewFile(uintptr(fds[0]), "entry")
+	defer f.Close()
+	f.Seek(0, 0)
+	b, err := ioutil.ReadAll(f)
+	assert.Success(t, "read entry", err)
+	assert.True(t, "message", strings.HasPrefix(string(b), "MESSAGE="+msg+"\nPRIORITY=7\n"))
+}
@@ -0,0 +1,297 @@
+package slogjson
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"go.opencensus.io/trace"
+
+	"cdr.dev/slog"
+)
+
+// errorKeys are the top level keys that the first
+// error field of an entry is moved to.
+type errorKeys struct {
+	Message string
+	Type    string
+	Stack   string
+}
+
+// extract moves the first field holding an error
+// from fields into m.
+func (ek *errorKeys) extract(m, fields slog.Map) (slog.Map, slog.Map) {
+	for i, f := range fields {
+		err, ok := f.Any().(error)
+		if !ok {
+			continue
+		}
+
+		m = append(m,
+			slog.F(ek.Message, err.Error()),
+			slog.F(ek.Type, fmt.Sprintf("%T", err)),
+		)
+		// The detailed format includes the chain with
+		// locations for errors created with xerrors.
+		if stack := fmt.Sprintf("%+v", err); stack != err.Error() {
+			m = append(m, slog.F(ek.Stack, stack))
+		}
+
+		fields2 := make(slog.Map, 0, len(fields)-1)
+		fields2 = append(fields2, fields[:i]...)
+		fields2 = append(fields2, fields[i+1:]...)
+		return m, fields2
+	}
+	return m, fields
+}
+
+// ECS lays entries out with the field names of the
+// Elastic Common Schema so that they can be ingested
+// by Elasticsearch without an ingest pipeline.
+//
+//	{
+//	  "@timestamp": "2019-09-10T20:19:07.159852Z",
+//	  "log.level": "info",
+//	  "message": "hi",
+//	  "log.origin.file.name": "slog/examples_test.go",
+//	  "log.origin.file.line": 62,
+//	  "log.origin.function": "cdr.dev/slog/sloggers/slogtest_test.TestExampleTest",
+//	  "log.logger": "comp.subcomp",
+//	  "trace.id": "<traceid>",
+//	  "span.id": "<spanid>",
+//	  "error.message": "failed to connect",
+//	  "error.type": "*xerrors.wrapError",
+//	  "ecs.version": "1.6.0",
+//	  "fields": {
+//	    "my_field": "field value"
+//	  }
+//	}
+//
+// The first field holding an error is moved into the error.* keys.
+// Options after ECS can further customize the layout.
+func ECS() Option {
+	return func(o *options) {
+		o.keys = KeyMap{
+			Time:        "@timestamp",
+			Level:       "log.level",
+			Message:     "message",
+			LoggerNames: "log.logger",
+			Trace:       "trace.id",
+			Span:        "span.id",
+		}
+		o.level = func(l slog.Level) interface{} {
+			return strings.ToLower(l.String())
+		}
+		o.caller = func(ent slog.SinkEntry) slog.Map {
+			return slog.M(
+				slog.F("log.origin.file.name", ent.File),
+				slog.F("log.origin.file.line", ent.Line),
+				slog.F("log.origin.function", ent.Func),
+			)
+		}
+		o.joinNames = true
+		o.errorKeys = &errorKeys{
+			Message: "error.message",
+			Type:    "error.type",
+			Stack:   "error.stack_trace",
+		}
+		o.top = slog.M(slog.F("ecs.version", "1.6.0"))
+	}
+}
+
+// GCP lays entries out with the special fields of Google Cloud Logging
+// so that services on Cloud Run and GKE writing to stdout get their
+// severity, source location and traces parsed.
+//
+//	{
+//	  "timestamp": "2019-09-10T20:19:07.159852Z",
+//	  "severity": "INFO",
+//	  "message": "hi",
+//	  "logging.googleapis.com/sourceLocation": {
+//	    "file": "slog/examples_test.go",
+//	    "line": 62,
+//	    "function": "cdr.dev/slog/sloggers/slogtest_test.TestExampleTest"
+//	  },
+//	  "logging.googleapis.com/operation": {
+//	    "producer": "comp.subcomp"
+//	  },
+//	  "logging.googleapis.com/trace": "projects/<projectID>/traces/<traceid>",
+//	  "logging.googleapis.com/spanId": "<spanid>",
+//	  "logging.googleapis.com/trace_sampled": true,
+//	  "my_field": "field value"
+//	}
+//
+// Fields are flattened to the top level so that they become the
+// entry's jsonPayload. projectID is used to qualify trace IDs.
+// See slogstackdriver to detect it from the metadata server.
+//
+// See https://cloud.google.com/logging/docs/structured-logging
+func GCP(projectID string) Option {
+	return func(o *options) {
+		o.keys = KeyMap{
+			Time:    "timestamp",
+			Level:   "severity",
+			Message: "message",
+		}
+		o.level = func(l slog.Level) interface{} {
+			switch {
+			case l < slog.LevelInfo:
+				return "DEBUG"
+			case l < slog.LevelWarn:
+				return "INFO"
+			case l < slog.LevelError:
+				return "WARNING"
+			case l < slog.LevelCritical:
+				return "ERROR"
+			default:
+				return "CRITICAL"
+			}
+		}
+		o.caller = func(ent slog.SinkEntry) slog.Map {
+			return slog.M(
+				slog.F("logging.googleapis.com/sourceLocation", slog.M(
+					slog.F("file", ent.File),
+					slog.F("line", ent.Line),
+					slog.F("function", ent.Func),
+				)),
+			)
+		}
+		o.names = func(names []string) slog.Map {
+			if len(names) == 0 {
+				return nil
+			}
+			return slog.M(
+				slog.F("logging.googleapis.com/operation", slog.M(
+					slog.F("producer", strings.Join(names, ".")),
+				)),
+			)
+		}
+		o.trace = func(sc trace.SpanContext) slog.Map {
+			return slog.M(
+				slog.F("logging.googleapis.com/trace", "projects/"+projectID+"/traces/"+sc.TraceID.String()),
+				slog.F("logging.googleapis.com/spanId", sc.SpanID.String()),
+				slog.F("logging.googleapis.com/trace_sampled", sc.IsSampled()),
+			)
+		}
+		o.flatten = true
+		o.collision = CollisionPrefix
+	}
+}
+
+// Datadog lays entries out with the reserved attributes of Datadog
+// so that their status, logger and traces are recognized.
+//
+//	{
+//	  "timestamp": "2019-09-10T20:19:07.159852Z",
+//	  "status": "info",
+//	  "message": "hi",
+//	  "caller": "slog/examples_test.go:62",
+//	  "func": "cdr.dev/slog/sloggers/slogtest_test.TestExampleTest",
+//	  "logger.name": "comp.subcomp",
+//	  "dd.trace_id": "1234567890123456789",
+//	  "dd.span_id": "1234567890123456789",
+//	  "error.message": "failed to connect",
+//	  "error.kind": "*xerrors.wrapError",
+//	  "fields": {
+//	    "my_field": "field value"
+//	  }
+//	}
+//
+// Datadog correlates logs with traces by 64 bit decimal IDs so the
+// trace ID is the lower 64 bits of the OpenCensus trace ID, which
+// matches the IDs Datadog's OpenCensus exporter reports.
+// The first field holding an error is moved into the error.* keys.
+//
+// See https://docs.datadoghq.com/logs/log_configuration/attributes_naming_convention/
+func Datadog() Option {
+	return func(o *options) {
+		o.keys = KeyMap{
+			Time:        "timestamp",
+			Level:       "status",
+			Message:     "message",
+			LoggerNames: "logger.name",
+		}
+		o.level = func(l slog.Level) interface{} {
+			return strings.ToLower(l.String())
+		}
+		o.joinNames = true
+		o.trace = func(sc trace.SpanContext) slog.Map {
+			return slog.M(
+				slog.F("dd.trace_id", strconv.FormatUint(binary.BigEndian.Uint64(sc.TraceID[8:]), 10)),
+				slog.F("dd.span_id", strconv.FormatUint(binary.BigEndian.Uint64(sc.SpanID[:]), 10)),
+			)
+		}
+		o.errorKeys = &errorKeys{
+			Message: "error.message",
+			Type:    "error.kind",
+			Stack:   "error.stack",
+		}
+	}
+}
+
+// Bunyan lays entries out like the Bunyan and pino loggers for Node.js
+// so that they can be piped through the bunyan and pino-pretty viewers.
+//
+//	{
+//	  "time": "2019-09-10T20:19:07.159852Z",
+//	  "level": 30,
+//	  "msg": "hi",
+//	  "src": {
+//	    "file": "slog/examples_test.go",
+//	    "line": 62,
+//	    "func": "cdr.dev/slog/sloggers/slogtest_test.TestExampleTest"
+//	  },
+//	  "name": "comp.subcomp",
+//	  "trace": "<traceid>",
+//	  "span": "<spanid>",
+//	  "hostname": "host",
+//	  "pid": 42,
+//	  "v": 0,
+//	  "my_field": "field value"
+//	}
+//
+// Levels are mapped with BunyanLevel.
+// The name defaults to the name of the executable
+// for entries without logger names.
+// Fields are flattened to the top level.
+func Bunyan() Option {
+	hostname, _ := os.Hostname()
+	process := filepath.Base(os.Args[0])
+
+	return func(o *options) {
+		o.keys = KeyMap{
+			Time:    "time",
+			Level:   "level",
+			Message: "msg",
+		}
+		o.level = func(l slog.Level) interface{} {
+			return BunyanLevel(l)
+		}
+		o.caller = func(ent slog.SinkEntry) slog.Map {
+			return slog.M(
+				slog.F("src", slog.M(
+					slog.F("file", ent.File),
+					slog.F("line", ent.Line),
+					slog.F("func", ent.Func),
+				)),
+			)
+		}
+		o.names = func(names []string) slog.Map {
+			name := process
+			if len(names) > 0 {
+				name = strings.Join(names, ".")
+			}
+			return slog.M(slog.F("name", name))
+		}
+		o.top = slog.M(
+			slog.F("hostname", hostname),
+			slog.F("pid", os.Getpid()),
+			slog.F("v", 0),
+		)
+		o.flatten = true
+		o.collision = CollisionPrefix
+	}
+}
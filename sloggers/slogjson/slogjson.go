@@ -2,30 +2,41 @@
 //
 // Format
 //
-//  {
-//    "ts": "2019-09-10T20:19:07.159852-05:00",
-//    "level": "INFO",
-//    "logger_names": ["comp", "subcomp"],
-//    "msg": "hi",
-//    "caller": "slog/examples_test.go:62",
-//    "func": "cdr.dev/slog/sloggers/slogtest_test.TestExampleTest",
-//    "trace": "<traceid>",
-//    "span": "<spanid>",
-//    "fields": {
-//      "my_field": "field value"
-//    }
-//  }
+//	{
+//	  "ts": "2019-09-10T20:19:07.159852-05:00",
+//	  "level": "INFO",
+//	  "logger_names": ["comp", "subcomp"],
+//	  "msg": "hi",
+//	  "caller": "slog/examples_test.go:62",
+//	  "func": "cdr.dev/slog/sloggers/slogtest_test.TestExampleTest",
+//	  "trace": "<traceid>",
+//	  "span": "<spanid>",
+//	  "fields": {
+//	    "my_field": "field value"
+//	  }
+//	}
 package slogjson // import "cdr.dev/slog/sloggers/slogjson"
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
-	"fmt"
 	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 
 	"go.opencensus.io/trace"
 
 	"cdr.dev/slog"
+	"cdr.dev/slog/internal/callerpath"
+	"cdr.dev/slog/internal/entryhuman"
+	"cdr.dev/slog/internal/severity"
 	"cdr.dev/slog/internal/syncwriter"
 )
 
@@ -34,46 +45,709 @@ import (
 // for the format.
 // If the writer implements Sync() error then
 // it will be called when syncing.
-func Sink(w io.Writer) slog.Sink {
-	return jsonSink{
-		w: syncwriter.New(w),
+//
+// The format can be customized with options.
+func Sink(w io.Writer, opts ...Option) slog.Sink {
+	s := jsonSink{
+		w:  syncwriter.New(w),
+		w2: w,
+	}
+	for _, opt := range opts {
+		opt(&s.opts)
+	}
+	s.opts.keys = s.opts.keys.withDefaults()
+	return s
+}
+
+// Option customizes the sink returned by Sink.
+type Option func(o *options)
+
+type options struct {
+	fields      slog.Map
+	keys        KeyMap
+	timeFormat  TimeFormat
+	bytesFormat BytesFormat
+	levelCode   LevelCode
+	levelKey    string
+
+	maxFieldSize int
+	maxEntrySize int
+
+	sortFields bool
+	noEscape   bool
+	indent     string
+	color      ColorMode
+	omitCaller bool
+	omitFunc   bool
+
+	callerFormat callerpath.Format
+
+	flatten   bool
+	collision Collision
+
+	// The hooks below are set by layouts like ECS
+	// to replace the default encoding of a part
+	// of the entry.
+	level     func(l slog.Level) interface{}
+	caller    func(ent slog.SinkEntry) slog.Map
+	trace     func(sc trace.SpanContext) slog.Map
+	joinNames bool
+	names     func(names []string) slog.Map
+	errorKeys *errorKeys
+	top       slog.Map
+}
+
+// KeyMap holds the names of the top level keys.
+// Empty names keep the default.
+type KeyMap struct {
+	// Time defaults to "ts".
+	Time string
+	// Level defaults to "level".
+	Level string
+	// Message defaults to "msg".
+	Message string
+	// Caller defaults to "caller".
+	Caller string
+	// Func defaults to "func".
+	Func string
+	// LoggerNames defaults to "logger_names".
+	LoggerNames string
+	// Trace defaults to "trace".
+	Trace string
+	// Span defaults to "span".
+	Span string
+	// Fields defaults to "fields".
+	Fields string
+	// Truncated defaults to "truncated".
+	Truncated string
+}
+
+func (km KeyMap) withDefaults() KeyMap {
+	def := func(k *string, v string) {
+		if *k == "" {
+			*k = v
+		}
+	}
+	def(&km.Time, "ts")
+	def(&km.Level, "level")
+	def(&km.Message, "msg")
+	def(&km.Caller, "caller")
+	def(&km.Func, "func")
+	def(&km.LoggerNames, "logger_names")
+	def(&km.Trace, "trace")
+	def(&km.Span, "span")
+	def(&km.Fields, "fields")
+	def(&km.Truncated, "truncated")
+	return km
+}
+
+// WithKeyMap renames the top level keys.
+//
+// For example, to match a pipeline that expects
+// timestamp, severity and message:
+//
+//	slogjson.WithKeyMap(slogjson.KeyMap{
+//		Time:    "timestamp",
+//		Level:   "severity",
+//		Message: "message",
+//	})
+func WithKeyMap(km KeyMap) Option {
+	return func(o *options) {
+		o.keys = km
+	}
+}
+
+// WithFields adds the fields to every entry before
+// the entry's own fields.
+//
+// Use it for deployment metadata like the service name
+// or version.
+func WithFields(fields ...slog.Field) Option {
+	return func(o *options) {
+		o.fields = append(o.fields, fields...)
+	}
+}
+
+// TimeFormat formats the time of an entry into
+// a JSON value.
+type TimeFormat func(t time.Time) interface{}
+
+// The supported time formats.
+var (
+	// TimeRFC3339Nano formats times as RFC3339 strings
+	// with nanosecond precision. It is the default.
+	TimeRFC3339Nano = TimeLayout(time.RFC3339Nano)
+	// TimeUnix formats times as the number of seconds
+	// since the Unix epoch.
+	TimeUnix TimeFormat = func(t time.Time) interface{} {
+		return t.Unix()
+	}
+	// TimeUnixMilli formats times as the number of
+	// milliseconds since the Unix epoch.
+	TimeUnixMilli TimeFormat = func(t time.Time) interface{} {
+		return t.UnixNano() / int64(time.Millisecond)
+	}
+	// TimeUnixNano formats times as the number of
+	// nanoseconds since the Unix epoch.
+	TimeUnixNano TimeFormat = func(t time.Time) interface{} {
+		return t.UnixNano()
+	}
+)
+
+// TimeLayout returns a TimeFormat that formats times as
+// strings with the given layout. See time.Format.
+//
+// For example, use "2006-01-02T15:04:05.000Z07:00" for
+// RFC3339 with millisecond precision.
+func TimeLayout(layout string) TimeFormat {
+	return func(t time.Time) interface{} {
+		return t.Format(layout)
+	}
+}
+
+// WithTimeFormat sets the format of the time key.
+func WithTimeFormat(f TimeFormat) Option {
+	return func(o *options) {
+		o.timeFormat = f
+	}
+}
+
+// BytesFormat formats []byte field values into a JSON value.
+type BytesFormat func(b []byte) interface{}
+
+// The supported []byte formats.
+var (
+	// BytesBase64 formats []byte as base64 strings like
+	// encoding/json. It is the default.
+	BytesBase64 BytesFormat = func(b []byte) interface{} {
+		return base64.StdEncoding.EncodeToString(b)
+	}
+	// BytesHex formats []byte as hex strings.
+	BytesHex BytesFormat = func(b []byte) interface{} {
+		return hex.EncodeToString(b)
+	}
+)
+
+// BytesPreview returns a BytesFormat that formats []byte as hex
+// strings of at most n bytes. Longer values are truncated and
+// annotated with their length, e.g. "deadbeef... (1024 bytes)".
+func BytesPreview(n int) BytesFormat {
+	return func(b []byte) interface{} {
+		if len(b) <= n {
+			return hex.EncodeToString(b)
+		}
+		return hex.EncodeToString(b[:n]) + "... (" + strconv.Itoa(len(b)) + " bytes)"
+	}
+}
+
+// WithBytesFormat sets the format of []byte field values,
+// including those nested in slog.Map values.
+func WithBytesFormat(f BytesFormat) Option {
+	return func(o *options) {
+		o.bytesFormat = f
+	}
+}
+
+// formatBytes returns a copy of fields with every []byte
+// value formatted with f.
+func formatBytes(fields slog.Map, f BytesFormat) slog.Map {
+	fields2 := make(slog.Map, len(fields))
+	for i, field := range fields {
+		switch v := field.Any().(type) {
+		case []byte:
+			field = slog.F(field.Name, f(v))
+		case slog.Map:
+			field = slog.F(field.Name, formatBytes(v, f))
+		}
+		fields2[i] = field
+	}
+	return fields2
+}
+
+// WithMaxFieldSize truncates string field values, including those
+// nested in slog.Map values, to at most n bytes.
+//
+// Entries with truncated values are annotated with "truncated": true.
+func WithMaxFieldSize(n int) Option {
+	return func(o *options) {
+		o.maxFieldSize = n
+	}
+}
+
+// WithMaxEntrySize limits the size of every entry to n bytes
+// so that one huge entry cannot break downstream ingestion,
+// e.g. syslog's 16KB or CloudWatch's 256KB limits.
+//
+// Oversized entries first have their fields dropped from the end
+// and then their message truncated until they fit. They are
+// annotated with "truncated": true. The limit does not apply
+// to the whitespace added by WithIndent.
+func WithMaxEntrySize(n int) Option {
+	return func(o *options) {
+		o.maxEntrySize = n
+	}
+}
+
+// truncateStrings returns a copy of fields with every string
+// value longer than n bytes truncated.
+func truncateStrings(fields slog.Map, n int) (slog.Map, bool) {
+	truncated := false
+	fields2 := make(slog.Map, len(fields))
+	for i, f := range fields {
+		switch v := f.Any().(type) {
+		case string:
+			if len(v) > n {
+				f = slog.F(f.Name, truncateString(v, n))
+				truncated = true
+			}
+		case slog.Map:
+			m, ok := truncateStrings(v, n)
+			f = slog.F(f.Name, m)
+			truncated = truncated || ok
+		}
+		fields2[i] = f
+	}
+	return fields2, truncated
+}
+
+// truncateString truncates s to at most n bytes including the "..."
+// appended to mark it truncated without splitting a UTF-8 sequence.
+func truncateString(s string, n int) string {
+	const marker = "..."
+	if n <= 0 {
+		return ""
+	}
+	if n >= len(s) {
+		return s
+	}
+	if n <= len(marker) {
+		return marker[:n]
+	}
+	n -= len(marker)
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + marker
+}
+
+// WithSortedFields sorts the fields by name, including those
+// nested in slog.Map values, instead of keeping them in the
+// order they were added so that entries are stable for diffing,
+// golden tests and deduplication.
+//
+// Fields with the same name keep their order.
+func WithSortedFields() Option {
+	return func(o *options) {
+		o.sortFields = true
+	}
+}
+
+// sortFields returns a copy of fields sorted by name.
+func sortFields(fields slog.Map) slog.Map {
+	fields2 := make(slog.Map, len(fields))
+	for i, f := range fields {
+		if m, ok := f.Any().(slog.Map); ok {
+			f = slog.F(f.Name, sortFields(m))
+		}
+		fields2[i] = f
+	}
+	sort.SliceStable(fields2, func(i, j int) bool {
+		return fields2[i].Name < fields2[j].Name
+	})
+	return fields2
+}
+
+// WithoutHTMLEscape disables the escaping of <, > and & that
+// encoding/json does by default so that URLs and HTML snippets
+// stay readable in the logs.
+func WithoutHTMLEscape() Option {
+	return func(o *options) {
+		o.noEscape = true
+	}
+}
+
+// unescapeHTML replaces the escaped forms of <, > and &
+// in the JSON in b with the literal characters.
+func unescapeHTML(b []byte) []byte {
+	j := 0
+	for i := 0; i < len(b); i++ {
+		if b[i] != '\\' || i+1 >= len(b) {
+			b[j] = b[i]
+			j++
+			continue
+		}
+
+		if i+5 < len(b) {
+			var c byte
+			switch string(b[i+1 : i+6]) {
+			case "u0026":
+				c = '&'
+			case "u003c":
+				c = '<'
+			case "u003e":
+				c = '>'
+			}
+			if c != 0 {
+				b[j] = c
+				j++
+				i += 5
+				continue
+			}
+		}
+
+		// Copy the escape as is so that an escaped
+		// backslash is not mistaken for an escape.
+		b[j] = b[i]
+		b[j+1] = b[i+1]
+		j += 2
+		i++
+	}
+	return b[:j]
+}
+
+// LevelCode maps a level to a numeric code.
+type LevelCode func(l slog.Level) int
+
+// The supported level codes.
+var (
+	// LevelValue is the value of the level, e.g.
+	// 10 for LevelInfo.
+	LevelValue LevelCode = func(l slog.Level) int {
+		return int(l)
+	}
+	// SyslogSeverity maps levels to the RFC 5424 severities:
+	// 7 (debug) for LevelDebug and below, 6 (informational) for
+	// LevelInfo, 4 (warning) for LevelWarn, 3 (error) for
+	// LevelError, 2 (critical) for LevelCritical and
+	// 0 (emergency) for LevelFatal and above.
+	SyslogSeverity LevelCode = severity.Syslog
+	// BunyanLevel maps levels to the levels of Bunyan and pino:
+	// 10 below LevelDebug, 20 for LevelDebug, 30 for LevelInfo,
+	// 40 for LevelWarn, 50 for LevelError and 60 for LevelCritical
+	// and above.
+	BunyanLevel LevelCode = func(l slog.Level) int {
+		switch {
+		case l < slog.LevelDebug:
+			return 10
+		case l < slog.LevelInfo:
+			return 20
+		case l < slog.LevelWarn:
+			return 30
+		case l < slog.LevelError:
+			return 40
+		case l < slog.LevelCritical:
+			return 50
+		default:
+			return 60
+		}
+	}
+)
+
+// WithNumericLevel encodes the level as the numeric code c.
+//
+// If key is empty, the code replaces the level's name.
+// Otherwise it is written under key after the level's name.
+func WithNumericLevel(c LevelCode, key string) Option {
+	return func(o *options) {
+		o.levelCode = c
+		o.levelKey = key
+	}
+}
+
+// WithIndent indents every entry with the given indent,
+// e.g. two spaces, for reading logs during development.
+//
+// When writing to a TTY, the output is also colorized.
+// See WithColor.
+func WithIndent(indent string) Option {
+	return func(o *options) {
+		o.indent = indent
+	}
+}
+
+// ColorMode controls whether indented output is colored.
+type ColorMode int
+
+// The color modes.
+const (
+	// ColorAuto colors output written to a TTY. The NO_COLOR
+	// environment variable disables color and FORCE_COLOR
+	// enables it regardless of the writer.
+	ColorAuto = ColorMode(entryhuman.ColorAuto)
+	// ColorAlways always colors output.
+	ColorAlways = ColorMode(entryhuman.ColorAlways)
+	// ColorNever never colors output.
+	ColorNever = ColorMode(entryhuman.ColorNever)
+)
+
+// WithColor sets whether output indented with WithIndent
+// is colored. Unindented output is never colored.
+// The default is ColorAuto.
+func WithColor(mode ColorMode) Option {
+	return func(o *options) {
+		o.color = mode
+	}
+}
+
+// CallerFormat is how the file of the caller is formatted.
+type CallerFormat int
+
+// The caller formats.
+const (
+	// CallerFull is the full path of the file.
+	CallerFull = CallerFormat(callerpath.Full)
+	// CallerBase is only the file name, e.g. main.go.
+	CallerBase = CallerFormat(callerpath.Base)
+	// CallerShort is the last two path components, e.g. cmd/main.go.
+	CallerShort = CallerFormat(callerpath.Short)
+	// CallerModule is the path relative to the root of the module
+	// that contains the file, e.g. internal/cmd/main.go. Files
+	// outside of a module are formatted like CallerShort.
+	CallerModule = CallerFormat(callerpath.Module)
+)
+
+// WithCallerFormat sets how the file of the caller is formatted.
+// It applies to the file of every layout.
+// The default is CallerFull.
+func WithCallerFormat(f CallerFormat) Option {
+	return func(o *options) {
+		o.callerFormat = callerpath.Format(f)
+	}
+}
+
+// WithoutCaller omits the caller key.
+func WithoutCaller() Option {
+	return func(o *options) {
+		o.omitCaller = true
+	}
+}
+
+// WithoutFunc omits the func key.
+//
+// The logger_names key is always omitted when
+// the entry has no logger names.
+func WithoutFunc() Option {
+	return func(o *options) {
+		o.omitFunc = true
+	}
+}
+
+// Collision is the policy for a flattened field whose
+// name collides with a top level key.
+type Collision int
+
+// The supported collision policies.
+const (
+	// CollisionPrefix prefixes the field's name with the
+	// fields key and a dot, e.g. "fields.msg".
+	CollisionPrefix Collision = iota
+	// CollisionOverwrite replaces the top level value
+	// with the field's value.
+	CollisionOverwrite
+	// CollisionDrop drops the field.
+	CollisionDrop
+)
+
+// WithFlatFields merges the fields into the top level object
+// instead of nesting them under the fields key so that backends
+// that only index top level keys can index them.
+//
+// c decides what happens when a field's name collides with
+// a top level key present in the entry.
+func WithFlatFields(c Collision) Option {
+	return func(o *options) {
+		o.flatten = true
+		o.collision = c
 	}
 }
 
 type jsonSink struct {
-	w *syncwriter.Writer
+	w    *syncwriter.Writer
+	w2   io.Writer
+	opts options
 }
 
 func (s jsonSink) LogEntry(ctx context.Context, ent slog.SinkEntry) {
+	if len(s.opts.fields) > 0 {
+		fields := make(slog.Map, 0, len(s.opts.fields)+len(ent.Fields))
+		fields = append(fields, s.opts.fields...)
+		ent.Fields = append(fields, ent.Fields...)
+	}
+
+	if s.opts.bytesFormat != nil {
+		ent.Fields = formatBytes(ent.Fields, s.opts.bytesFormat)
+	}
+
+	if s.opts.callerFormat != callerpath.Full {
+		ent.File = callerpath.Trim(ent.File, s.opts.callerFormat)
+	}
+	if s.opts.sortFields {
+		ent.Fields = sortFields(ent.Fields)
+	}
+
+	truncated := false
+	if s.opts.maxFieldSize > 0 {
+		ent.Fields, truncated = truncateStrings(ent.Fields, s.opts.maxFieldSize)
+	}
+
+	bufp := getBuffer()
+	defer putBuffer(bufp)
+
+	buf := s.entryMap(ent, truncated).AppendJSON((*bufp)[:0])
+	if limit := s.opts.maxEntrySize; limit > 0 {
+		// Drop fields from the end and then truncate the
+		// message until the entry fits.
+		for len(buf) > limit && len(ent.Fields) > 0 {
+			ent.Fields = ent.Fields[:len(ent.Fields)-1]
+			buf = s.entryMap(ent, true).AppendJSON(buf[:0])
+		}
+		for len(buf) > limit && ent.Message != "" {
+			keep := len(ent.Message) - (len(buf) - limit)
+			ent.Message = truncateString(ent.Message, keep)
+			buf = s.entryMap(ent, true).AppendJSON(buf[:0])
+		}
+	}
+	if s.opts.noEscape {
+		buf = unescapeHTML(buf)
+	}
+	buf = append(buf, '\n')
+	*bufp = buf
+	if s.opts.indent != "" {
+		ibuf := &bytes.Buffer{}
+		json.Indent(ibuf, buf[:len(buf)-1], "", s.opts.indent)
+		buf = append(entryhuman.FormatJSON(s.w2, ibuf.Bytes(), entryhuman.ColorMode(s.opts.color)), '\n')
+	}
+
+	err := s.w.Write(ctx, "slogjson", buf)
+	if err != nil {
+		slog.ReportError(ctx, err, ent)
+	}
+}
+
+func (s jsonSink) entryMap(ent slog.SinkEntry, truncated bool) slog.Map {
+	var ts interface{} = ent.Time
+	if s.opts.timeFormat != nil {
+		ts = s.opts.timeFormat(ent.Time)
+	}
+
+	var level interface{} = ent.Level
+	if s.opts.level != nil {
+		level = s.opts.level(ent.Level)
+	}
+	if s.opts.levelCode != nil && s.opts.levelKey == "" {
+		level = s.opts.levelCode(ent.Level)
+	}
+
+	keys := s.opts.keys
 	m := slog.M(
-		slog.F("ts", ent.Time),
-		slog.F("level", ent.Level),
-		slog.F("msg", ent.Message),
-		slog.F("caller", fmt.Sprintf("%v:%v", ent.File, ent.Line)),
-		slog.F("func", ent.Func),
+		slog.F(keys.Time, ts),
+		slog.F(keys.Level, level),
 	)
+	if s.opts.levelCode != nil && s.opts.levelKey != "" {
+		m = append(m, slog.F(s.opts.levelKey, s.opts.levelCode(ent.Level)))
+	}
+	m = append(m, slog.F(keys.Message, ent.Message))
 
-	if len(ent.LoggerNames) > 0 {
-		m = append(m, slog.F("logger_names", ent.LoggerNames))
+	if s.opts.caller != nil {
+		if !s.opts.omitCaller {
+			m = append(m, s.opts.caller(ent)...)
+		}
+	} else {
+		if !s.opts.omitCaller {
+			m = append(m, slog.F(keys.Caller, ent.File+":"+strconv.Itoa(ent.Line)))
+		}
+		if !s.opts.omitFunc {
+			m = append(m, slog.F(keys.Func, ent.Func))
+		}
+	}
+
+	if s.opts.names != nil {
+		m = append(m, s.opts.names(ent.LoggerNames)...)
+	} else if len(ent.LoggerNames) > 0 {
+		if s.opts.joinNames {
+			m = append(m, slog.F(keys.LoggerNames, strings.Join(ent.LoggerNames, ".")))
+		} else {
+			m = append(m, slog.F(keys.LoggerNames, ent.LoggerNames))
+		}
 	}
 
 	if ent.SpanContext != (trace.SpanContext{}) {
-		m = append(m,
-			slog.F("trace", ent.SpanContext.TraceID),
-			slog.F("span", ent.SpanContext.SpanID),
-		)
+		if s.opts.trace != nil {
+			m = append(m, s.opts.trace(ent.SpanContext)...)
+		} else {
+			m = append(m,
+				slog.F(keys.Trace, ent.SpanContext.TraceID.String()),
+				slog.F(keys.Span, ent.SpanContext.SpanID.String()),
+			)
+		}
+	}
+
+	if s.opts.errorKeys != nil {
+		m, ent.Fields = s.opts.errorKeys.extract(m, ent.Fields)
 	}
 
-	if len(ent.Fields) > 0 {
+	m = append(m, s.opts.top...)
+
+	if truncated {
+		m = append(m, slog.F(keys.Truncated, true))
+	}
+
+	if s.opts.flatten {
+		m = s.flatten(m, ent.Fields)
+	} else if len(ent.Fields) > 0 {
 		m = append(m,
-			slog.F("fields", ent.Fields),
+			slog.F(keys.Fields, ent.Fields),
 		)
 	}
+	return m
+}
+
+func (s jsonSink) flatten(m slog.Map, fields slog.Map) slog.Map {
+	top := make(map[string]int, len(m))
+	for i, f := range m {
+		top[f.Name] = i
+	}
 
-	buf, _ := json.Marshal(m)
+	for _, f := range fields {
+		i, ok := top[f.Name]
+		if !ok {
+			m = append(m, f)
+			continue
+		}
+		switch s.opts.collision {
+		case CollisionOverwrite:
+			m[i] = f
+		case CollisionDrop:
+		default:
+			f.Name = s.opts.keys.Fields + "." + f.Name
+			m = append(m, f)
+		}
+	}
+	return m
+}
 
-	buf = append(buf, '\n')
-	s.w.Write("slogjson", buf)
+// bufferPool reuses the buffers entries are encoded into
+// so that sustained logging does not churn the GC.
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, 1024)
+		return &b
+	},
+}
+
+// maxPooledBuffer is the largest buffer returned to the pool
+// so that one huge entry does not pin its memory forever.
+const maxPooledBuffer = 64 << 10
+
+func getBuffer() *[]byte {
+	return bufferPool.Get().(*[]byte)
+}
+
+func putBuffer(b *[]byte) {
+	if cap(*b) > maxPooledBuffer {
+		return
+	}
+	bufferPool.Put(b)
 }
 
 func (s jsonSink) Sync() {
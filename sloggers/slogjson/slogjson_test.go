@@ -3,9 +3,15 @@ package slogjson_test
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
 	"runtime"
+	"strings"
 	"testing"
+	"time"
 
 	"go.opencensus.io/trace"
 
@@ -29,7 +35,354 @@ func TestMake(t *testing.T) {
 	l.Error(ctx, "line1\n\nline2", slog.F("wowow", "me\nyou"))
 
 	j := entryjson.Filter(b.String(), "ts")
-	exp := fmt.Sprintf(`{"level":"ERROR","msg":"line1\n\nline2","caller":"%v:29","func":"cdr.dev/slog/sloggers/slogjson_test.TestMake","logger_names":["named"],"trace":"%v","span":"%v","fields":{"wowow":"me\nyou"}}
+	exp := fmt.Sprintf(`{"level":"ERROR","msg":"line1\n\nline2","caller":"%v:35","func":"cdr.dev/slog/sloggers/slogjson_test.TestMake","logger_names":["named"],"trace":"%v","span":"%v","fields":{"wowow":"me\nyou"}}
 `, slogjsonTestFile, s.SpanContext().TraceID, s.SpanContext().SpanID)
 	assert.Equal(t, "entry", exp, j)
 }
+
+func TestWithFields(t *testing.T) {
+	t.Parallel()
+
+	b := &bytes.Buffer{}
+	l := slog.Make(slogjson.Sink(b, slogjson.WithFields(slog.F("service", "api"), slog.F("version", "abc"))))
+	l.Info(bg, "hi", slog.F("a", 1))
+
+	j := entryjson.Filter(b.String(), "ts")
+	j = entryjson.Filter(j, "caller")
+	exp := `{"level":"INFO","msg":"hi","func":"cdr.dev/slog/sloggers/slogjson_test.TestWithFields","fields":{"service":"api","version":"abc","a":1}}
+`
+	assert.Equal(t, "entry", exp, j)
+}
+
+func TestWithKeyMap(t *testing.T) {
+	t.Parallel()
+
+	b := &bytes.Buffer{}
+	l := slog.Make(slogjson.Sink(b, slogjson.WithKeyMap(slogjson.KeyMap{
+		Time:    "timestamp",
+		Level:   "severity",
+		Message: "message",
+		Fields:  "attrs",
+	})))
+	l.Named("named").Info(bg, "hi", slog.F("a", 1))
+
+	j := entryjson.Filter(b.String(), "timestamp")
+	j = entryjson.Filter(j, "caller")
+	exp := `{"severity":"INFO","message":"hi","func":"cdr.dev/slog/sloggers/slogjson_test.TestWithKeyMap","logger_names":["named"],"attrs":{"a":1}}
+`
+	assert.Equal(t, "entry", exp, j)
+}
+
+func TestWithTimeFormat(t *testing.T) {
+	t.Parallel()
+
+	ts := time.Date(2020, time.March, 4, 5, 6, 7, 891234567, time.UTC)
+	for _, tc := range []struct {
+		f   slogjson.TimeFormat
+		exp string
+	}{
+		{slogjson.TimeRFC3339Nano, `"2020-03-04T05:06:07.891234567Z"`},
+		{slogjson.TimeLayout("2006-01-02T15:04:05.000Z07:00"), `"2020-03-04T05:06:07.891Z"`},
+		{slogjson.TimeUnix, `1583298367`},
+		{slogjson.TimeUnixMilli, `1583298367891`},
+		{slogjson.TimeUnixNano, `1583298367891234567`},
+	} {
+		b := &bytes.Buffer{}
+		s := slogjson.Sink(b, slogjson.WithTimeFormat(tc.f))
+		s.LogEntry(bg, slog.SinkEntry{Time: ts})
+		assert.True(t, "ts", strings.HasPrefix(b.String(), `{"ts":`+tc.exp+`,`))
+	}
+}
+
+func TestWithIndent(t *testing.T) {
+	t.Parallel()
+
+	b := &bytes.Buffer{}
+	s := slogjson.Sink(b, slogjson.WithIndent("  "), slogjson.WithTimeFormat(slogjson.TimeUnix))
+	s.LogEntry(bg, slog.SinkEntry{
+		Message: "hi",
+		Fields:  slog.M(slog.F("a", 1)),
+	})
+
+	exp := `{
+  "ts": -62135596800,
+  "level": "DEBUG",
+  "msg": "hi",
+  "caller": ":0",
+  "func": "",
+  "fields": {
+    "a": 1
+  }
+}
+`
+	assert.Equal(t, "entry", exp, b.String())
+}
+
+func TestWithColor(t *testing.T) {
+	t.Parallel()
+
+	b := &bytes.Buffer{}
+	s := slogjson.Sink(b,
+		slogjson.WithIndent("  "),
+		slogjson.WithColor(slogjson.ColorAlways),
+		slogjson.WithoutCaller(),
+		slogjson.WithoutFunc(),
+		slogjson.WithTimeFormat(slogjson.TimeUnix),
+	)
+	s.LogEntry(bg, slog.SinkEntry{
+		Message: "hi",
+	})
+
+	exp := "{\n  \x1b[34m\"ts\"\x1b[0m: \x1b[35m-62135596800\x1b[0m,\n  \x1b[34m\"level\"\x1b[0m: \x1b[32m\"DEBUG\"\x1b[0m,\n  \x1b[34m\"msg\"\x1b[0m: \x1b[32m\"hi\"\x1b[0m\n}\n"
+	assert.Equal(t, "entry", exp, b.String())
+}
+
+func TestWithoutCaller(t *testing.T) {
+	t.Parallel()
+
+	b := &bytes.Buffer{}
+	l := slog.Make(slogjson.Sink(b, slogjson.WithoutCaller(), slogjson.WithoutFunc()))
+	l.Info(bg, "hi")
+
+	j := entryjson.Filter(b.String(), "ts")
+	assert.Equal(t, "entry", `{"level":"INFO","msg":"hi"}
+`, j)
+}
+
+func TestWithFlatFields(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		c   slogjson.Collision
+		exp string
+	}{
+		{slogjson.CollisionPrefix, `{"level":"INFO","msg":"hi","a":1,"fields.msg":"field"}`},
+		{slogjson.CollisionOverwrite, `{"level":"INFO","msg":"field","a":1}`},
+		{slogjson.CollisionDrop, `{"level":"INFO","msg":"hi","a":1}`},
+	} {
+		b := &bytes.Buffer{}
+		l := slog.Make(slogjson.Sink(b, slogjson.WithFlatFields(tc.c), slogjson.WithoutCaller(), slogjson.WithoutFunc()))
+		l.Info(bg, "hi", slog.F("a", 1), slog.F("msg", "field"))
+
+		j := entryjson.Filter(b.String(), "ts")
+		assert.Equal(t, "entry", tc.exp+"\n", j)
+	}
+}
+
+func BenchmarkSink(b *testing.B) {
+	s := slogjson.Sink(ioutil.Discard)
+	ent := slog.SinkEntry{
+		Time:        time.Now(),
+		Level:       slog.LevelInfo,
+		Message:     "hello",
+		LoggerNames: []string{"comp", "db"},
+		File:        "slogjson_test.go",
+		Line:        42,
+		Func:        "cdr.dev/slog/sloggers/slogjson_test.BenchmarkSink",
+		Fields: slog.M(
+			slog.String("str", "value"),
+			slog.Int("int", 42),
+			slog.Bool("bool", true),
+			slog.Duration("dur", time.Second),
+		),
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		s.LogEntry(bg, ent)
+	}
+}
+
+func TestECS(t *testing.T) {
+	t.Parallel()
+
+	ctx, span := trace.StartSpan(bg, "meow")
+	b := &bytes.Buffer{}
+	l := slog.Make(slogjson.Sink(b, slogjson.ECS()))
+	l.Named("comp").Named("db").Info(ctx, "hi", slog.F("a", 1), slog.Error(io.EOF))
+
+	j := entryjson.Filter(b.String(), "@timestamp")
+	exp := fmt.Sprintf(`{"log.level":"info","message":"hi","log.origin.file.name":"%v","log.origin.file.line":202,"log.origin.function":"cdr.dev/slog/sloggers/slogjson_test.TestECS","log.logger":"comp.db","trace.id":"%v","span.id":"%v","error.message":"EOF","error.type":"*errors.errorString","ecs.version":"1.6.0","fields":{"a":1}}
+`, slogjsonTestFile, span.SpanContext().TraceID, span.SpanContext().SpanID)
+	assert.Equal(t, "entry", exp, j)
+}
+
+func TestGCP(t *testing.T) {
+	t.Parallel()
+
+	ctx, span := trace.StartSpan(bg, "meow", trace.WithSampler(trace.AlwaysSample()))
+	b := &bytes.Buffer{}
+	l := slog.Make(slogjson.Sink(b, slogjson.GCP("proj")))
+	l.Named("comp").Warn(ctx, "hi", slog.F("a", 1), slog.F("message", "field"))
+
+	j := entryjson.Filter(b.String(), "timestamp")
+	exp := fmt.Sprintf(`{"severity":"WARNING","message":"hi","logging.googleapis.com/sourceLocation":{"file":"%v","line":216,"function":"cdr.dev/slog/sloggers/slogjson_test.TestGCP"},"logging.googleapis.com/operation":{"producer":"comp"},"logging.googleapis.com/trace":"projects/proj/traces/%v","logging.googleapis.com/spanId":"%v","logging.googleapis.com/trace_sampled":true,"a":1,"fields.message":"field"}
+`, slogjsonTestFile, span.SpanContext().TraceID, span.SpanContext().SpanID)
+	assert.Equal(t, "entry", exp, j)
+}
+
+func TestDatadog(t *testing.T) {
+	t.Parallel()
+
+	sc := trace.SpanContext{
+		TraceID: trace.TraceID{0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 1, 2},
+		SpanID:  trace.SpanID{0, 0, 0, 0, 0, 0, 0, 3},
+	}
+	b := &bytes.Buffer{}
+	s := slogjson.Sink(b, slogjson.Datadog(), slogjson.WithoutCaller(), slogjson.WithoutFunc())
+	s.LogEntry(bg, slog.SinkEntry{
+		Level:       slog.LevelWarn,
+		Message:     "hi",
+		LoggerNames: []string{"comp", "db"},
+		SpanContext: sc,
+		Fields:      slog.M(slog.Error(io.EOF), slog.F("a", 1)),
+	})
+
+	j := entryjson.Filter(b.String(), "timestamp")
+	exp := `{"status":"warn","message":"hi","logger.name":"comp.db","dd.trace_id":"258","dd.span_id":"3","error.message":"EOF","error.kind":"*errors.errorString","fields":{"a":1}}
+`
+	assert.Equal(t, "entry", exp, j)
+}
+
+func TestBunyan(t *testing.T) {
+	t.Parallel()
+
+	b := &bytes.Buffer{}
+	s := slogjson.Sink(b, slogjson.Bunyan(), slogjson.WithoutCaller())
+	s.LogEntry(bg, slog.SinkEntry{
+		Level:       slog.LevelError,
+		Message:     "hi",
+		LoggerNames: []string{"comp", "db"},
+		Fields:      slog.M(slog.F("a", 1), slog.F("pid", "field")),
+	})
+
+	hostname, _ := os.Hostname()
+	j := entryjson.Filter(b.String(), "time")
+	exp := fmt.Sprintf(`{"level":50,"msg":"hi","name":"comp.db","hostname":%q,"pid":%v,"v":0,"a":1,"fields.pid":"field"}
+`, hostname, os.Getpid())
+	assert.Equal(t, "entry", exp, j)
+}
+
+func TestWithBytesFormat(t *testing.T) {
+	t.Parallel()
+
+	p := []byte{0xde, 0xad, 0xbe, 0xef}
+	for _, tc := range []struct {
+		f   slogjson.BytesFormat
+		exp string
+	}{
+		{nil, `"3q2+7w=="`},
+		{slogjson.BytesHex, `"deadbeef"`},
+		{slogjson.BytesPreview(2), `"dead... (4 bytes)"`},
+		{slogjson.BytesPreview(4), `"deadbeef"`},
+	} {
+		b := &bytes.Buffer{}
+		s := slogjson.Sink(b, slogjson.WithBytesFormat(tc.f), slogjson.WithoutCaller(), slogjson.WithoutFunc())
+		s.LogEntry(bg, slog.SinkEntry{
+			Fields: slog.M(
+				slog.F("a", p),
+				slog.F("m", slog.M(slog.F("b", p))),
+			),
+		})
+
+		j := entryjson.Filter(b.String(), "ts")
+		exp := `{"level":"DEBUG","msg":"","fields":{"a":` + tc.exp + `,"m":{"b":` + tc.exp + `}}}` + "\n"
+		assert.Equal(t, "entry", exp, j)
+	}
+}
+
+func TestWithMaxSize(t *testing.T) {
+	t.Parallel()
+
+	b := &bytes.Buffer{}
+	s := slogjson.Sink(b, slogjson.WithMaxFieldSize(5), slogjson.WithoutCaller(), slogjson.WithoutFunc(), slogjson.WithTimeFormat(slogjson.TimeUnix))
+	s.LogEntry(bg, slog.SinkEntry{
+		Message: "hi",
+		Fields: slog.M(
+			slog.F("a", "héllo"),
+			slog.F("m", slog.M(slog.F("b", "ok"))),
+		),
+	})
+	assert.Equal(t, "entry", `{"ts":-62135596800,"level":"DEBUG","msg":"hi","truncated":true,"fields":{"a":"h...","m":{"b":"ok"}}}`+"\n", b.String())
+
+	b.Reset()
+	s = slogjson.Sink(b, slogjson.WithMaxEntrySize(80), slogjson.WithoutCaller(), slogjson.WithoutFunc(), slogjson.WithTimeFormat(slogjson.TimeUnix))
+	s.LogEntry(bg, slog.SinkEntry{
+		Message: "hi",
+		Fields: slog.M(
+			slog.F("a", 1),
+			slog.F("b", strings.Repeat("x", 100)),
+		),
+	})
+	assert.Equal(t, "entry", `{"ts":-62135596800,"level":"DEBUG","msg":"hi","truncated":true,"fields":{"a":1}}`+"\n", b.String())
+
+	b.Reset()
+	s.LogEntry(bg, slog.SinkEntry{
+		Message: strings.Repeat("y", 100),
+	})
+	assert.Equal(t, "size", 81, b.Len())
+	assert.True(t, "truncated", strings.HasSuffix(b.String(), `y...","truncated":true}`+"\n"))
+}
+
+func TestWithSortedFields(t *testing.T) {
+	t.Parallel()
+
+	b := &bytes.Buffer{}
+	s := slogjson.Sink(b, slogjson.WithSortedFields(), slogjson.WithoutCaller(), slogjson.WithoutFunc())
+	s.LogEntry(bg, slog.SinkEntry{
+		Fields: slog.M(
+			slog.F("c", 1),
+			slog.F("a", slog.M(slog.F("z", 1), slog.F("y", 2))),
+			slog.F("b", 1),
+			slog.F("a", 2),
+		),
+	})
+
+	j := entryjson.Filter(b.String(), "ts")
+	assert.Equal(t, "entry", `{"level":"DEBUG","msg":"","fields":{"a":{"y":2,"z":1},"a":2,"b":1,"c":1}}`+"\n", j)
+}
+
+func TestWithoutHTMLEscape(t *testing.T) {
+	t.Parallel()
+
+	b := &bytes.Buffer{}
+	s := slogjson.Sink(b, slogjson.WithoutHTMLEscape(), slogjson.WithoutCaller(), slogjson.WithoutFunc())
+	s.LogEntry(bg, slog.SinkEntry{
+		Message: "<a href=\"/?a=1&b=2\">",
+		Fields: slog.M(
+			slog.F("raw", json.RawMessage(`"\u003c"`)),
+			slog.F("backslash", `\u003c`),
+		),
+	})
+
+	j := entryjson.Filter(b.String(), "ts")
+	assert.Equal(t, "entry", `{"level":"DEBUG","msg":"<a href=\"/?a=1&b=2\">","fields":{"raw":"<","backslash":"\\u003c"}}`+"\n", j)
+}
+
+func TestWithNumericLevel(t *testing.T) {
+	t.Parallel()
+
+	b := &bytes.Buffer{}
+	s := slogjson.Sink(b, slogjson.WithNumericLevel(slogjson.SyslogSeverity, ""), slogjson.WithoutCaller(), slogjson.WithoutFunc())
+	s.LogEntry(bg, slog.SinkEntry{Level: slog.LevelWarn})
+	assert.Equal(t, "entry", `{"level":4,"msg":""}`+"\n", entryjson.Filter(b.String(), "ts"))
+
+	b.Reset()
+	s = slogjson.Sink(b, slogjson.WithNumericLevel(slogjson.LevelValue, "level_value"), slogjson.WithoutCaller(), slogjson.WithoutFunc())
+	s.LogEntry(bg, slog.SinkEntry{Level: slog.LevelError})
+	assert.Equal(t, "entry", `{"level":"ERROR","level_value":30,"msg":""}`+"\n", entryjson.Filter(b.String(), "ts"))
+}
+
+func TestWithCallerFormat(t *testing.T) {
+	t.Parallel()
+
+	b := &bytes.Buffer{}
+	s := slogjson.Sink(b, slogjson.WithCallerFormat(slogjson.CallerShort), slogjson.WithoutFunc())
+	s.LogEntry(bg, slog.SinkEntry{File: slogjsonTestFile, Line: 42})
+	assert.Equal(t, "entry", `{"level":"DEBUG","msg":"","caller":"slogjson/slogjson_test.go:42"}`+"\n", entryjson.Filter(b.String(), "ts"))
+
+	b.Reset()
+	s = slogjson.Sink(b, slogjson.WithCallerFormat(slogjson.CallerModule), slogjson.WithoutFunc())
+	s.LogEntry(bg, slog.SinkEntry{File: slogjsonTestFile, Line: 42})
+	assert.Equal(t, "entry", `{"level":"DEBUG","msg":"","caller":"sloggers/slogjson/slogjson_test.go:42"}`+"\n", entryjson.Filter(b.String(), "ts"))
+}
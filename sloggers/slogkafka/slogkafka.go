@@ -0,0 +1,208 @@
+// Package slogkafka contains the slogger that publishes entries
+// to a Kafka topic.
+//
+// It is independent of any Kafka client. Adapt the producer of the
+// client of your choice to the Producer interface.
+//
+// Messages are encoded with slogjson by default.
+package slogkafka // import "cdr.dev/slog/sloggers/slogkafka"
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opencensus.io/trace"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/sloggers/slogbatch"
+	"cdr.dev/slog/sloggers/slogjson"
+)
+
+// Message is a Kafka message.
+type Message struct {
+	Topic string
+	Key   []byte
+	Value []byte
+	Time  time.Time
+}
+
+// Producer publishes messages to Kafka.
+//
+// Produce must return once all messages have been acknowledged
+// according to the acks setting of the producer, or with an error.
+// For example, a sarama.SyncProducer can implement it with
+// SendMessages and a kafka-go Writer with WriteMessages.
+type Producer interface {
+	Produce(ctx context.Context, msgs []Message) error
+}
+
+// Delivery determines when entries are produced.
+type Delivery int
+
+const (
+	// DeliveryBatched produces entries in batches in the background.
+	// Entries that have not been produced yet are lost if the
+	// process crashes.
+	DeliveryBatched Delivery = iota
+
+	// DeliverySync produces every entry before LogEntry returns
+	// so that logging blocks until Kafka has acknowledged it.
+	DeliverySync
+)
+
+// Options represents the options for the sink returned by Sink.
+type Options struct {
+	// Topic is the topic of every message.
+	Topic string
+
+	// Key returns the key of an entry's message. Messages with
+	// the same key are written to the same partition and so stay
+	// in order. See KeyComponent and KeyTraceID.
+	//
+	// Defaults to no key so that the producer balances messages
+	// across partitions.
+	Key func(ent slog.SinkEntry) []byte
+
+	// JSON customizes the encoding of messages.
+	JSON []slogjson.Option
+
+	// Delivery defaults to DeliveryBatched.
+	Delivery Delivery
+
+	// MaxRetries is the maximum number of retries of a failed Produce.
+	//
+	// Defaults to 3.
+	MaxRetries int
+
+	// Backoff is the delay before the first retry. It doubles
+	// for every following retry.
+	//
+	// Defaults to 500ms.
+	Backoff time.Duration
+
+	// Batch configures the batching of entries with DeliveryBatched.
+	// See slogbatch.Options.
+	Batch *slogbatch.Options
+}
+
+// Sink creates a slog.Sink that publishes entries with p.
+//
+// The returned sink implements io.Closer with DeliveryBatched.
+// Close produces the remaining entries. The producer is not closed.
+func Sink(p Producer, opts *Options) (slog.Sink, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	if opts.Topic == "" {
+		return nil, xerrors.New("topic is required")
+	}
+
+	s := &kafkaSink{
+		p:          p,
+		topic:      opts.Topic,
+		key:        opts.Key,
+		maxRetries: opts.MaxRetries,
+		backoff:    opts.Backoff,
+	}
+	if s.maxRetries <= 0 {
+		s.maxRetries = 3
+	}
+	if s.backoff <= 0 {
+		s.backoff = 500 * time.Millisecond
+	}
+	s.enc = slogjson.Sink(&s.buf, opts.JSON...)
+
+	switch opts.Delivery {
+	case DeliveryBatched:
+		return slogbatch.Sink(s, opts.Batch), nil
+	case DeliverySync:
+		return s, nil
+	default:
+		return nil, xerrors.Errorf("unknown delivery %v", opts.Delivery)
+	}
+}
+
+// KeyComponent keys messages by the dotted logger names of the entry.
+func KeyComponent(ent slog.SinkEntry) []byte {
+	if len(ent.LoggerNames) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(ent.LoggerNames, "."))
+}
+
+// KeyTraceID keys messages by the trace ID of the entry so that
+// the entries of a trace are consumed in order. Entries without
+// a trace have no key.
+func KeyTraceID(ent slog.SinkEntry) []byte {
+	if ent.SpanContext == (trace.SpanContext{}) {
+		return nil
+	}
+	return []byte(ent.SpanContext.TraceID.String())
+}
+
+type kafkaSink struct {
+	p          Producer
+	topic      string
+	key        func(ent slog.SinkEntry) []byte
+	maxRetries int
+	backoff    time.Duration
+
+	mu  sync.Mutex
+	enc slog.Sink
+	buf bytes.Buffer
+}
+
+func (s *kafkaSink) LogEntry(ctx context.Context, ent slog.SinkEntry) {
+	s.LogEntries(ctx, []slog.SinkEntry{ent})
+}
+
+func (s *kafkaSink) LogEntries(ctx context.Context, ents []slog.SinkEntry) {
+	msgs := make([]Message, len(ents))
+	for i, ent := range ents {
+		msgs[i] = Message{
+			Topic: s.topic,
+			Value: s.encode(ent),
+			Time:  ent.Time,
+		}
+		if s.key != nil {
+			msgs[i].Key = s.key(ent)
+		}
+	}
+
+	backoff := s.backoff
+	for i := 0; ; i++ {
+		err := s.p.Produce(ctx, msgs)
+		if err == nil {
+			return
+		}
+		if i == s.maxRetries || ctx.Err() != nil {
+			slog.ReportError(ctx, xerrors.Errorf("slogkafka: failed to produce %v entries: %w", len(ents), err), ents...)
+			return
+		}
+
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			slog.ReportError(ctx, xerrors.Errorf("slogkafka: failed to produce %v entries: %v: %w", len(ents), err, ctx.Err()), ents...)
+			return
+		case <-t.C:
+		}
+		backoff *= 2
+	}
+}
+
+func (s *kafkaSink) encode(ent slog.SinkEntry) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.buf.Reset()
+	s.enc.LogEntry(context.Background(), ent)
+	return bytes.TrimSuffix(append([]byte(nil), s.buf.Bytes()...), []byte("\n"))
+}
+
+func (s *kafkaSink) Sync() {}
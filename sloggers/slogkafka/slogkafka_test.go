@@ -0,0 +1,114 @@
+package slogkafka_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opencensus.io/trace"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+	"cdr.dev/slog/sloggers/slogkafka"
+)
+
+var bg = context.Background()
+
+var kt = time.Date(2000, time.February, 5, 4, 4, 4, 123456789, time.UTC)
+
+type fakeProducer struct {
+	mu    sync.Mutex
+	fail  int
+	calls int
+	msgs  []slogkafka.Message
+}
+
+func (p *fakeProducer) Produce(ctx context.Context, msgs []slogkafka.Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.calls++
+	if p.calls <= p.fail {
+		return errors.New("broker unavailable")
+	}
+	p.msgs = append(p.msgs, msgs...)
+	return nil
+}
+
+func TestSink_sync(t *testing.T) {
+	t.Parallel()
+
+	p := &fakeProducer{fail: 1}
+	s, err := slogkafka.Sink(p, &slogkafka.Options{
+		Topic:    "logs",
+		Key:      slogkafka.KeyComponent,
+		Delivery: slogkafka.DeliverySync,
+		Backoff:  time.Millisecond,
+	})
+	assert.Success(t, "sink", err)
+
+	s.LogEntry(bg, slog.SinkEntry{
+		Time:        kt,
+		Level:       slog.LevelInfo,
+		LoggerNames: []string{"comp", "db"},
+		Message:     "hi",
+	})
+
+	assert.Equal(t, "calls", 2, p.calls)
+	assert.Len(t, "messages", 1, p.msgs)
+	msg := p.msgs[0]
+	assert.Equal(t, "topic", "logs", msg.Topic)
+	assert.Equal(t, "key", "comp.db", string(msg.Key))
+	assert.Equal(t, "time", kt, msg.Time)
+	assert.Equal(t, "value", `{"ts":"2000-02-05T04:04:04.123456789Z","level":"INFO","msg":"hi","caller":":0","func":"","logger_names":["comp","db"]}`, string(msg.Value))
+}
+
+func TestSink_canceledBackoff(t *testing.T) {
+	t.Parallel()
+
+	p := &fakeProducer{fail: 10}
+	s, err := slogkafka.Sink(p, &slogkafka.Options{
+		Topic:    "logs",
+		Delivery: slogkafka.DeliverySync,
+		Backoff:  time.Hour,
+	})
+	assert.Success(t, "sink", err)
+
+	ctx, cancel := context.WithTimeout(bg, time.Millisecond*10)
+	defer cancel()
+	s.LogEntry(ctx, slog.SinkEntry{Time: kt, Message: "hi"})
+
+	assert.Equal(t, "calls", 1, p.calls)
+	assert.Len(t, "messages", 0, p.msgs)
+}
+
+func TestSink_batched(t *testing.T) {
+	t.Parallel()
+
+	p := &fakeProducer{}
+	s, err := slogkafka.Sink(p, &slogkafka.Options{
+		Topic: "logs",
+		Key:   slogkafka.KeyTraceID,
+	})
+	assert.Success(t, "sink", err)
+
+	sc := trace.SpanContext{TraceID: trace.TraceID{1}}
+	s.LogEntry(bg, slog.SinkEntry{Time: kt, Message: "one", SpanContext: sc})
+	s.LogEntry(bg, slog.SinkEntry{Time: kt, Message: "two"})
+	err = s.(interface{ Close() error }).Close()
+	assert.Success(t, "close", err)
+
+	assert.Equal(t, "calls", 1, p.calls)
+	assert.Len(t, "messages", 2, p.msgs)
+	assert.Equal(t, "key", sc.TraceID.String(), string(p.msgs[0].Key))
+	assert.Equal(t, "key", []byte(nil), p.msgs[1].Key)
+}
+
+func TestSink_noTopic(t *testing.T) {
+	t.Parallel()
+
+	_, err := slogkafka.Sink(&fakeProducer{}, nil)
+	assert.Error(t, "sink", err)
+}
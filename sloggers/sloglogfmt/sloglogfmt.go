@@ -0,0 +1,108 @@
+// Package sloglogfmt contains the slogger that writes logs in logfmt.
+//
+// Format
+//
+//	ts=2019-09-10T20:19:07.159852Z level=info logger=comp.subcomp msg=hi caller=slog/examples_test.go:62 func=cdr.dev/slog/sloggers/slogtest_test.TestExampleTest trace=<traceid> span=<spanid> my_field="field value" nested.key=1
+//
+// Nested fields are flattened into dotted keys and arrays
+// are encoded as JSON.
+//
+// See https://brandur.org/logfmt
+package sloglogfmt // import "cdr.dev/slog/sloggers/sloglogfmt"
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"go.opencensus.io/trace"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/entryjson"
+	"cdr.dev/slog/internal/syncwriter"
+)
+
+// Sink creates a slog.Sink that writes logfmt formatted logs
+// to the given writer.
+//
+// If the writer implements Sync() error then
+// it will be called when syncing.
+func Sink(w io.Writer) slog.Sink {
+	return logfmtSink{
+		w: syncwriter.New(w),
+	}
+}
+
+type logfmtSink struct {
+	w *syncwriter.Writer
+}
+
+func (s logfmtSink) LogEntry(ctx context.Context, ent slog.SinkEntry) {
+	b := make([]byte, 0, 256)
+	b = appendPair(b, "ts", ent.Time.Format(time.RFC3339Nano))
+	b = appendPair(b, "level", strings.ToLower(ent.Level.String()))
+	if len(ent.LoggerNames) > 0 {
+		b = appendPair(b, "logger", strings.Join(ent.LoggerNames, "."))
+	}
+	b = appendPair(b, "msg", ent.Message)
+	b = appendPair(b, "caller", ent.File+":"+strconv.Itoa(ent.Line))
+	b = appendPair(b, "func", ent.Func)
+	if ent.SpanContext != (trace.SpanContext{}) {
+		b = appendPair(b, "trace", ent.SpanContext.TraceID.String())
+		b = appendPair(b, "span", ent.SpanContext.SpanID.String())
+	}
+
+	for _, f := range entryjson.Flatten(ent.Fields, ".") {
+		b = appendPair(b, f.Key, entryjson.String(f.Value))
+	}
+
+	b[len(b)-1] = '\n'
+	err := s.w.Write(ctx, "sloglogfmt", b)
+	if err != nil {
+		slog.ReportError(ctx, err, ent)
+	}
+}
+
+func (s logfmtSink) Sync() {
+	s.w.Sync("sloglogfmt")
+}
+
+// appendPair appends key=val followed by a space.
+func appendPair(b []byte, key, val string) []byte {
+	b = append(b, sanitizeKey(key)...)
+	b = append(b, '=')
+	b = append(b, quote(val)...)
+	return append(b, ' ')
+}
+
+// sanitizeKey replaces the characters that cannot be
+// in a logfmt key with underscores.
+func sanitizeKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || !unicode.IsPrint(r) {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+// quote quotes val if it is empty or contains spaces,
+// quotes, equal signs or unprintable characters.
+func quote(val string) string {
+	if val == "" {
+		return `""`
+	}
+	for _, r := range val {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == utf8.RuneError || !unicode.IsPrint(r) {
+			return strconv.Quote(val)
+		}
+	}
+	return val
+}
@@ -0,0 +1,51 @@
+package sloglogfmt_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"go.opencensus.io/trace"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+	"cdr.dev/slog/sloggers/sloglogfmt"
+)
+
+var bg = context.Background()
+
+func TestSink(t *testing.T) {
+	t.Parallel()
+
+	b := &bytes.Buffer{}
+	s := sloglogfmt.Sink(b)
+	s.LogEntry(bg, slog.SinkEntry{
+		Time:        time.Date(2000, time.February, 5, 4, 4, 4, 0, time.UTC),
+		Level:       slog.LevelWarn,
+		LoggerNames: []string{"comp", "db"},
+		Message:     "query failed",
+		File:        "db.go",
+		Line:        42,
+		Func:        "main.query",
+		SpanContext: trace.SpanContext{
+			TraceID: trace.TraceID{1},
+			SpanID:  trace.SpanID{2},
+		},
+		Fields: slog.M(
+			slog.F("query", `SELECT "a" FROM b`),
+			slog.F("rows", 3),
+			slog.F("ok", false),
+			slog.F("empty", ""),
+			slog.F("req", slog.M(
+				slog.F("id", "abc"),
+				slog.F("bad key", nil),
+			)),
+			slog.F("tags", []string{"a", "b"}),
+		),
+	})
+
+	assert.Equal(t, "entry", `ts=2000-02-05T04:04:04Z level=warn logger=comp.db msg="query failed" caller=db.go:42 func=main.query `+
+		`trace=01000000000000000000000000000000 span=0200000000000000 `+
+		`query="SELECT \"a\" FROM b" rows=3 ok=false empty="" req.id=abc req.bad_key=null tags="[\"a\",\"b\"]"`+"\n", b.String())
+}
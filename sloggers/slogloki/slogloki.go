@@ -0,0 +1,230 @@
+// Package slogloki contains the slogger that pushes entries
+// to Grafana Loki.
+//
+// Every entry is a line in the stream of its labels. The line
+// contains the remaining keys of slogjson:
+//
+//	{
+//	  "msg": "hi",
+//	  "caller": "slog/examples_test.go:62",
+//	  "func": "cdr.dev/slog/sloggers/slogtest_test.TestExampleTest",
+//	  "trace": "<traceid>",
+//	  "span": "<spanid>",
+//	  "fields": {
+//	    "my_field": "field value"
+//	  }
+//	}
+//
+// See https://grafana.com/docs/loki/latest/reference/loki-http-api/#ingest-logs
+package slogloki // import "cdr.dev/slog/sloggers/slogloki"
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opencensus.io/trace"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/httpretry"
+	"cdr.dev/slog/sloggers/slogbatch"
+)
+
+// Options represents the options for the sink returned by Sink.
+type Options struct {
+	// URL is the base URL of Loki, e.g. http://loki:3100.
+	URL string
+
+	// Header is added to every request, e.g. Authorization
+	// or X-Scope-OrgID for multi tenant deployments.
+	Header http.Header
+
+	// Labels are added to every stream, e.g. job or env.
+	Labels map[string]string
+
+	// LevelLabel is the name of the label with the lowercase
+	// level of the entry. Set it to "-" to omit the label.
+	//
+	// Defaults to "level".
+	LevelLabel string
+
+	// ComponentLabel is the name of the label with the dotted
+	// logger names of the entry. Set it to "-" to omit the label.
+	//
+	// Defaults to "component".
+	ComponentLabel string
+
+	// Client defaults to a client with a 30 second timeout.
+	Client *http.Client
+
+	// MaxRetries is the maximum number of retries of a push
+	// that fails with a network error, 429 or 5xx.
+	//
+	// Defaults to 3.
+	MaxRetries int
+
+	// Backoff is the delay before the first retry. It doubles
+	// for every following retry.
+	//
+	// Defaults to 500ms.
+	Backoff time.Duration
+
+	// Batch configures the batching of entries.
+	// See slogbatch.Options.
+	Batch *slogbatch.Options
+}
+
+// Sink creates a slog.Sink that pushes batches of entries to Loki.
+//
+// The returned sink implements io.Closer. Close pushes the
+// remaining entries.
+func Sink(opts *Options) (slog.Sink, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to parse URL: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, xerrors.Errorf("invalid URL %q", opts.URL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/loki/api/v1/push"
+
+	s := &lokiSink{
+		url:            u.String(),
+		labels:         opts.Labels,
+		levelLabel:     opts.LevelLabel,
+		componentLabel: opts.ComponentLabel,
+		opts: httpretry.Options{
+			Client:     opts.Client,
+			MaxRetries: opts.MaxRetries,
+			Backoff:    opts.Backoff,
+		},
+		header: http.Header{
+			"Content-Type": []string{"application/json"},
+		},
+	}
+	for k, v := range opts.Header {
+		s.header[k] = v
+	}
+	if s.levelLabel == "" {
+		s.levelLabel = "level"
+	}
+	if s.componentLabel == "" {
+		s.componentLabel = "component"
+	}
+	return slogbatch.Sink(s, opts.Batch), nil
+}
+
+type lokiSink struct {
+	url            string
+	header         http.Header
+	labels         map[string]string
+	levelLabel     string
+	componentLabel string
+	opts           httpretry.Options
+}
+
+type pushRequest struct {
+	Streams []*stream `json:"streams"`
+}
+
+type stream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+func (s *lokiSink) LogEntry(ctx context.Context, ent slog.SinkEntry) {
+	s.LogEntries(ctx, []slog.SinkEntry{ent})
+}
+
+func (s *lokiSink) LogEntries(ctx context.Context, ents []slog.SinkEntry) {
+	var req pushRequest
+	streams := make(map[string]*stream)
+	for _, ent := range ents {
+		labels := s.labelsOf(ent)
+		key := labelsKey(labels)
+		st, ok := streams[key]
+		if !ok {
+			st = &stream{Stream: labels}
+			streams[key] = st
+			req.Streams = append(req.Streams, st)
+		}
+		st.Values = append(st.Values, [2]string{
+			strconv.FormatInt(ent.Time.UnixNano(), 10),
+			line(ent),
+		})
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		slog.ReportError(ctx, xerrors.Errorf("slogloki: failed to marshal push request: %w", err), ents...)
+		return
+	}
+	_, err = httpretry.Do(ctx, s.opts, http.MethodPost, s.url, s.header, body)
+	if err != nil {
+		slog.ReportError(ctx, xerrors.Errorf("slogloki: failed to push %v entries: %w", len(ents), err), ents...)
+	}
+}
+
+func (s *lokiSink) labelsOf(ent slog.SinkEntry) map[string]string {
+	labels := make(map[string]string, len(s.labels)+2)
+	for k, v := range s.labels {
+		labels[k] = v
+	}
+	if s.levelLabel != "-" {
+		labels[s.levelLabel] = strings.ToLower(ent.Level.String())
+	}
+	if s.componentLabel != "-" && len(ent.LoggerNames) > 0 {
+		labels[s.componentLabel] = strings.Join(ent.LoggerNames, ".")
+	}
+	return labels
+}
+
+// labelsKey returns a key that identifies the stream of labels.
+func labelsKey(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for _, k := range keys {
+		sb.WriteString(strconv.Quote(k))
+		sb.WriteByte('=')
+		sb.WriteString(strconv.Quote(labels[k]))
+		sb.WriteByte(',')
+	}
+	return sb.String()
+}
+
+func line(ent slog.SinkEntry) string {
+	m := slog.M(
+		slog.F("msg", ent.Message),
+		slog.F("caller", ent.File+":"+strconv.Itoa(ent.Line)),
+		slog.F("func", ent.Func),
+	)
+	if ent.SpanContext != (trace.SpanContext{}) {
+		m = append(m,
+			slog.F("trace", ent.SpanContext.TraceID.String()),
+			slog.F("span", ent.SpanContext.SpanID.String()),
+		)
+	}
+	if len(ent.Fields) > 0 {
+		m = append(m, slog.F("fields", ent.Fields))
+	}
+
+	// No error is guaranteed due to slog.Map handling errors itself.
+	b, _ := m.MarshalJSON()
+	return string(b)
+}
+
+func (s *lokiSink) Sync() {}
@@ -0,0 +1,109 @@
+package slogloki_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+	"cdr.dev/slog/sloggers/slogloki"
+)
+
+var bg = context.Background()
+
+var kt = time.Date(2000, time.February, 5, 4, 4, 4, 123456789, time.UTC)
+
+func TestSink(t *testing.T) {
+	t.Parallel()
+
+	bodies := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "path", "/loki/api/v1/push", r.URL.Path)
+		assert.Equal(t, "tenant", "team", r.Header.Get("X-Scope-OrgID"))
+		b, err := ioutil.ReadAll(r.Body)
+		assert.Success(t, "read body", err)
+		bodies <- b
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	s, err := slogloki.Sink(&slogloki.Options{
+		URL: srv.URL,
+		Header: http.Header{
+			"X-Scope-Orgid": []string{"team"},
+		},
+		Labels: map[string]string{"job": "test"},
+	})
+	assert.Success(t, "sink", err)
+
+	l := slog.Make(s)
+	l.Info(bg, "one", slog.F("id", 1))
+	l.Named("db").Info(bg, "two")
+	l.Info(bg, "three")
+	s.LogEntry(bg, slog.SinkEntry{
+		Time:    kt,
+		Level:   slog.LevelWarn,
+		Message: "four",
+		File:    "main.go",
+		Line:    62,
+		Func:    "main.main",
+	})
+	s.Sync()
+
+	var req struct {
+		Streams []struct {
+			Stream map[string]string `json:"stream"`
+			Values [][2]string       `json:"values"`
+		} `json:"streams"`
+	}
+	err = json.Unmarshal(<-bodies, &req)
+	assert.Success(t, "unmarshal", err)
+
+	assert.Len(t, "streams", 3, req.Streams)
+	assert.Equal(t, "labels", map[string]string{"job": "test", "level": "info"}, req.Streams[0].Stream)
+	assert.Len(t, "values", 2, req.Streams[0].Values)
+	assert.Equal(t, "labels", map[string]string{"job": "test", "level": "info", "component": "db"}, req.Streams[1].Stream)
+	assert.Equal(t, "labels", map[string]string{"job": "test", "level": "warn"}, req.Streams[2].Stream)
+	assert.Equal(t, "value", [2]string{
+		"949723444123456789",
+		`{"msg":"four","caller":"main.go:62","func":"main.main"}`,
+	}, req.Streams[2].Values[0])
+}
+
+func TestSink_retry(t *testing.T) {
+	t.Parallel()
+
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	s, err := slogloki.Sink(&slogloki.Options{
+		URL:     srv.URL,
+		Backoff: time.Millisecond,
+	})
+	assert.Success(t, "sink", err)
+
+	s.LogEntry(bg, slog.SinkEntry{Time: kt, Message: "hi"})
+	err = s.(interface{ Close() error }).Close()
+	assert.Success(t, "close", err)
+	assert.Equal(t, "requests", int32(2), atomic.LoadInt32(&requests))
+}
+
+func TestSink_invalidURL(t *testing.T) {
+	t.Parallel()
+
+	_, err := slogloki.Sink(&slogloki.Options{URL: "loki:3100"})
+	assert.Error(t, "sink", err)
+}
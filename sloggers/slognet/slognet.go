@@ -0,0 +1,190 @@
+// Package slognet contains the slogger that writes JSON entries
+// to a network connection, e.g. the tcp input of Logstash with
+// the json_lines codec.
+//
+// Unix domain sockets are supported as well to feed local log
+// shippers like vector or fluent-bit without touching disk.
+//
+// Entries are encoded with slogjson and framed by a newline or
+// a length prefix.
+package slognet // import "cdr.dev/slog/sloggers/slognet"
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/netqueue"
+	"cdr.dev/slog/sloggers/slogjson"
+)
+
+// Framing determines how entries are delimited.
+type Framing int
+
+// The supported framings.
+const (
+	// FramingNewline terminates every entry with a newline.
+	FramingNewline Framing = iota
+	// FramingLengthPrefix prefixes every entry with its
+	// length as a 4 byte big endian integer.
+	FramingLengthPrefix
+)
+
+// Options represents the options for the sink returned by Sink.
+type Options struct {
+	// Network is "tcp", "udp", "unix", "unixgram" or
+	// one of their variants like "tcp4".
+	//
+	// Defaults to "tcp".
+	Network string
+
+	// Addr is the address of the receiver, e.g. logstash:5000
+	// or the path of a Unix socket like /run/vector.sock.
+	Addr string
+
+	// TLSConfig enables TLS over TCP.
+	TLSConfig *tls.Config
+
+	// Framing defaults to FramingNewline. Over datagram networks
+	// like "udp" and "unixgram" every entry is sent in its own
+	// datagram.
+	Framing Framing
+
+	// JSON customizes the encoding of entries.
+	JSON []slogjson.Option
+
+	// BufferSize is the maximum number of entries buffered while
+	// the sink is disconnected. The oldest entries are dropped
+	// once it is full.
+	//
+	// Defaults to 1000.
+	BufferSize int
+
+	// MinBackoff is the delay before the first reconnect.
+	// It doubles for every following reconnect up to MaxBackoff.
+	//
+	// Defaults to 100ms.
+	MinBackoff time.Duration
+
+	// MaxBackoff defaults to 30 seconds.
+	MaxBackoff time.Duration
+
+	// DialTimeout defaults to 5 seconds.
+	DialTimeout time.Duration
+
+	// WriteTimeout is the maximum time a write may take before the
+	// sink reconnects, e.g. when the receiver stopped reading.
+	//
+	// Defaults to 10 seconds.
+	WriteTimeout time.Duration
+}
+
+// Sink creates a slog.Sink that writes entries to a connection.
+//
+// The sink connects and writes in the background so that logging never
+// blocks on the network. It reconnects with exponential backoff when a
+// write fails and buffers entries in the meantime. Sync waits until the
+// buffered entries have been written or the sink is disconnected.
+//
+// The returned sink implements io.Closer. Close writes the buffered
+// entries if the sink is connected and closes the connection.
+func Sink(opts *Options) (slog.Sink, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	network := opts.Network
+	if network == "" {
+		network = "tcp"
+	}
+	if opts.Addr == "" {
+		return nil, xerrors.New("address is required")
+	}
+	d := &net.Dialer{
+		Timeout: opts.DialTimeout,
+	}
+	if d.Timeout <= 0 {
+		d.Timeout = 5 * time.Second
+	}
+
+	dial := func() (net.Conn, error) {
+		return d.Dial(network, opts.Addr)
+	}
+	if opts.TLSConfig != nil {
+		dial = func() (net.Conn, error) {
+			c, err := tls.DialWithDialer(d, network, opts.Addr, opts.TLSConfig)
+			if err != nil {
+				// Avoid returning a nil *tls.Conn as a non nil net.Conn.
+				return nil, err
+			}
+			return c, nil
+		}
+	}
+	return newSink(dial, opts), nil
+}
+
+func newSink(dial func() (net.Conn, error), opts *Options) *netSink {
+	s := &netSink{
+		framing: opts.Framing,
+		q: netqueue.New(dial, netqueue.Options{
+			Name:         "slognet",
+			BufferSize:   opts.BufferSize,
+			MinBackoff:   opts.MinBackoff,
+			MaxBackoff:   opts.MaxBackoff,
+			WriteTimeout: opts.WriteTimeout,
+		}),
+	}
+	s.enc = slogjson.Sink(&s.buf, opts.JSON...)
+	return s
+}
+
+type netSink struct {
+	framing Framing
+	q       *netqueue.Queue
+
+	mu  sync.Mutex
+	enc slog.Sink
+	buf bytes.Buffer
+}
+
+func (s *netSink) LogEntry(ctx context.Context, ent slog.SinkEntry) {
+	if !s.q.Write(s.frame(ent)) {
+		slog.ReportError(ctx, xerrors.New("slognet: sink is closed"), ent)
+	}
+}
+
+func (s *netSink) frame(ent slog.SinkEntry) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.buf.Reset()
+	s.enc.LogEntry(context.Background(), ent)
+	b := bytes.TrimRight(s.buf.Bytes(), "\n")
+
+	switch s.framing {
+	case FramingLengthPrefix:
+		frame := make([]byte, 4, 4+len(b))
+		binary.BigEndian.PutUint32(frame, uint32(len(b)))
+		return append(frame, b...)
+	default:
+		frame := make([]byte, 0, len(b)+1)
+		frame = append(frame, b...)
+		return append(frame, '\n')
+	}
+}
+
+// Sync waits until the buffered entries have been written
+// or the sink is disconnected.
+func (s *netSink) Sync() {
+	s.q.Sync()
+}
+
+func (s *netSink) Close() error {
+	return s.q.Close()
+}
@@ -0,0 +1,277 @@
+package slognet_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+	"cdr.dev/slog/sloggers/slognet"
+)
+
+var bg = context.Background()
+
+var kt = time.Date(2000, time.February, 5, 4, 4, 4, 123456789, time.UTC)
+
+func TestSink(t *testing.T) {
+	t.Parallel()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Success(t, "listen", err)
+	defer l.Close()
+
+	s, err := slognet.Sink(&slognet.Options{
+		Addr: l.Addr().String(),
+	})
+	assert.Success(t, "sink", err)
+	defer s.(io.Closer).Close()
+
+	s.LogEntry(bg, slog.SinkEntry{
+		Time:    kt,
+		Level:   slog.LevelWarn,
+		Message: "hi",
+		Fields:  slog.M(slog.F("id", 1)),
+	})
+
+	c, err := l.Accept()
+	assert.Success(t, "accept", err)
+	r := bufio.NewReader(c)
+
+	m := readLine(t, r)
+	assert.Equal(t, "msg", "hi", m["msg"])
+	assert.Equal(t, "level", "WARN", m["level"])
+	assert.Equal(t, "fields", map[string]interface{}{"id": 1.0}, m["fields"])
+
+	// The sink reconnects after the receiver drops the connection.
+	c.Close()
+	done := make(chan net.Conn)
+	go func() {
+		c, err := l.Accept()
+		if err == nil {
+			done <- c
+		}
+	}()
+	for i := 0; ; i++ {
+		s.LogEntry(bg, slog.SinkEntry{Time: kt, Message: "again"})
+		select {
+		case c := <-done:
+			defer c.Close()
+			m := readLine(t, bufio.NewReader(c))
+			assert.Equal(t, "msg", "again", m["msg"])
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+		if i > 500 {
+			t.Fatal("sink did not reconnect")
+		}
+	}
+}
+
+func TestSinkBuffer(t *testing.T) {
+	t.Parallel()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Success(t, "listen", err)
+	addr := l.Addr().String()
+	l.Close()
+
+	s, err := slognet.Sink(&slognet.Options{
+		Addr:       addr,
+		Framing:    slognet.FramingLengthPrefix,
+		BufferSize: 2,
+		MinBackoff: 10 * time.Millisecond,
+		MaxBackoff: 10 * time.Millisecond,
+	})
+	assert.Success(t, "sink", err)
+	defer s.(io.Closer).Close()
+
+	s.LogEntry(bg, slog.SinkEntry{Time: kt, Message: "1"})
+	// Sync returns once the sink knows it is disconnected.
+	s.Sync()
+	s.LogEntry(bg, slog.SinkEntry{Time: kt, Message: "2"})
+	s.LogEntry(bg, slog.SinkEntry{Time: kt, Message: "3"})
+
+	l, err = net.Listen("tcp", addr)
+	assert.Success(t, "listen", err)
+	defer l.Close()
+
+	c, err := l.Accept()
+	assert.Success(t, "accept", err)
+	defer c.Close()
+
+	for _, msg := range []string{"2", "3"} {
+		var n uint32
+		err = binary.Read(c, binary.BigEndian, &n)
+		assert.Success(t, "length", err)
+		b := make([]byte, n)
+		_, err = io.ReadFull(c, b)
+		assert.Success(t, "frame", err)
+
+		var m map[string]interface{}
+		err = json.Unmarshal(b, &m)
+		assert.Success(t, "unmarshal", err)
+		assert.Equal(t, "msg", msg, m["msg"])
+	}
+}
+
+func TestSinkWriteTimeout(t *testing.T) {
+	t.Parallel()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Success(t, "listen", err)
+	defer l.Close()
+
+	s, err := slognet.Sink(&slognet.Options{
+		Addr:         l.Addr().String(),
+		WriteTimeout: 50 * time.Millisecond,
+	})
+	assert.Success(t, "sink", err)
+
+	// The receiver never reads so the writes block
+	// once the socket buffers are full.
+	msg := strings.Repeat("x", 1<<20)
+	for i := 0; i < 32; i++ {
+		s.LogEntry(bg, slog.SinkEntry{Time: kt, Message: msg})
+	}
+
+	closed := make(chan error)
+	go func() {
+		closed <- s.(io.Closer).Close()
+	}()
+	select {
+	case err := <-closed:
+		assert.Success(t, "close", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("close hung on a receiver that stopped reading")
+	}
+}
+
+func TestSinkUDP(t *testing.T) {
+	t.Parallel()
+
+	c, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Success(t, "listen", err)
+	defer c.Close()
+
+	s, err := slognet.Sink(&slognet.Options{
+		Network: "udp",
+		Addr:    c.LocalAddr().String(),
+	})
+	assert.Success(t, "sink", err)
+
+	s.LogEntry(bg, slog.SinkEntry{
+		Time:    kt,
+		Message: "hi",
+		File:    "main.go",
+		Line:    62,
+		Func:    "main.main",
+	})
+	err = s.(io.Closer).Close()
+	assert.Success(t, "close", err)
+
+	b := make([]byte, 1024)
+	n, _, err := c.ReadFrom(b)
+	assert.Success(t, "read", err)
+	assert.Equal(t, "datagram", `{"ts":"2000-02-05T04:04:04.123456789Z","level":"DEBUG","msg":"hi","caller":"main.go:62","func":"main.main"}`+"\n", string(b[:n]))
+}
+
+func readLine(t *testing.T, r *bufio.Reader) map[string]interface{} {
+	t.Helper()
+
+	line, err := r.ReadBytes('\n')
+	assert.Success(t, "read", err)
+
+	var m map[string]interface{}
+	err = json.Unmarshal(line, &m)
+	assert.Success(t, "unmarshal", err)
+	return m
+}
+
+func TestSinkUnix(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "slognet")
+	assert.Success(t, "tempdir", err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "stream.sock")
+
+	l, err := net.Listen("unix", path)
+	assert.Success(t, "listen", err)
+	defer l.Close()
+
+	s, err := slognet.Sink(&slognet.Options{
+		Network: "unix",
+		Addr:    path,
+	})
+	assert.Success(t, "sink", err)
+	defer s.(io.Closer).Close()
+
+	s.LogEntry(bg, slog.SinkEntry{Time: kt, Message: "hi"})
+
+	c, err := l.Accept()
+	assert.Success(t, "accept", err)
+	defer c.Close()
+
+	m := readLine(t, bufio.NewReader(c))
+	assert.Equal(t, "msg", "hi", m["msg"])
+}
+
+func TestSinkUnixgram(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "slognet")
+	assert.Success(t, "tempdir", err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "dgram.sock")
+
+	c, err := net.ListenPacket("unixgram", path)
+	assert.Success(t, "listen", err)
+
+	s, err := slognet.Sink(&slognet.Options{
+		Network:    "unixgram",
+		Addr:       path,
+		MinBackoff: 10 * time.Millisecond,
+		MaxBackoff: 10 * time.Millisecond,
+	})
+	assert.Success(t, "sink", err)
+	defer s.(io.Closer).Close()
+
+	b := make([]byte, 1024)
+	read := func(c net.PacketConn) map[string]interface{} {
+		n, _, err := c.ReadFrom(b)
+		assert.Success(t, "read", err)
+
+		var m map[string]interface{}
+		err = json.Unmarshal(b[:n], &m)
+		assert.Success(t, "unmarshal", err)
+		return m
+	}
+
+	s.LogEntry(bg, slog.SinkEntry{Time: kt, Message: "1"})
+	assert.Equal(t, "msg", "1", read(c)["msg"])
+
+	// The sink reconnects when the receiver recreates its socket.
+	c.Close()
+	os.Remove(path)
+	c, err = net.ListenPacket("unixgram", path)
+	assert.Success(t, "listen", err)
+	defer c.Close()
+
+	s.LogEntry(bg, slog.SinkEntry{Time: kt, Message: "2"})
+	s.LogEntry(bg, slog.SinkEntry{Time: kt, Message: "3"})
+	msg := read(c)["msg"]
+	if msg == "2" {
+		msg = read(c)["msg"]
+	}
+	assert.Equal(t, "msg", "3", msg)
+}
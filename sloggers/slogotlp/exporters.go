@@ -0,0 +1,147 @@
+package slogotlp
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"cdr.dev/slog/internal/httpretry"
+)
+
+type httpExporter struct {
+	url    string
+	header http.Header
+	opts   httpretry.Options
+}
+
+func newHTTPExporter(opts *Options) (exporter, error) {
+	endpoint := opts.Endpoint
+	if endpoint == "" {
+		endpoint = "http://localhost:4318"
+	}
+	e := &httpExporter{
+		url: strings.TrimSuffix(endpoint, "/") + "/v1/logs",
+		header: http.Header{
+			"Content-Type": []string{"application/x-protobuf"},
+		},
+		opts: httpretry.Options{
+			Client:     opts.Client,
+			MaxRetries: opts.MaxRetries,
+			Backoff:    opts.Backoff,
+		},
+	}
+	for k, v := range opts.Headers {
+		e.header.Set(k, v)
+	}
+	return e, nil
+}
+
+func (e *httpExporter) export(ctx context.Context, req []byte) ([]byte, error) {
+	return httpretry.Do(ctx, e.opts, http.MethodPost, e.url, e.header, req)
+}
+
+func (e *httpExporter) close() error {
+	return nil
+}
+
+type grpcExporter struct {
+	conn       *grpc.ClientConn
+	md         metadata.MD
+	maxRetries int
+	backoff    time.Duration
+}
+
+func newGRPCExporter(opts *Options) (exporter, error) {
+	endpoint := opts.Endpoint
+	if endpoint == "" {
+		endpoint = "localhost:4317"
+	}
+	creds := grpc.WithInsecure()
+	if opts.TLSConfig != nil {
+		creds = grpc.WithTransportCredentials(credentials.NewTLS(opts.TLSConfig))
+	}
+	// Dialing does not block so the collector may start later.
+	conn, err := grpc.Dial(endpoint, creds)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to dial %v: %w", endpoint, err)
+	}
+
+	e := &grpcExporter{
+		conn:       conn,
+		md:         metadata.New(opts.Headers),
+		maxRetries: opts.MaxRetries,
+		backoff:    opts.Backoff,
+	}
+	if e.maxRetries <= 0 {
+		e.maxRetries = 3
+	}
+	if e.backoff <= 0 {
+		e.backoff = 500 * time.Millisecond
+	}
+	return e, nil
+}
+
+// rawCodec passes encoded messages through as is.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	return v.([]byte), nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	*v.(*[]byte) = append([]byte(nil), data...)
+	return nil
+}
+
+func (rawCodec) Name() string {
+	return "proto"
+}
+
+func (e *grpcExporter) export(ctx context.Context, req []byte) ([]byte, error) {
+	ctx = metadata.NewOutgoingContext(ctx, e.md)
+
+	backoff := e.backoff
+	for i := 0; ; i++ {
+		var resp []byte
+		err := e.conn.Invoke(ctx, "/opentelemetry.proto.collector.logs.v1.LogsService/Export", req, &resp, grpc.ForceCodec(rawCodec{}))
+		if err == nil {
+			return resp, nil
+		}
+		if i == e.maxRetries || !retryable(status.Code(err)) {
+			return nil, err
+		}
+
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, err
+		case <-t.C:
+		}
+		backoff *= 2
+	}
+}
+
+// retryable reports whether an export that failed with c
+// should be retried as specified by OTLP.
+func retryable(c codes.Code) bool {
+	switch c {
+	case codes.Canceled, codes.DeadlineExceeded, codes.Aborted,
+		codes.OutOfRange, codes.Unavailable, codes.DataLoss,
+		codes.ResourceExhausted:
+		return true
+	}
+	return false
+}
+
+func (e *grpcExporter) close() error {
+	return e.conn.Close()
+}
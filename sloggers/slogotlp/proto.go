@@ -0,0 +1,149 @@
+package slogotlp
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+
+	"golang.org/x/xerrors"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"cdr.dev/slog/internal/entryjson"
+)
+
+// The messages of opentelemetry/proto/logs/v1/logs.proto are encoded
+// by hand to avoid depending on the generated code of the OpenTelemetry
+// protos. Every function appends a field with the given number.
+//
+// See https://github.com/open-telemetry/opentelemetry-proto/blob/main/opentelemetry/proto/logs/v1/logs.proto
+
+func appendMessage(b []byte, num protowire.Number, msg []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
+
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func appendBytes(b []byte, num protowire.Number, v []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+func appendVarint(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func appendFixed64(b []byte, num protowire.Number, v uint64) []byte {
+	b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, v)
+}
+
+// appendKeyValue appends a KeyValue with a value decoded by entryjson.
+func appendKeyValue(b []byte, num protowire.Number, key string, v interface{}) []byte {
+	var kv []byte
+	kv = appendString(kv, 1, key)
+	kv = appendMessage(kv, 2, anyValue(v))
+	return appendMessage(b, num, kv)
+}
+
+// anyValue encodes a value decoded by entryjson as an AnyValue.
+func anyValue(v interface{}) []byte {
+	var b []byte
+	switch v := v.(type) {
+	case string:
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendString(b, v)
+	case bool:
+		b = protowire.AppendTag(b, 2, protowire.VarintType)
+		b = protowire.AppendVarint(b, protowire.EncodeBool(v))
+	case json.Number:
+		if i, err := strconv.ParseInt(v.String(), 10, 64); err == nil {
+			b = protowire.AppendTag(b, 3, protowire.VarintType)
+			b = protowire.AppendVarint(b, uint64(i))
+			break
+		}
+		f, _ := v.Float64()
+		b = appendDouble(b, 4, f)
+	case []interface{}:
+		var arr []byte
+		for _, el := range v {
+			arr = appendMessage(arr, 1, anyValue(el))
+		}
+		b = appendMessage(b, 5, arr)
+	case entryjson.Object:
+		var kvs []byte
+		for _, f := range v {
+			kvs = appendKeyValue(kvs, 1, f.Key, f.Value)
+		}
+		b = appendMessage(b, 6, kvs)
+	}
+	// null is an empty AnyValue.
+	return b
+}
+
+func appendDouble(b []byte, num protowire.Number, f float64) []byte {
+	b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, math.Float64bits(f))
+}
+
+// partialSuccess decodes the partial_success of an ExportLogsServiceResponse.
+func partialSuccess(b []byte) (rejected int64, msg string, err error) {
+	err = consumeFields(b, func(num protowire.Number, typ protowire.Type, v []byte) error {
+		if num != 1 || typ != protowire.BytesType {
+			return nil
+		}
+		return consumeFields(v, func(num protowire.Number, typ protowire.Type, v []byte) error {
+			switch {
+			case num == 1 && typ == protowire.VarintType:
+				n, _ := protowire.ConsumeVarint(v)
+				rejected = int64(n)
+			case num == 2 && typ == protowire.BytesType:
+				msg = string(v)
+			}
+			return nil
+		})
+	})
+	return rejected, msg, err
+}
+
+// consumeFields calls fn with every field of the message b. v is the
+// encoded varint or fixed value or the contents of a bytes field.
+func consumeFields(b []byte, fn func(num protowire.Number, typ protowire.Type, v []byte) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return xerrors.Errorf("invalid tag: %w", protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		var v []byte
+		if typ == protowire.BytesType {
+			v, n = protowire.ConsumeBytes(b)
+		} else {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return xerrors.Errorf("invalid field %v: %w", num, protowire.ParseError(n))
+		}
+		if typ != protowire.BytesType {
+			v = b[:n]
+		}
+		b = b[n:]
+
+		err := fn(num, typ, v)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,257 @@
+// Package slogotlp contains the slogger that exports entries
+// as OpenTelemetry log records to a collector with OTLP.
+//
+// Entries become log records with the message as the body, the
+// level as the severity, the caller as the code.* attributes and
+// the fields as attributes. Nested fields are nested kvlist values.
+// The logger names of an entry are its instrumentation scope and
+// its trace and span correlate it with the trace.
+//
+// See https://opentelemetry.io/docs/specs/otel/logs/data-model/
+package slogotlp // import "cdr.dev/slog/sloggers/slogotlp"
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opencensus.io/trace"
+	"golang.org/x/xerrors"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/entryjson"
+	"cdr.dev/slog/sloggers/slogbatch"
+)
+
+// Protocol is the transport of OTLP.
+type Protocol int
+
+// The supported protocols.
+const (
+	// ProtocolHTTP sends binary protobuf requests over HTTP.
+	ProtocolHTTP Protocol = iota
+	// ProtocolGRPC sends requests over gRPC.
+	ProtocolGRPC
+)
+
+// Options represents the options for the sink returned by Sink.
+type Options struct {
+	// Protocol defaults to ProtocolHTTP.
+	Protocol Protocol
+
+	// Endpoint is the address of the collector.
+	//
+	// With ProtocolHTTP, it is the base URL that /v1/logs is
+	// appended to and defaults to http://localhost:4318.
+	//
+	// With ProtocolGRPC, it is the host and port and
+	// defaults to localhost:4317.
+	Endpoint string
+
+	// TLSConfig enables TLS with ProtocolGRPC. The connection is
+	// insecure if it is nil. With ProtocolHTTP, an https endpoint
+	// enables TLS as configured by the client.
+	TLSConfig *tls.Config
+
+	// Headers are sent with every request, e.g. for authentication.
+	Headers map[string]string
+
+	// ServiceName is the service.name resource attribute.
+	//
+	// Defaults to the name of the executable.
+	ServiceName string
+
+	// Resource holds additional resource attributes,
+	// e.g. deployment.environment.
+	Resource slog.Map
+
+	// Timeout is the timeout of an export.
+	//
+	// Defaults to 10 seconds.
+	Timeout time.Duration
+
+	// Client is the client of ProtocolHTTP.
+	//
+	// Defaults to a client with a 30 second timeout.
+	Client *http.Client
+
+	// MaxRetries is the maximum number of retries of an export
+	// that fails with a network error or a retryable status.
+	//
+	// Defaults to 3.
+	MaxRetries int
+
+	// Backoff is the delay before the first retry. It doubles
+	// for every following retry.
+	//
+	// Defaults to 500ms.
+	Backoff time.Duration
+
+	// Batch configures the batching of entries.
+	// See slogbatch.Options.
+	Batch *slogbatch.Options
+}
+
+// exporter sends encoded ExportLogsServiceRequests and
+// returns the encoded responses.
+type exporter interface {
+	export(ctx context.Context, req []byte) ([]byte, error)
+	close() error
+}
+
+// Sink creates a slog.Sink that exports batches of entries.
+//
+// The returned sink implements io.Closer. Close exports the
+// remaining entries and closes the connection.
+func Sink(opts *Options) (slog.Sink, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	s := &otlpSink{
+		timeout: opts.Timeout,
+	}
+	if s.timeout <= 0 {
+		s.timeout = 10 * time.Second
+	}
+
+	serviceName := opts.ServiceName
+	if serviceName == "" {
+		exe, _ := os.Executable()
+		serviceName = strings.TrimSuffix(filepath.Base(exe), filepath.Ext(exe))
+	}
+	resource := append(slog.M(slog.F("service.name", serviceName)), opts.Resource...)
+	for _, f := range entryjson.Flatten(resource, ".") {
+		s.resource = appendKeyValue(s.resource, 1, f.Key, f.Value)
+	}
+
+	var err error
+	switch opts.Protocol {
+	case ProtocolHTTP:
+		s.exp, err = newHTTPExporter(opts)
+	case ProtocolGRPC:
+		s.exp, err = newGRPCExporter(opts)
+	default:
+		err = xerrors.Errorf("unknown protocol %v", opts.Protocol)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return slogbatch.Sink(s, opts.Batch), nil
+}
+
+type otlpSink struct {
+	exp      exporter
+	timeout  time.Duration
+	resource []byte
+}
+
+func (s *otlpSink) LogEntry(ctx context.Context, ent slog.SinkEntry) {
+	s.LogEntries(ctx, []slog.SinkEntry{ent})
+}
+
+func (s *otlpSink) LogEntries(ctx context.Context, ents []slog.SinkEntry) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	resp, err := s.exp.export(ctx, s.request(ents))
+	if err != nil {
+		slog.ReportError(ctx, xerrors.Errorf("slogotlp: failed to export %v entries: %w", len(ents), err), ents...)
+		return
+	}
+	rejected, msg, err := partialSuccess(resp)
+	if err != nil {
+		slog.ReportError(ctx, xerrors.Errorf("slogotlp: failed to decode export response: %w", err), ents...)
+		return
+	}
+	if rejected > 0 {
+		slog.ReportError(ctx, xerrors.Errorf("slogotlp: collector rejected %v of %v entries: %v", rejected, len(ents), msg), ents...)
+	}
+}
+
+// request encodes an ExportLogsServiceRequest of ents
+// with a ScopeLogs per logger name.
+func (s *otlpSink) request(ents []slog.SinkEntry) []byte {
+	var names []string
+	scopes := make(map[string][]byte)
+	for _, ent := range ents {
+		name := strings.Join(ent.LoggerNames, ".")
+		if _, ok := scopes[name]; !ok {
+			names = append(names, name)
+		}
+		scopes[name] = appendMessage(scopes[name], 2, logRecord(ent))
+	}
+
+	var resourceLogs []byte
+	resourceLogs = appendMessage(resourceLogs, 1, s.resource)
+	for _, name := range names {
+		var scope []byte
+		scope = appendString(scope, 1, name)
+
+		var scopeLogs []byte
+		scopeLogs = appendMessage(scopeLogs, 1, scope)
+		scopeLogs = append(scopeLogs, scopes[name]...)
+		resourceLogs = appendMessage(resourceLogs, 2, scopeLogs)
+	}
+	return appendMessage(nil, 1, resourceLogs)
+}
+
+func logRecord(ent slog.SinkEntry) []byte {
+	var b []byte
+	b = appendFixed64(b, 1, uint64(ent.Time.UnixNano()))
+	b = appendVarint(b, 2, severity(ent.Level))
+	b = appendString(b, 3, ent.Level.String())
+	b = appendMessage(b, 5, anyValue(ent.Message))
+
+	b = appendKeyValue(b, 6, "code.filepath", ent.File)
+	b = appendKeyValue(b, 6, "code.lineno", json.Number(strconv.Itoa(ent.Line)))
+	b = appendKeyValue(b, 6, "code.function", ent.Func)
+	if len(ent.Fields) > 0 {
+		// No error is guaranteed due to slog.Map handling errors itself.
+		j, _ := ent.Fields.MarshalJSON()
+		v, _ := entryjson.Decode(j)
+		fields, _ := v.(entryjson.Object)
+		for _, f := range fields {
+			b = appendKeyValue(b, 6, f.Key, f.Value)
+		}
+	}
+
+	if ent.SpanContext != (trace.SpanContext{}) {
+		b = protowire.AppendTag(b, 8, protowire.Fixed32Type)
+		b = protowire.AppendFixed32(b, uint32(ent.SpanContext.TraceOptions))
+		b = appendBytes(b, 9, ent.SpanContext.TraceID[:])
+		b = appendBytes(b, 10, ent.SpanContext.SpanID[:])
+	}
+	b = appendFixed64(b, 11, uint64(time.Now().UnixNano()))
+	return b
+}
+
+// severity maps level to a SeverityNumber.
+func severity(level slog.Level) uint64 {
+	switch {
+	case level < slog.LevelInfo:
+		return 5 // DEBUG
+	case level < slog.LevelWarn:
+		return 9 // INFO
+	case level < slog.LevelError:
+		return 13 // WARN
+	case level < slog.LevelCritical:
+		return 17 // ERROR
+	case level < slog.LevelFatal:
+		return 21 // FATAL
+	default:
+		return 24 // FATAL4
+	}
+}
+
+func (s *otlpSink) Sync() {}
+
+func (s *otlpSink) Close() error {
+	return s.exp.close()
+}
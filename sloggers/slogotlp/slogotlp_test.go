@@ -0,0 +1,216 @@
+package slogotlp_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.opencensus.io/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+	"cdr.dev/slog/sloggers/slogotlp"
+)
+
+var bg = context.Background()
+
+var kt = time.Date(2000, time.February, 5, 4, 4, 4, 123456789, time.UTC)
+
+var ent = slog.SinkEntry{
+	Time:        kt,
+	Level:       slog.LevelWarn,
+	LoggerNames: []string{"comp", "db"},
+	Message:     "hi",
+	File:        "main.go",
+	Line:        62,
+	Func:        "main.main",
+	SpanContext: trace.SpanContext{
+		TraceID:      trace.TraceID{1},
+		SpanID:       trace.SpanID{2},
+		TraceOptions: 1,
+	},
+	Fields: slog.M(
+		slog.F("req", slog.M(slog.F("id", 1))),
+	),
+}
+
+func TestSink_http(t *testing.T) {
+	t.Parallel()
+
+	reqs := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "path", "/v1/logs", r.URL.Path)
+		assert.Equal(t, "content type", "application/x-protobuf", r.Header.Get("Content-Type"))
+		assert.Equal(t, "api key", "key", r.Header.Get("Api-Key"))
+		b, err := ioutil.ReadAll(r.Body)
+		assert.Success(t, "read body", err)
+		reqs <- b
+	}))
+	defer srv.Close()
+
+	s, err := slogotlp.Sink(&slogotlp.Options{
+		Endpoint:    srv.URL,
+		Headers:     map[string]string{"api-key": "key"},
+		ServiceName: "svc",
+	})
+	assert.Success(t, "sink", err)
+	defer s.(interface{ Close() error }).Close()
+
+	s.LogEntry(bg, ent)
+	s.Sync()
+
+	checkRequest(t, <-reqs)
+}
+
+func TestSink_grpc(t *testing.T) {
+	t.Parallel()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Success(t, "listen", err)
+
+	reqs := make(chan []byte, 1)
+	srv := grpc.NewServer(
+		grpc.ForceServerCodec(rawCodec{}),
+		grpc.UnknownServiceHandler(func(_ interface{}, stream grpc.ServerStream) error {
+			method, _ := grpc.MethodFromServerStream(stream)
+			assert.Equal(t, "method", "/opentelemetry.proto.collector.logs.v1.LogsService/Export", method)
+			md, _ := metadata.FromIncomingContext(stream.Context())
+			assert.Equal(t, "api key", []string{"key"}, md.Get("api-key"))
+
+			var req []byte
+			err := stream.RecvMsg(&req)
+			if err != nil {
+				return err
+			}
+			reqs <- req
+			return stream.SendMsg([]byte{})
+		}),
+	)
+	go srv.Serve(ln)
+	defer srv.Stop()
+
+	s, err := slogotlp.Sink(&slogotlp.Options{
+		Protocol:    slogotlp.ProtocolGRPC,
+		Endpoint:    ln.Addr().String(),
+		Headers:     map[string]string{"api-key": "key"},
+		ServiceName: "svc",
+	})
+	assert.Success(t, "sink", err)
+	defer s.(interface{ Close() error }).Close()
+
+	s.LogEntry(bg, ent)
+	s.Sync()
+
+	checkRequest(t, <-reqs)
+}
+
+func checkRequest(t *testing.T, req []byte) {
+	t.Helper()
+
+	resourceLogs := fields(t, req)[1]
+	assert.Len(t, "resource logs", 1, resourceLogs)
+	rl := fields(t, resourceLogs[0])
+
+	resource := fields(t, rl[1][0])
+	assert.Equal(t, "resource", map[string]interface{}{"service.name": "svc"}, keyValues(t, resource[1]))
+
+	scopeLogs := fields(t, rl[2][0])
+	assert.Equal(t, "scope", "comp.db", string(fields(t, scopeLogs[1][0])[1][0]))
+
+	rec := fields(t, scopeLogs[2][0])
+	assert.Equal(t, "time", uint64(kt.UnixNano()), fixed64(rec[1][0]))
+	assert.Equal(t, "severity", uint64(13), varint(rec[2][0]))
+	assert.Equal(t, "severity text", "WARN", string(rec[3][0]))
+	assert.Equal(t, "body", "hi", anyValue(t, rec[5][0]))
+	assert.Equal(t, "attributes", map[string]interface{}{
+		"code.filepath": "main.go",
+		"code.lineno":   int64(62),
+		"code.function": "main.main",
+		"req":           map[string]interface{}{"id": int64(1)},
+	}, keyValues(t, rec[6]))
+	assert.Equal(t, "trace id", ent.SpanContext.TraceID[:], rec[9][0])
+	assert.Equal(t, "span id", ent.SpanContext.SpanID[:], rec[10][0])
+}
+
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	return v.([]byte), nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	*v.(*[]byte) = append([]byte(nil), data...)
+	return nil
+}
+
+func (rawCodec) Name() string {
+	return "proto"
+}
+
+// fields decodes the fields of the message b by number. The values
+// are the raw varint or fixed bytes or the contents of bytes fields.
+func fields(t *testing.T, b []byte) map[protowire.Number][][]byte {
+	t.Helper()
+
+	m := make(map[protowire.Number][][]byte)
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		assert.True(t, "valid tag", n > 0)
+		b = b[n:]
+
+		var v []byte
+		if typ == protowire.BytesType {
+			v, n = protowire.ConsumeBytes(b)
+		} else {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			v = b[:n]
+		}
+		assert.True(t, "valid field", n > 0)
+		b = b[n:]
+		m[num] = append(m[num], v)
+	}
+	return m
+}
+
+func varint(b []byte) uint64 {
+	v, _ := protowire.ConsumeVarint(b)
+	return v
+}
+
+func fixed64(b []byte) uint64 {
+	v, _ := protowire.ConsumeFixed64(b)
+	return v
+}
+
+func keyValues(t *testing.T, kvs [][]byte) map[string]interface{} {
+	t.Helper()
+
+	m := make(map[string]interface{})
+	for _, kv := range kvs {
+		f := fields(t, kv)
+		m[string(f[1][0])] = anyValue(t, f[2][0])
+	}
+	return m
+}
+
+func anyValue(t *testing.T, b []byte) interface{} {
+	t.Helper()
+
+	f := fields(t, b)
+	switch {
+	case f[1] != nil:
+		return string(f[1][0])
+	case f[3] != nil:
+		return int64(varint(f[3][0]))
+	case f[6] != nil:
+		return keyValues(t, fields(t, f[6][0])[1])
+	}
+	return nil
+}
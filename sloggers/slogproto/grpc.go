@@ -0,0 +1,184 @@
+package slogproto
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/sloggers/slogbatch"
+)
+
+// GRPCOptions represents the options for the sink returned by GRPCSink.
+type GRPCOptions struct {
+	// Endpoint is the host and port of the collector.
+	Endpoint string
+
+	// TLSConfig enables TLS. The connection is insecure if it is nil.
+	TLSConfig *tls.Config
+
+	// Headers are sent as the metadata of the stream,
+	// e.g. for authentication.
+	Headers map[string]string
+
+	// CloseTimeout is the maximum duration Close waits for the
+	// collector to acknowledge the end of the stream.
+	//
+	// Defaults to 10 seconds.
+	CloseTimeout time.Duration
+
+	// Batch configures the batching of entries.
+	// See slogbatch.Options.
+	Batch *slogbatch.Options
+}
+
+const streamMethod = "/slog.v1.Collector/Stream"
+
+// GRPCSink creates a slog.Sink that sends batches of entries on a
+// long lived Collector.Stream call.
+//
+// The stream is reopened once when sending a batch fails, e.g. because
+// the collector restarted. A batch that cannot be sent is reported.
+//
+// The returned sink implements io.Closer. Close sends the remaining
+// entries, ends the stream and closes the connection.
+func GRPCSink(opts *GRPCOptions) (slog.Sink, error) {
+	if opts == nil {
+		opts = &GRPCOptions{}
+	}
+	if opts.Endpoint == "" {
+		return nil, xerrors.New("endpoint is required")
+	}
+	creds := grpc.WithInsecure()
+	if opts.TLSConfig != nil {
+		creds = grpc.WithTransportCredentials(credentials.NewTLS(opts.TLSConfig))
+	}
+	// Dialing does not block so the collector may start later.
+	conn, err := grpc.Dial(opts.Endpoint, creds)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to dial %v: %w", opts.Endpoint, err)
+	}
+
+	s := &grpcSink{
+		conn:         conn,
+		md:           metadata.New(opts.Headers),
+		closeTimeout: opts.CloseTimeout,
+	}
+	if s.closeTimeout <= 0 {
+		s.closeTimeout = 10 * time.Second
+	}
+	return slogbatch.Sink(s, opts.Batch), nil
+}
+
+type grpcSink struct {
+	conn         *grpc.ClientConn
+	md           metadata.MD
+	closeTimeout time.Duration
+
+	mu     sync.Mutex
+	stream grpc.ClientStream
+	cancel context.CancelFunc
+}
+
+// rawCodec passes encoded messages through as is.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	return v.([]byte), nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	*v.(*[]byte) = append([]byte(nil), data...)
+	return nil
+}
+
+func (rawCodec) Name() string {
+	return "proto"
+}
+
+func (s *grpcSink) LogEntry(ctx context.Context, ent slog.SinkEntry) {
+	s.LogEntries(ctx, []slog.SinkEntry{ent})
+}
+
+func (s *grpcSink) LogEntries(ctx context.Context, ents []slog.SinkEntry) {
+	var batch []byte
+	for _, ent := range ents {
+		batch = appendMessage(batch, 1, entry(ent))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var err error
+	for i := 0; i < 2; i++ {
+		err = s.send(batch)
+		if err == nil {
+			return
+		}
+	}
+	slog.ReportError(ctx, xerrors.Errorf("slogproto: failed to send %v entries: %w", len(ents), err), ents...)
+}
+
+// send sends batch on the stream, opening it if necessary.
+// The stream is discarded if sending fails.
+func (s *grpcSink) send(batch []byte) error {
+	if s.stream == nil {
+		// The stream outlives the context of any entry.
+		ctx, cancel := context.WithCancel(metadata.NewOutgoingContext(context.Background(), s.md))
+		stream, err := s.conn.NewStream(ctx, &grpc.StreamDesc{ClientStreams: true}, streamMethod, grpc.ForceCodec(rawCodec{}))
+		if err != nil {
+			cancel()
+			return xerrors.Errorf("failed to open stream: %w", err)
+		}
+		s.stream = stream
+		s.cancel = cancel
+	}
+
+	err := s.stream.SendMsg(batch)
+	if err == nil {
+		return nil
+	}
+	if err == io.EOF {
+		// The stream was aborted and RecvMsg returns why.
+		var resp []byte
+		err = s.stream.RecvMsg(&resp)
+		if err == nil {
+			err = xerrors.New("collector ended the stream")
+		}
+	}
+	s.cancel()
+	s.stream = nil
+	return err
+}
+
+func (s *grpcSink) Sync() {}
+
+func (s *grpcSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stream != nil {
+		t := time.AfterFunc(s.closeTimeout, s.cancel)
+		defer t.Stop()
+
+		err := s.stream.CloseSend()
+		if err == nil {
+			var resp []byte
+			err = s.stream.RecvMsg(&resp)
+		}
+		s.cancel()
+		s.stream = nil
+		if err != nil {
+			s.conn.Close()
+			return xerrors.Errorf("failed to close stream: %w", err)
+		}
+	}
+	return s.conn.Close()
+}
@@ -0,0 +1,116 @@
+package slogproto
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+
+	"go.opencensus.io/trace"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/entryjson"
+)
+
+// The messages of entry.proto are encoded by hand to avoid
+// generated code. Every function appends a field with the
+// given number.
+
+func appendMessage(b []byte, num protowire.Number, msg []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
+
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func appendVarint(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+// entry encodes ent as an Entry.
+func entry(ent slog.SinkEntry) []byte {
+	var e []byte
+	if !ent.Time.IsZero() {
+		var ts []byte
+		ts = appendVarint(ts, 1, uint64(ent.Time.Unix()))
+		ts = appendVarint(ts, 2, uint64(ent.Time.Nanosecond()))
+		e = appendMessage(e, 1, ts)
+	}
+	e = appendVarint(e, 2, uint64(int32(ent.Level)))
+	e = appendString(e, 3, ent.Level.String())
+	for _, name := range ent.LoggerNames {
+		e = protowire.AppendTag(e, 4, protowire.BytesType)
+		e = protowire.AppendString(e, name)
+	}
+	e = appendString(e, 5, ent.Message)
+	e = appendString(e, 6, ent.File)
+	e = appendVarint(e, 7, uint64(int32(ent.Line)))
+	e = appendString(e, 8, ent.Func)
+	if ent.SpanContext != (trace.SpanContext{}) {
+		e = appendMessage(e, 9, ent.SpanContext.TraceID[:])
+		e = appendMessage(e, 10, ent.SpanContext.SpanID[:])
+	}
+	if len(ent.Fields) > 0 {
+		// No error is guaranteed due to slog.Map handling errors itself.
+		j, _ := ent.Fields.MarshalJSON()
+		v, _ := entryjson.Decode(j)
+		for _, f := range v.(entryjson.Object) {
+			e = appendField(e, 11, f.Key, f.Value)
+		}
+	}
+	return e
+}
+
+// appendField appends a Field with a value decoded by entryjson.
+func appendField(b []byte, num protowire.Number, name string, v interface{}) []byte {
+	var f []byte
+	f = appendString(f, 1, name)
+	f = appendMessage(f, 2, value(v))
+	return appendMessage(b, num, f)
+}
+
+// value encodes a value decoded by entryjson as a Value.
+func value(v interface{}) []byte {
+	var b []byte
+	switch v := v.(type) {
+	case string:
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendString(b, v)
+	case bool:
+		b = protowire.AppendTag(b, 2, protowire.VarintType)
+		b = protowire.AppendVarint(b, protowire.EncodeBool(v))
+	case json.Number:
+		if i, err := strconv.ParseInt(v.String(), 10, 64); err == nil {
+			b = protowire.AppendTag(b, 3, protowire.VarintType)
+			b = protowire.AppendVarint(b, protowire.EncodeZigZag(i))
+			break
+		}
+		f, _ := v.Float64()
+		b = protowire.AppendTag(b, 4, protowire.Fixed64Type)
+		b = protowire.AppendFixed64(b, math.Float64bits(f))
+	case []interface{}:
+		var arr []byte
+		for _, el := range v {
+			arr = appendMessage(arr, 1, value(el))
+		}
+		b = appendMessage(b, 5, arr)
+	case entryjson.Object:
+		var obj []byte
+		for _, f := range v {
+			obj = appendField(obj, 1, f.Key, f.Value)
+		}
+		b = appendMessage(b, 6, obj)
+	}
+	// null is an empty Value.
+	return b
+}
@@ -0,0 +1,46 @@
+// Package slogproto contains the slogger that writes entries
+// as protobuf messages.
+//
+// Every entry is an Entry message as defined in entry.proto.
+// Fields are typed values with the same structure as their JSON.
+// Sink writes length delimited entries to a writer and GRPCSink
+// streams batches of entries to a collector implementing the
+// Collector service.
+package slogproto // import "cdr.dev/slog/sloggers/slogproto"
+
+import (
+	"context"
+	"io"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/syncwriter"
+)
+
+// Sink creates a slog.Sink that writes entries to the given writer.
+// Every entry is prefixed with its length as a varint like
+// protodelim and writeDelimitedTo in other languages.
+// If the writer implements Sync() error then
+// it will be called when syncing.
+func Sink(w io.Writer) slog.Sink {
+	return protoSink{
+		w: syncwriter.New(w),
+	}
+}
+
+type protoSink struct {
+	w *syncwriter.Writer
+}
+
+func (s protoSink) LogEntry(ctx context.Context, ent slog.SinkEntry) {
+	b := protowire.AppendBytes(nil, entry(ent))
+	err := s.w.Write(ctx, "slogproto", b)
+	if err != nil {
+		slog.ReportError(ctx, err, ent)
+	}
+}
+
+func (s protoSink) Sync() {
+	s.w.Sync("slogproto")
+}
@@ -0,0 +1,234 @@
+package slogproto_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"go.opencensus.io/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+	"cdr.dev/slog/sloggers/slogproto"
+)
+
+var bg = context.Background()
+
+var kt = time.Date(2000, time.February, 5, 4, 4, 4, 123456789, time.UTC)
+
+var ent = slog.SinkEntry{
+	Time:        kt,
+	Level:       slog.LevelWarn,
+	LoggerNames: []string{"comp", "db"},
+	Message:     "hi",
+	File:        "main.go",
+	Line:        62,
+	Func:        "main.main",
+	SpanContext: trace.SpanContext{
+		TraceID: trace.TraceID{1},
+		SpanID:  trace.SpanID{2},
+	},
+	Fields: slog.M(
+		slog.F("id", -1),
+		slog.F("ratio", 0.5),
+		slog.F("ok", true),
+		slog.F("none", nil),
+		slog.F("tags", []string{"a"}),
+		slog.F("req", slog.M(slog.F("path", "/"))),
+	),
+}
+
+func TestSink(t *testing.T) {
+	t.Parallel()
+
+	b := &bytes.Buffer{}
+	s := slogproto.Sink(b)
+	s.LogEntry(bg, ent)
+	s.LogEntry(bg, slog.SinkEntry{Message: "bye"})
+	s.Sync()
+
+	e, n := protowire.ConsumeBytes(b.Bytes())
+	assert.True(t, "valid length", n > 0)
+	checkEntry(t, e)
+
+	e, n = protowire.ConsumeBytes(b.Bytes()[n:])
+	assert.True(t, "valid length", n > 0)
+	f := fields(t, e)
+	assert.Equal(t, "message", "bye", string(f[5][0]))
+	assert.Equal(t, "level name", "DEBUG", string(f[3][0]))
+	assert.Len(t, "time", 0, f[1])
+	assert.Len(t, "level", 0, f[2])
+}
+
+func TestGRPCSink(t *testing.T) {
+	t.Parallel()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Success(t, "listen", err)
+
+	batches := make(chan []byte, 2)
+	srv := grpc.NewServer(
+		grpc.ForceServerCodec(rawCodec{}),
+		grpc.UnknownServiceHandler(func(_ interface{}, stream grpc.ServerStream) error {
+			method, _ := grpc.MethodFromServerStream(stream)
+			assert.Equal(t, "method", "/slog.v1.Collector/Stream", method)
+			md, _ := metadata.FromIncomingContext(stream.Context())
+			assert.Equal(t, "api key", []string{"key"}, md.Get("api-key"))
+
+			for {
+				var batch []byte
+				err := stream.RecvMsg(&batch)
+				if err == io.EOF {
+					close(batches)
+					return stream.SendMsg([]byte{})
+				}
+				if err != nil {
+					return err
+				}
+				batches <- batch
+			}
+		}),
+	)
+	go srv.Serve(ln)
+	defer srv.Stop()
+
+	s, err := slogproto.GRPCSink(&slogproto.GRPCOptions{
+		Endpoint: ln.Addr().String(),
+		Headers:  map[string]string{"api-key": "key"},
+	})
+	assert.Success(t, "sink", err)
+
+	s.LogEntry(bg, ent)
+	s.Sync()
+	s.LogEntry(bg, ent)
+	s.LogEntry(bg, ent)
+	err = s.(io.Closer).Close()
+	assert.Success(t, "close", err)
+
+	var entries [][]byte
+	for batch := range batches {
+		entries = append(entries, fields(t, batch)[1]...)
+	}
+	assert.Len(t, "entries", 3, entries)
+	checkEntry(t, entries[2])
+}
+
+func checkEntry(t *testing.T, e []byte) {
+	t.Helper()
+
+	f := fields(t, e)
+	ts := fields(t, f[1][0])
+	assert.Equal(t, "seconds", uint64(kt.Unix()), varint(ts[1][0]))
+	assert.Equal(t, "nanos", uint64(kt.Nanosecond()), varint(ts[2][0]))
+	assert.Equal(t, "level", uint64(slog.LevelWarn), varint(f[2][0]))
+	assert.Equal(t, "level name", "WARN", string(f[3][0]))
+	assert.Equal(t, "logger names", [][]byte{[]byte("comp"), []byte("db")}, f[4])
+	assert.Equal(t, "message", "hi", string(f[5][0]))
+	assert.Equal(t, "file", "main.go", string(f[6][0]))
+	assert.Equal(t, "line", uint64(62), varint(f[7][0]))
+	assert.Equal(t, "func", "main.main", string(f[8][0]))
+	assert.Equal(t, "trace id", ent.SpanContext.TraceID[:], f[9][0])
+	assert.Equal(t, "span id", ent.SpanContext.SpanID[:], f[10][0])
+	assert.Equal(t, "fields", []field{
+		{"id", int64(-1)},
+		{"ratio", 0.5},
+		{"ok", true},
+		{"none", nil},
+		{"tags", []interface{}{"a"}},
+		{"req", []field{{"path", "/"}}},
+	}, decodeFields(t, f[11]))
+}
+
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	return v.([]byte), nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	*v.(*[]byte) = append([]byte(nil), data...)
+	return nil
+}
+
+func (rawCodec) Name() string {
+	return "proto"
+}
+
+// fields decodes the fields of the message b by number. The values
+// are the raw varint or fixed bytes or the contents of bytes fields.
+func fields(t *testing.T, b []byte) map[protowire.Number][][]byte {
+	t.Helper()
+
+	m := make(map[protowire.Number][][]byte)
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		assert.True(t, "valid tag", n > 0)
+		b = b[n:]
+
+		var v []byte
+		if typ == protowire.BytesType {
+			v, n = protowire.ConsumeBytes(b)
+		} else {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			v = b[:n]
+		}
+		assert.True(t, "valid field", n > 0)
+		b = b[n:]
+		m[num] = append(m[num], v)
+	}
+	return m
+}
+
+func varint(b []byte) uint64 {
+	v, _ := protowire.ConsumeVarint(b)
+	return v
+}
+
+type field struct {
+	Name  string
+	Value interface{}
+}
+
+func decodeFields(t *testing.T, fs [][]byte) []field {
+	t.Helper()
+
+	var decoded []field
+	for _, b := range fs {
+		f := fields(t, b)
+		decoded = append(decoded, field{string(f[1][0]), value(t, f[2][0])})
+	}
+	return decoded
+}
+
+func value(t *testing.T, b []byte) interface{} {
+	t.Helper()
+
+	f := fields(t, b)
+	switch {
+	case f[1] != nil:
+		return string(f[1][0])
+	case f[2] != nil:
+		return protowire.DecodeBool(varint(f[2][0]))
+	case f[3] != nil:
+		return protowire.DecodeZigZag(varint(f[3][0]))
+	case f[4] != nil:
+		v, _ := protowire.ConsumeFixed64(f[4][0])
+		return math.Float64frombits(v)
+	case f[5] != nil:
+		var arr []interface{}
+		for _, el := range fields(t, f[5][0])[1] {
+			arr = append(arr, value(t, el))
+		}
+		return arr
+	case f[6] != nil:
+		return decodeFields(t, fields(t, f[6][0])[1])
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+package slograte
+
+import (
+	"time"
+
+	"cdr.dev/slog"
+)
+
+func SetNow(s slog.Sink, now func() time.Time) {
+	s.(*rateSink).now = now
+}
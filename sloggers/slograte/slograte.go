@@ -0,0 +1,249 @@
+// Package slograte contains a slogger that rate limits entries
+// with a token bucket.
+package slograte // import "cdr.dev/slog/sloggers/slograte"
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"cdr.dev/slog"
+)
+
+// Key determines how entries are grouped into rate limits.
+//
+// Keys can be combined, e.g. KeyLoggerName|KeyLevel rate
+// limits entries per logger name and level.
+type Key int
+
+const (
+	// KeyNone rate limits all entries together.
+	KeyNone Key = 0
+
+	// KeyLoggerName rate limits entries per logger name.
+	KeyLoggerName Key = 1 << 0
+
+	// KeyLevel rate limits entries per level.
+	KeyLevel Key = 1 << 1
+)
+
+// Options represents the options for the sink returned by Sink.
+type Options struct {
+	// Rate is the number of entries allowed per second.
+	//
+	// Defaults to 10.
+	Rate float64
+
+	// Burst is the maximum number of entries allowed at once.
+	//
+	// Defaults to Rate or 1, whichever is larger.
+	Burst int
+
+	// Key determines how entries are grouped into rate limits.
+	Key Key
+
+	// SummaryInterval is the minimum interval between entries
+	// summarizing the number of dropped entries.
+	//
+	// Defaults to time.Second.
+	SummaryInterval time.Duration
+}
+
+// Sink creates a slog.Sink that rate limits the entries passed to s.
+//
+// When entries have been dropped, a warning with the number of dropped
+// entries is logged at most once every SummaryInterval, either before
+// the next allowed entry or once the interval elapses, and on Sync.
+func Sink(s slog.Sink, opts *Options) slog.Sink {
+	if opts == nil {
+		opts = &Options{}
+	}
+	rs := &rateSink{
+		s:               s,
+		rate:            opts.Rate,
+		burst:           float64(opts.Burst),
+		key:             opts.Key,
+		summaryInterval: opts.SummaryInterval,
+		buckets:         make(map[bucketKey]*bucket),
+		now:             time.Now,
+	}
+	if rs.rate <= 0 {
+		// A bucket would never refill.
+		rs.rate = 10
+	}
+	if rs.burst <= 0 {
+		rs.burst = rs.rate
+		if rs.burst < 1 {
+			rs.burst = 1
+		}
+	}
+	if rs.summaryInterval <= 0 {
+		rs.summaryInterval = time.Second
+	}
+	return rs
+}
+
+type bucketKey struct {
+	name  string
+	level slog.Level
+}
+
+type bucket struct {
+	loggerNames []string
+	tokens      float64
+	last        time.Time
+
+	dropped     int
+	lastSummary time.Time
+	// timer logs the summary once the interval elapses
+	// if no entry is allowed before then.
+	timer *time.Timer
+	// gen is incremented on every summary so that a timer
+	// that fires late does not log a later summary.
+	gen int
+}
+
+type rateSink struct {
+	s               slog.Sink
+	rate            float64
+	burst           float64
+	key             Key
+	summaryInterval time.Duration
+
+	mu      sync.Mutex
+	buckets map[bucketKey]*bucket
+
+	now func() time.Time
+}
+
+func (s *rateSink) LogEntry(ctx context.Context, ent slog.SinkEntry) {
+	summary, ok := s.allow(ent)
+	if summary != nil {
+		s.s.LogEntry(ctx, *summary)
+	}
+	if ok {
+		s.s.LogEntry(ctx, ent)
+	}
+}
+
+func (s *rateSink) Sync() {
+	s.mu.Lock()
+	var summaries []slog.SinkEntry
+	now := s.now()
+	for k, b := range s.buckets {
+		if b.dropped > 0 {
+			summaries = append(summaries, s.summary(k, b, now))
+		}
+	}
+	s.mu.Unlock()
+
+	for _, ent := range summaries {
+		s.s.LogEntry(context.Background(), ent)
+	}
+	s.s.Sync()
+}
+
+func (s *rateSink) Close() error {
+	if c, ok := s.s.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func (s *rateSink) allow(ent slog.SinkEntry) (*slog.SinkEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var k bucketKey
+	if s.key&KeyLoggerName != 0 {
+		k.name = strings.Join(ent.LoggerNames, ".")
+	}
+	if s.key&KeyLevel != 0 {
+		k.level = ent.Level
+	}
+
+	now := s.now()
+	b, ok := s.buckets[k]
+	if !ok {
+		b = &bucket{
+			tokens:      s.burst,
+			last:        now,
+			lastSummary: now,
+		}
+		if s.key&KeyLoggerName != 0 {
+			b.loggerNames = ent.LoggerNames
+		}
+		s.buckets[k] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * s.rate
+	if b.tokens > s.burst {
+		b.tokens = s.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		if b.dropped == 0 {
+			s.schedule(k, b, now)
+		}
+		b.dropped++
+		return nil, false
+	}
+	b.tokens--
+
+	var summary *slog.SinkEntry
+	if b.dropped > 0 && now.Sub(b.lastSummary) >= s.summaryInterval {
+		ent := s.summary(k, b, now)
+		summary = &ent
+	}
+	return summary, true
+}
+
+// schedule starts the timer that logs the summary of b
+// once the summary interval elapses.
+func (s *rateSink) schedule(k bucketKey, b *bucket, now time.Time) {
+	d := s.summaryInterval - now.Sub(b.lastSummary)
+	if d < 0 {
+		d = 0
+	}
+	gen := b.gen
+	b.timer = time.AfterFunc(d, func() {
+		s.expire(k, gen)
+	})
+}
+
+func (s *rateSink) expire(k bucketKey, gen int) {
+	s.mu.Lock()
+	b := s.buckets[k]
+	if b.gen != gen || b.dropped == 0 {
+		s.mu.Unlock()
+		return
+	}
+	ent := s.summary(k, b, s.now())
+	s.mu.Unlock()
+
+	s.s.LogEntry(context.Background(), ent)
+}
+
+func (s *rateSink) summary(k bucketKey, b *bucket, now time.Time) slog.SinkEntry {
+	ent := slog.SinkEntry{
+		Time:        now.UTC(),
+		Level:       slog.LevelWarn,
+		Message:     "dropped entries due to rate limit",
+		LoggerNames: b.loggerNames,
+		Fields: slog.M(
+			slog.F("dropped", b.dropped),
+		),
+	}
+	if s.key&KeyLevel != 0 {
+		ent.Fields = append(ent.Fields, slog.F("level", k.level))
+	}
+
+	b.dropped = 0
+	b.lastSummary = now
+	b.timer.Stop()
+	b.gen++
+	return ent
+}
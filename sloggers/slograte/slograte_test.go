@@ -0,0 +1,169 @@
+package slograte_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+	"cdr.dev/slog/sloggers/slograte"
+)
+
+var bg = context.Background()
+
+type fakeSink struct {
+	entries []slog.SinkEntry
+	syncs   int
+}
+
+func (s *fakeSink) LogEntry(_ context.Context, e slog.SinkEntry) {
+	s.entries = append(s.entries, e)
+}
+
+func (s *fakeSink) Sync() {
+	s.syncs++
+}
+
+func TestSink(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(0, 0)
+	fs := &fakeSink{}
+	s := slograte.Sink(fs, &slograte.Options{
+		Rate:  1,
+		Burst: 2,
+	})
+	slograte.SetNow(s, func() time.Time {
+		return now
+	})
+	l := slog.Make(s)
+
+	for i := 0; i < 5; i++ {
+		l.Info(bg, "hi")
+	}
+	assert.Len(t, "entries", 2, fs.entries)
+
+	now = now.Add(time.Second)
+	l.Info(bg, "hi")
+	assert.Len(t, "entries", 4, fs.entries)
+	assert.Equal(t, "summary", slog.M(slog.F("dropped", 3)), fs.entries[2].Fields)
+	assert.Equal(t, "msg", "hi", fs.entries[3].Message)
+
+	l.Info(bg, "hi")
+	l.Sync()
+	assert.Len(t, "entries", 5, fs.entries)
+	assert.Equal(t, "summary", slog.M(slog.F("dropped", 1)), fs.entries[4].Fields)
+	assert.Equal(t, "syncs", 1, fs.syncs)
+}
+
+func TestKey(t *testing.T) {
+	t.Parallel()
+
+	fs := &fakeSink{}
+	l := slog.Make(slograte.Sink(fs, &slograte.Options{
+		Rate: 1,
+		Key:  slograte.KeyLoggerName,
+	}))
+
+	l.Named("a").Info(bg, "hi")
+	l.Named("a").Info(bg, "hi")
+	l.Named("b").Info(bg, "hi")
+	assert.Len(t, "entries", 2, fs.entries)
+	l.Sync()
+
+	assert.Len(t, "entries", 3, fs.entries)
+	assert.Equal(t, "names", []string{"a"}, fs.entries[2].LoggerNames)
+}
+
+func TestKeyLevel(t *testing.T) {
+	t.Parallel()
+
+	fs := &fakeSink{}
+	l := slog.Make(slograte.Sink(fs, &slograte.Options{
+		Rate: 1,
+		Key:  slograte.KeyLevel,
+	}))
+
+	l.Info(bg, "hi")
+	l.Info(bg, "hi")
+	l.Warn(bg, "hi")
+	assert.Len(t, "entries", 2, fs.entries)
+	l.Sync()
+
+	assert.Len(t, "entries", 3, fs.entries)
+	assert.Equal(t, "summary", slog.M(slog.F("dropped", 1), slog.F("level", slog.LevelInfo)), fs.entries[2].Fields)
+}
+
+func TestKeyCombined(t *testing.T) {
+	t.Parallel()
+
+	fs := &fakeSink{}
+	l := slog.Make(slograte.Sink(fs, &slograte.Options{
+		Rate: 1,
+		Key:  slograte.KeyLoggerName | slograte.KeyLevel,
+	}))
+
+	l.Named("a").Info(bg, "hi")
+	l.Named("a").Info(bg, "hi")
+	l.Named("a").Warn(bg, "hi")
+	l.Named("b").Info(bg, "hi")
+	assert.Len(t, "entries", 3, fs.entries)
+	l.Sync()
+
+	assert.Len(t, "entries", 4, fs.entries)
+	assert.Equal(t, "names", []string{"a"}, fs.entries[3].LoggerNames)
+	assert.Equal(t, "summary", slog.M(slog.F("dropped", 1), slog.F("level", slog.LevelInfo)), fs.entries[3].Fields)
+}
+
+type chanSink chan slog.SinkEntry
+
+func (s chanSink) LogEntry(_ context.Context, e slog.SinkEntry) {
+	s <- e
+}
+
+func (s chanSink) Sync() {}
+
+func TestSummaryInterval(t *testing.T) {
+	t.Parallel()
+
+	cs := make(chanSink, 4)
+	l := slog.Make(slograte.Sink(cs, &slograte.Options{
+		Rate:            1,
+		SummaryInterval: time.Millisecond * 10,
+	}))
+
+	l.Info(bg, "hi")
+	l.Info(bg, "hi")
+	assert.Equal(t, "msg", "hi", (<-cs).Message)
+
+	// The summary is logged by the timer without another entry.
+	select {
+	case ent := <-cs:
+		assert.Equal(t, "summary", slog.M(slog.F("dropped", 1)), ent.Fields)
+	case <-time.After(time.Second * 5):
+		t.Fatal("summary was not logged when the interval elapsed")
+	}
+}
+
+func TestDefaultRate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(0, 0)
+	fs := &fakeSink{}
+	s := slograte.Sink(fs, nil)
+	slograte.SetNow(s, func() time.Time {
+		return now
+	})
+	l := slog.Make(s)
+
+	for i := 0; i < 20; i++ {
+		l.Info(bg, "hi")
+	}
+	assert.Len(t, "entries", 10, fs.entries)
+
+	// The bucket refills.
+	now = now.Add(time.Second)
+	l.Info(bg, "hi")
+	assert.Len(t, "entries", 12, fs.entries)
+}
@@ -0,0 +1,208 @@
+// Package slogredact contains a slogger that masks sensitive
+// field values before passing entries on to another sink.
+package slogredact // import "cdr.dev/slog/sloggers/slogredact"
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/entryjson"
+)
+
+// DefaultKeys are the field name patterns redacted by default.
+var DefaultKeys = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)passw(or)?d`),
+	regexp.MustCompile(`(?i)secret`),
+	regexp.MustCompile(`(?i)token`),
+	regexp.MustCompile(`(?i)api_?key`),
+	regexp.MustCompile(`(?i)authorization`),
+	regexp.MustCompile(`(?i)cookie`),
+	regexp.MustCompile(`(?i)(credit_?)?card_?number`),
+	regexp.MustCompile(`(?i)^ssn$`),
+}
+
+// DefaultValues are the value patterns redacted by default.
+var DefaultValues = []*regexp.Regexp{
+	// Credit card numbers.
+	regexp.MustCompile(`\b(?:\d{4}[ -]?){3}\d{4}\b`),
+	regexp.MustCompile(`\b3[47]\d{2}[ -]?\d{6}[ -]?\d{5}\b`),
+	// Bearer tokens.
+	regexp.MustCompile(`(?i)\bbearer\s+[a-z0-9\-._~+/]+=*`),
+	// AWS access key IDs.
+	regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+	// JSON web tokens.
+	regexp.MustCompile(`\beyJ[a-zA-Z0-9_-]+\.[a-zA-Z0-9_-]+\.[a-zA-Z0-9_-]+`),
+	// Passwords in URLs.
+	regexp.MustCompile(`://[^/\s:@]+:([^/\s@]+)@`),
+}
+
+// Options represents the options for the sink returned by Sink.
+type Options struct {
+	// Keys are matched against field names at every level of nesting.
+	// The entire value of a matching field is replaced with Mask.
+	//
+	// Defaults to DefaultKeys. Set to an empty slice to disable.
+	Keys []*regexp.Regexp
+
+	// Values are matched against the message and every string value.
+	// Each match is replaced with Mask. If a pattern has a capturing
+	// group, only the text matched by the first group is replaced.
+	//
+	// Defaults to DefaultValues. Set to an empty slice to disable.
+	Values []*regexp.Regexp
+
+	// Mask replaces redacted values.
+	//
+	// Defaults to "[REDACTED]".
+	Mask string
+}
+
+// Sink creates a slog.Sink that redacts sensitive values from
+// every entry before passing it to s.
+//
+// Values that are not a slog.Map, string or number are encoded
+// to JSON first so that keys nested inside structs and maps
+// are redacted as well.
+func Sink(s slog.Sink, opts *Options) slog.Sink {
+	if opts == nil {
+		opts = &Options{}
+	}
+	r := &redactSink{
+		s:      s,
+		keys:   opts.Keys,
+		values: opts.Values,
+		mask:   opts.Mask,
+	}
+	if r.keys == nil {
+		r.keys = DefaultKeys
+	}
+	if r.values == nil {
+		r.values = DefaultValues
+	}
+	if r.mask == "" {
+		r.mask = "[REDACTED]"
+	}
+	return r
+}
+
+type redactSink struct {
+	s      slog.Sink
+	keys   []*regexp.Regexp
+	values []*regexp.Regexp
+	mask   string
+}
+
+func (s *redactSink) LogEntry(ctx context.Context, ent slog.SinkEntry) {
+	ent.Message = s.redactString(ent.Message)
+	ent.Fields = s.redactMap(ent.Fields)
+	s.s.LogEntry(ctx, ent)
+}
+
+func (s *redactSink) Sync() {
+	s.s.Sync()
+}
+
+func (s *redactSink) Close() error {
+	if c, ok := s.s.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func (s *redactSink) redactMap(m slog.Map) slog.Map {
+	if m == nil {
+		return nil
+	}
+	m2 := make(slog.Map, 0, len(m))
+	for _, f := range m {
+		if s.matchKey(f.Name) {
+			m2 = append(m2, slog.F(f.Name, s.mask))
+			continue
+		}
+		m2 = append(m2, slog.F(f.Name, s.redactValue(f.Any())))
+	}
+	return m2
+}
+
+func (s *redactSink) redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case nil, bool, json.RawMessage, json.Number:
+		return v
+	case string:
+		return s.redactString(v)
+	case slog.Map:
+		return s.redactMap(v)
+	case entryjson.Object:
+		m := make(slog.Map, 0, len(v))
+		for _, f := range v {
+			m = append(m, slog.F(f.Key, f.Value))
+		}
+		return s.redactMap(m)
+	case []interface{}:
+		v2 := make([]interface{}, len(v))
+		for i, e := range v {
+			v2[i] = s.redactValue(e)
+		}
+		return v2
+	}
+
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return v
+	}
+
+	// Walk the encoded form of everything else.
+	b, err := slog.M(slog.F("v", v)).MarshalJSON()
+	if err != nil {
+		return s.mask
+	}
+	tree, err := entryjson.Decode(b)
+	if err != nil {
+		return s.mask
+	}
+	obj, ok := tree.(entryjson.Object)
+	if !ok || len(obj) != 1 {
+		return s.mask
+	}
+	return s.redactValue(obj[0].Value)
+}
+
+func (s *redactSink) matchKey(k string) bool {
+	for _, re := range s.keys {
+		if re.MatchString(k) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *redactSink) redactString(v string) string {
+	for _, re := range s.values {
+		if re.NumSubexp() == 0 {
+			v = re.ReplaceAllLiteralString(v, s.mask)
+			continue
+		}
+
+		var b strings.Builder
+		last := 0
+		for _, loc := range re.FindAllStringSubmatchIndex(v, -1) {
+			if loc[2] < 0 {
+				continue
+			}
+			b.WriteString(v[last:loc[2]])
+			b.WriteString(s.mask)
+			last = loc[3]
+		}
+		b.WriteString(v[last:])
+		v = b.String()
+	}
+	return v
+}
@@ -0,0 +1,173 @@
+// Package slogring contains a slogger that keeps the most recent
+// entries in memory and dumps them to another sink on demand.
+//
+// It works like a flight recorder. Log everything including debug
+// entries to the ring and only the important entries to the regular
+// sink. When something goes wrong, the ring provides the context that
+// led up to it without the cost of always persisting debug logs:
+//
+//	f, err := os.Create("crash.log")
+//	if err != nil {
+//		return err
+//	}
+//	defer f.Close()
+//	ring := slogring.New(slogjson.Sink(f), nil)
+//	s := slog.LevelFilter(sloghuman.Sink(os.Stderr), slog.LevelInfo)
+//	l := slog.Make(s, ring).Leveled(slog.LevelDebug)
+//	defer l.Close()
+//	defer ring.DumpOnPanic(ctx)
+//
+// Dump to a separate sink rather than the regular one as the entries
+// at or above Info would otherwise be written twice. It also keeps
+// l.Close safe as closing the ring closes the sink it dumps to, which
+// would otherwise be closed twice. The writer sinks like slogjson do
+// not close their writer so f is closed separately.
+package slogring // import "cdr.dev/slog/sloggers/slogring"
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"cdr.dev/slog"
+)
+
+// Options represents the options for the Ring returned by New.
+type Options struct {
+	// Size is the number of entries kept.
+	//
+	// Defaults to 1000.
+	Size int
+
+	// DumpLevel is the level at which logging an entry dumps
+	// the ring including the entry itself. It is a pointer so
+	// that slog.LevelDebug can be distinguished from unset.
+	//
+	// Defaults to slog.LevelError.
+	DumpLevel *slog.Level
+
+	// ManualDump disables dumping on DumpLevel so that
+	// the ring is only dumped by Dump and DumpOnPanic.
+	ManualDump bool
+}
+
+// Ring is a slog.Sink that keeps the most recent entries
+// in memory and writes them to another sink when dumped.
+//
+// Entries are not written anywhere until the ring is dumped.
+// Combine it with a regular sink in slog.Make.
+type Ring struct {
+	dst        slog.Sink
+	dumpLevel  slog.Level
+	manualDump bool
+
+	mu sync.Mutex
+	// ents is a circular buffer starting at start.
+	ents  []slog.SinkEntry
+	start int
+	n     int
+}
+
+// New creates a Ring that dumps to dst.
+func New(dst slog.Sink, opts *Options) *Ring {
+	if opts == nil {
+		opts = &Options{}
+	}
+	size := opts.Size
+	if size <= 0 {
+		size = 1000
+	}
+	r := &Ring{
+		dst:        dst,
+		dumpLevel:  slog.LevelError,
+		manualDump: opts.ManualDump,
+		ents:       make([]slog.SinkEntry, size),
+	}
+	if opts.DumpLevel != nil {
+		r.dumpLevel = *opts.DumpLevel
+	}
+	return r
+}
+
+// LogEntry records ent, overwriting the oldest entry if the
+// ring is full. It dumps the ring if ent is at or above DumpLevel.
+func (r *Ring) LogEntry(ctx context.Context, ent slog.SinkEntry) {
+	r.mu.Lock()
+	r.ents[(r.start+r.n)%len(r.ents)] = ent
+	if r.n < len(r.ents) {
+		r.n++
+	} else {
+		r.start = (r.start + 1) % len(r.ents)
+	}
+	r.mu.Unlock()
+
+	if !r.manualDump && ent.Level >= r.dumpLevel {
+		r.Dump(ctx)
+	}
+}
+
+// Sync is a no-op as the entries are only in memory.
+func (r *Ring) Sync() {}
+
+// Close closes the sink being dumped to if it implements io.Closer.
+// The ring is not dumped.
+func (r *Ring) Close() error {
+	if c, ok := r.dst.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// Entries returns the recorded entries from oldest to newest.
+func (r *Ring) Entries() []slog.SinkEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.entries()
+}
+
+func (r *Ring) entries() []slog.SinkEntry {
+	ents := make([]slog.SinkEntry, r.n)
+	for i := range ents {
+		ents[i] = r.ents[(r.start+i)%len(r.ents)]
+	}
+	return ents
+}
+
+// Dump writes the recorded entries from oldest to newest to the
+// destination sink, syncs it and empties the ring.
+func (r *Ring) Dump(ctx context.Context) {
+	r.mu.Lock()
+	ents := r.entries()
+	for i := range r.ents {
+		r.ents[i] = slog.SinkEntry{}
+	}
+	r.start = 0
+	r.n = 0
+	r.mu.Unlock()
+
+	if len(ents) == 0 {
+		return
+	}
+	if bs, ok := r.dst.(slog.BatchSink); ok {
+		bs.LogEntries(ctx, ents)
+	} else {
+		for _, ent := range ents {
+			r.dst.LogEntry(ctx, ent)
+		}
+	}
+	r.dst.Sync()
+}
+
+// DumpOnPanic dumps the ring if the goroutine is panicking and then
+// continues panicking. It must be deferred directly:
+//
+//	defer ring.DumpOnPanic(ctx)
+func (r *Ring) DumpOnPanic(ctx context.Context) {
+	p := recover()
+	if p == nil {
+		return
+	}
+	r.Dump(ctx)
+	panic(p)
+}
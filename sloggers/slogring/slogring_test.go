@@ -0,0 +1,106 @@
+package slogring_test
+
+import (
+	"context"
+	"testing"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+	"cdr.dev/slog/sloggers/slogring"
+)
+
+var bg = context.Background()
+
+type fakeSink struct {
+	entries []slog.SinkEntry
+	syncs   int
+}
+
+func (s *fakeSink) LogEntry(_ context.Context, e slog.SinkEntry) {
+	s.entries = append(s.entries, e)
+}
+
+func (s *fakeSink) Sync() {
+	s.syncs++
+}
+
+func messages(ents []slog.SinkEntry) []string {
+	var msgs []string
+	for _, ent := range ents {
+		msgs = append(msgs, ent.Message)
+	}
+	return msgs
+}
+
+func TestRing(t *testing.T) {
+	t.Parallel()
+
+	s := &fakeSink{}
+	r := slogring.New(s, &slogring.Options{
+		Size: 3,
+	})
+	l := slog.Make(r).Leveled(slog.LevelDebug)
+
+	l.Debug(bg, "1")
+	l.Debug(bg, "2")
+	l.Info(bg, "3")
+	l.Warn(bg, "4")
+	assert.Len(t, "entries", 0, s.entries)
+	assert.Equal(t, "ring", []string{"2", "3", "4"}, messages(r.Entries()))
+
+	l.Error(bg, "5")
+	assert.Equal(t, "dumped", []string{"3", "4", "5"}, messages(s.entries))
+	assert.Equal(t, "syncs", 1, s.syncs)
+	assert.Len(t, "ring", 0, r.Entries())
+
+	l.Debug(bg, "6")
+	r.Dump(bg)
+	assert.Equal(t, "dumped", []string{"3", "4", "5", "6"}, messages(s.entries))
+
+	// Dumping an empty ring does nothing.
+	r.Dump(bg)
+	assert.Equal(t, "syncs", 2, s.syncs)
+}
+
+func TestRingDumpLevel(t *testing.T) {
+	t.Parallel()
+
+	s := &fakeSink{}
+	lvl := slog.LevelDebug
+	r := slogring.New(s, &slogring.Options{
+		DumpLevel: &lvl,
+	})
+	l := slog.Make(r).Leveled(slog.LevelDebug)
+
+	l.Debug(bg, "1")
+	assert.Equal(t, "dumped", []string{"1"}, messages(s.entries))
+}
+
+func TestRingManualDump(t *testing.T) {
+	t.Parallel()
+
+	s := &fakeSink{}
+	r := slogring.New(s, &slogring.Options{
+		ManualDump: true,
+	})
+	l := slog.Make(r)
+
+	l.Error(bg, "1")
+	assert.Len(t, "entries", 0, s.entries)
+
+	func() {
+		defer func() {
+			assert.Equal(t, "panic", "boom", recover())
+		}()
+		defer r.DumpOnPanic(bg)
+		panic("boom")
+	}()
+	assert.Equal(t, "dumped", []string{"1"}, messages(s.entries))
+
+	// No panic, no dump.
+	l.Info(bg, "2")
+	func() {
+		defer r.DumpOnPanic(bg)
+	}()
+	assert.Len(t, "entries", 1, s.entries)
+}
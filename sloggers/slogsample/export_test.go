@@ -0,0 +1,11 @@
+package slogsample
+
+import (
+	"time"
+
+	"cdr.dev/slog"
+)
+
+func SetNow(s slog.Sink, now func() time.Time) {
+	s.(*sampleSink).now = now
+}
@@ -0,0 +1,124 @@
+// Package slogsample contains a slogger that samples repeated
+// entries to limit the volume of hot path logs.
+package slogsample // import "cdr.dev/slog/sloggers/slogsample"
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"sync"
+	"time"
+
+	"cdr.dev/slog"
+)
+
+// Options represents the options for the sink returned by Sink.
+type Options struct {
+	// Tick is the interval over which entries are counted.
+	//
+	// Defaults to time.Second.
+	Tick time.Duration
+
+	// First is the number of entries with the same level and
+	// message that are logged every Tick before sampling begins.
+	First int
+
+	// Thereafter causes every Thereafter-th entry after First
+	// to be logged. If zero, all entries after First are dropped.
+	Thereafter int
+
+	// Probability, if set, is the chance that an entry after First
+	// is logged. It replaces Thereafter.
+	Probability float64
+}
+
+// Sink creates a slog.Sink that samples the entries passed to s.
+//
+// Entries are keyed by their level and message. Within every Tick,
+// the first First entries of a key are logged and then every
+// Thereafter-th entry or, if Probability is set, a random sample
+// of entries.
+func Sink(s slog.Sink, opts *Options) slog.Sink {
+	if opts == nil {
+		opts = &Options{}
+	}
+	ss := &sampleSink{
+		s:           s,
+		tick:        opts.Tick,
+		first:       uint64(opts.First),
+		thereafter:  uint64(opts.Thereafter),
+		probability: opts.Probability,
+		counts:      make(map[sampleKey]uint64),
+		now:         time.Now,
+	}
+	if ss.tick <= 0 {
+		ss.tick = time.Second
+	}
+	return ss
+}
+
+type sampleKey struct {
+	level slog.Level
+	msg   string
+}
+
+type sampleSink struct {
+	s           slog.Sink
+	tick        time.Duration
+	first       uint64
+	thereafter  uint64
+	probability float64
+
+	mu          sync.Mutex
+	windowStart time.Time
+	counts      map[sampleKey]uint64
+
+	now func() time.Time
+}
+
+func (s *sampleSink) LogEntry(ctx context.Context, ent slog.SinkEntry) {
+	if !s.sample(ent) {
+		return
+	}
+	s.s.LogEntry(ctx, ent)
+}
+
+func (s *sampleSink) Sync() {
+	s.s.Sync()
+}
+
+func (s *sampleSink) Close() error {
+	if c, ok := s.s.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func (s *sampleSink) sample(ent slog.SinkEntry) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.windowStart) >= s.tick {
+		s.windowStart = now
+		s.counts = make(map[sampleKey]uint64, len(s.counts))
+	}
+
+	k := sampleKey{
+		level: ent.Level,
+		msg:   ent.Message,
+	}
+	s.counts[k]++
+	n := s.counts[k]
+
+	switch {
+	case n <= s.first:
+		return true
+	case s.probability > 0:
+		return rand.Float64() < s.probability
+	case s.thereafter > 0:
+		return (n-s.first)%s.thereafter == 0
+	default:
+		return false
+	}
+}
@@ -0,0 +1,67 @@
+package slogsample_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+	"cdr.dev/slog/sloggers/slogsample"
+)
+
+var bg = context.Background()
+
+type fakeSink struct {
+	entries []slog.SinkEntry
+}
+
+func (s *fakeSink) LogEntry(_ context.Context, e slog.SinkEntry) {
+	s.entries = append(s.entries, e)
+}
+
+func (s *fakeSink) Sync() {}
+
+func TestSink(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(0, 0)
+	fs := &fakeSink{}
+	s := slogsample.Sink(fs, &slogsample.Options{
+		First:      2,
+		Thereafter: 3,
+	})
+	slogsample.SetNow(s, func() time.Time {
+		return now
+	})
+	l := slog.Make(s)
+
+	for i := 0; i < 10; i++ {
+		l.Info(bg, "hot")
+	}
+	l.Info(bg, "cold")
+	l.Warn(bg, "hot")
+
+	// 2 of the first, then the 5th and 8th.
+	assert.Len(t, "entries", 6, fs.entries)
+
+	now = now.Add(time.Second)
+	l.Info(bg, "hot")
+	assert.Len(t, "entries", 7, fs.entries)
+}
+
+func TestProbability(t *testing.T) {
+	t.Parallel()
+
+	fs := &fakeSink{}
+	l := slog.Make(slogsample.Sink(fs, &slogsample.Options{
+		Tick:        time.Hour,
+		First:       1,
+		Probability: 1,
+	}))
+
+	for i := 0; i < 5; i++ {
+		l.Info(bg, "hot")
+	}
+	assert.Len(t, "entries", 5, fs.entries)
+}
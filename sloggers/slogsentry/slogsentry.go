@@ -0,0 +1,281 @@
+// Package slogsentry contains the slogger that forwards
+// errors to Sentry.
+//
+// See https://develop.sentry.dev/sdk/data-model/event-payloads/
+package slogsentry // import "cdr.dev/slog/sloggers/slogsentry"
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opencensus.io/trace"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/errorchain"
+	"cdr.dev/slog/internal/httpretry"
+)
+
+// Options represents the options for the sink returned by Sink.
+type Options struct {
+	// DSN is the client key of the Sentry project,
+	// e.g. https://<key>@o0.ingest.sentry.io/<project>.
+	DSN string
+
+	// Level is the minimum level of the entries sent to Sentry.
+	//
+	// Defaults to slog.LevelError.
+	Level slog.Level
+
+	// Environment and Release are set on every event,
+	// e.g. production and the version of the binary.
+	Environment string
+	Release     string
+
+	// ServerName defaults to os.Hostname.
+	ServerName string
+
+	// Tags are set on every event.
+	Tags map[string]string
+
+	// Client defaults to a client with a 30 second timeout.
+	Client *http.Client
+
+	// MaxRetries is the maximum number of retries of an event
+	// that fails with a network error, 429 or 5xx.
+	//
+	// Defaults to 3.
+	MaxRetries int
+
+	// Backoff is the delay before the first retry. It doubles
+	// for every following retry.
+	//
+	// Defaults to 500ms.
+	Backoff time.Duration
+}
+
+// Sink creates a slog.Sink that passes every entry to s and also
+// sends the entries at or above Level to Sentry as events.
+//
+// The message is the event's message and the fields are its extra
+// data. The first field that holds an error is removed from the
+// extra data and its chain becomes the event's exceptions with the
+// location of every error created with xerrors as a stack frame.
+// The trace and span of the entry become the trace context.
+//
+// Events are sent before LogEntry returns as errors are rare and
+// Error and Critical sync the sink anyway.
+//
+// The returned sink implements io.Closer. Close closes s if it
+// implements io.Closer.
+func Sink(s slog.Sink, opts *Options) (slog.Sink, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	dsn, err := url.Parse(opts.DSN)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to parse DSN: %w", err)
+	}
+	key := dsn.User.Username()
+	i := strings.LastIndexByte(dsn.Path, '/')
+	project := dsn.Path[i+1:]
+	if dsn.Scheme == "" || dsn.Host == "" || key == "" || project == "" {
+		return nil, xerrors.Errorf("invalid DSN %q", opts.DSN)
+	}
+
+	ss := &sentrySink{
+		s:           s,
+		level:       opts.Level,
+		environment: opts.Environment,
+		release:     opts.Release,
+		serverName:  opts.ServerName,
+		tags:        opts.Tags,
+		url:         dsn.Scheme + "://" + dsn.Host + dsn.Path[:i] + "/api/" + project + "/store/",
+		header: http.Header{
+			"Content-Type":  []string{"application/json"},
+			"X-Sentry-Auth": []string{"Sentry sentry_version=7, sentry_client=cdr.dev/slog, sentry_key=" + key},
+		},
+		opts: httpretry.Options{
+			Client:     opts.Client,
+			MaxRetries: opts.MaxRetries,
+			Backoff:    opts.Backoff,
+		},
+	}
+	if ss.level == 0 {
+		ss.level = slog.LevelError
+	}
+	if ss.serverName == "" {
+		ss.serverName, _ = os.Hostname()
+	}
+	return ss, nil
+}
+
+type sentrySink struct {
+	s           slog.Sink
+	level       slog.Level
+	environment string
+	release     string
+	serverName  string
+	tags        map[string]string
+	url         string
+	header      http.Header
+	opts        httpretry.Options
+}
+
+func (s *sentrySink) LogEntry(ctx context.Context, ent slog.SinkEntry) {
+	s.s.LogEntry(ctx, ent)
+	if ent.Level < s.level {
+		return
+	}
+
+	body, err := json.Marshal(s.event(ent))
+	if err != nil {
+		slog.ReportError(ctx, xerrors.Errorf("slogsentry: failed to marshal event: %w", err), ent)
+		return
+	}
+	_, err = httpretry.Do(ctx, s.opts, http.MethodPost, s.url, s.header, body)
+	if err != nil {
+		slog.ReportError(ctx, xerrors.Errorf("slogsentry: failed to send event: %w", err), ent)
+	}
+}
+
+type event struct {
+	EventID     string                 `json:"event_id"`
+	Timestamp   string                 `json:"timestamp"`
+	Platform    string                 `json:"platform"`
+	Level       string                 `json:"level"`
+	Logger      string                 `json:"logger,omitempty"`
+	Message     string                 `json:"message"`
+	Culprit     string                 `json:"culprit,omitempty"`
+	ServerName  string                 `json:"server_name,omitempty"`
+	Environment string                 `json:"environment,omitempty"`
+	Release     string                 `json:"release,omitempty"`
+	Tags        map[string]string      `json:"tags,omitempty"`
+	Extra       slog.Map               `json:"extra,omitempty"`
+	Exception   *exceptions            `json:"exception,omitempty"`
+	Contexts    map[string]interface{} `json:"contexts,omitempty"`
+}
+
+type exceptions struct {
+	Values []exception `json:"values"`
+}
+
+type exception struct {
+	Type       string      `json:"type"`
+	Value      string      `json:"value"`
+	Stacktrace *stacktrace `json:"stacktrace,omitempty"`
+}
+
+type stacktrace struct {
+	Frames []frame `json:"frames"`
+}
+
+type frame struct {
+	Function string `json:"function"`
+	Filename string `json:"filename,omitempty"`
+	Lineno   int    `json:"lineno,omitempty"`
+	InApp    bool   `json:"in_app"`
+}
+
+func (s *sentrySink) event(ent slog.SinkEntry) event {
+	var id [16]byte
+	rand.Read(id[:])
+
+	ev := event{
+		EventID:     hex.EncodeToString(id[:]),
+		Timestamp:   ent.Time.UTC().Format(time.RFC3339Nano),
+		Platform:    "go",
+		Level:       level(ent.Level),
+		Logger:      strings.Join(ent.LoggerNames, "."),
+		Message:     ent.Message,
+		Culprit:     ent.Func,
+		ServerName:  s.serverName,
+		Environment: s.environment,
+		Release:     s.release,
+		Tags:        s.tags,
+	}
+
+	ev.Extra = make(slog.Map, 0, len(ent.Fields))
+	for _, f := range ent.Fields {
+		err, ok := f.Any().(error)
+		if !ok || ev.Exception != nil {
+			ev.Extra = append(ev.Extra, f)
+			continue
+		}
+		ev.Exception = &exceptions{
+			Values: exceptionValues(err),
+		}
+	}
+
+	if ent.SpanContext != (trace.SpanContext{}) {
+		ev.Contexts = map[string]interface{}{
+			"trace": map[string]string{
+				"trace_id": ent.SpanContext.TraceID.String(),
+				"span_id":  ent.SpanContext.SpanID.String(),
+			},
+		}
+	}
+	return ev
+}
+
+// exceptionValues returns the chain of err with the innermost
+// cause first as Sentry expects.
+func exceptionValues(err error) []exception {
+	causes := errorchain.Causes(err)
+	values := make([]exception, len(causes))
+	for i, c := range causes {
+		ex := exception{
+			Type:  c.Type,
+			Value: c.Msg,
+		}
+		if c.Func != "" {
+			f := frame{
+				Function: c.Func,
+				InApp:    true,
+			}
+			f.Filename = c.Loc
+			if j := strings.LastIndexByte(c.Loc, ':'); j > 0 {
+				f.Filename = c.Loc[:j]
+				f.Lineno, _ = strconv.Atoi(c.Loc[j+1:])
+			}
+			ex.Stacktrace = &stacktrace{Frames: []frame{f}}
+		}
+		values[len(causes)-1-i] = ex
+	}
+	return values
+}
+
+func level(l slog.Level) string {
+	switch {
+	case l < slog.LevelInfo:
+		return "debug"
+	case l < slog.LevelWarn:
+		return "info"
+	case l < slog.LevelError:
+		return "warning"
+	case l < slog.LevelCritical:
+		return "error"
+	default:
+		return "fatal"
+	}
+}
+
+func (s *sentrySink) Sync() {
+	s.s.Sync()
+}
+
+func (s *sentrySink) Close() error {
+	if c, ok := s.s.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
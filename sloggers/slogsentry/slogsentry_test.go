@@ -0,0 +1,121 @@
+package slogsentry_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opencensus.io/trace"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+	"cdr.dev/slog/sloggers/slogsentry"
+)
+
+var bg = context.Background()
+
+var kt = time.Date(2000, time.February, 5, 4, 4, 4, 123456789, time.UTC)
+
+type fakeSink struct {
+	entries []slog.SinkEntry
+}
+
+func (s *fakeSink) LogEntry(_ context.Context, ent slog.SinkEntry) {
+	s.entries = append(s.entries, ent)
+}
+
+func (s *fakeSink) Sync() {}
+
+func TestSink(t *testing.T) {
+	t.Parallel()
+
+	events := make(chan map[string]interface{}, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "path", "/sub/api/42/store/", r.URL.Path)
+		assert.True(t, "auth", strings.HasSuffix(r.Header.Get("X-Sentry-Auth"), "sentry_key=public"))
+
+		b, err := ioutil.ReadAll(r.Body)
+		assert.Success(t, "read body", err)
+		var ev map[string]interface{}
+		err = json.Unmarshal(b, &ev)
+		assert.Success(t, "unmarshal", err)
+		events <- ev
+	}))
+	defer srv.Close()
+
+	fs := &fakeSink{}
+	s, err := slogsentry.Sink(fs, &slogsentry.Options{
+		DSN:         strings.Replace(srv.URL, "://", "://public@", 1) + "/sub/42",
+		Environment: "test",
+		ServerName:  "host",
+	})
+	assert.Success(t, "sink", err)
+
+	s.LogEntry(bg, slog.SinkEntry{Level: slog.LevelWarn, Message: "skipped"})
+
+	err = xerrors.Errorf("failed to query: %w", io.EOF)
+	s.LogEntry(bg, slog.SinkEntry{
+		Time:        kt,
+		Level:       slog.LevelError,
+		LoggerNames: []string{"comp", "db"},
+		Message:     "request failed",
+		Func:        "main.handle",
+		SpanContext: trace.SpanContext{TraceID: trace.TraceID{1}, SpanID: trace.SpanID{2}},
+		Fields: slog.M(
+			slog.F("id", 1),
+			slog.Error(err),
+		),
+	})
+
+	assert.Len(t, "entries", 2, fs.entries)
+
+	ev := <-events
+	assert.Len(t, "event id", 32, ev["event_id"])
+	delete(ev, "event_id")
+	exs := ev["exception"].(map[string]interface{})["values"].([]interface{})
+	delete(ev, "exception")
+	assert.Equal(t, "event", map[string]interface{}{
+		"timestamp":   "2000-02-05T04:04:04.123456789Z",
+		"platform":    "go",
+		"level":       "error",
+		"logger":      "comp.db",
+		"message":     "request failed",
+		"culprit":     "main.handle",
+		"server_name": "host",
+		"environment": "test",
+		"extra":       map[string]interface{}{"id": float64(1)},
+		"contexts": map[string]interface{}{
+			"trace": map[string]interface{}{
+				"trace_id": "01000000000000000000000000000000",
+				"span_id":  "0200000000000000",
+			},
+		},
+	}, ev)
+
+	assert.Len(t, "exceptions", 2, exs)
+	assert.Equal(t, "exception", map[string]interface{}{
+		"type":  "*errors.errorString",
+		"value": "EOF",
+	}, exs[0])
+	ex := exs[1].(map[string]interface{})
+	assert.Equal(t, "type", "*xerrors.wrapError", ex["type"])
+	assert.Equal(t, "value", "failed to query", ex["value"])
+	frame := ex["stacktrace"].(map[string]interface{})["frames"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "function", "cdr.dev/slog/sloggers/slogsentry_test.TestSink", frame["function"])
+	assert.True(t, "filename", strings.HasSuffix(frame["filename"].(string), "slogsentry_test.go"))
+	assert.Equal(t, "lineno", float64(63), frame["lineno"])
+}
+
+func TestSink_invalidDSN(t *testing.T) {
+	t.Parallel()
+
+	_, err := slogsentry.Sink(&fakeSink{}, &slogsentry.Options{DSN: "https://sentry.io/42"})
+	assert.Error(t, "sink", err)
+}
@@ -0,0 +1,248 @@
+// Package slogsiem contains the slogger that writes logs in the
+// ArcSight Common Event Format or the QRadar Log Event Extended
+// Format for ingestion into SIEMs.
+//
+// Format
+//
+//	CEF:0|Acme|Billing|1.2|auth|login failed|7|rt=971211336000 msg=login failed suser=frank attempts=3
+//	LEEF:1.0|Acme|Billing|1.2|auth|devTime=Oct 10 2000 20:55:36.000 UTC	sev=7	msg=login failed	usrName=frank	attempts=3
+//
+// The fields of an entry are flattened into extensions with dotted
+// keys. Options.Keys maps them to the predefined keys of the format.
+//
+// Write the lines to a syslog connection to send them to a SIEM.
+//
+// See https://www.microfocus.com/documentation/arcsight/arcsight-smartconnectors/pdfdoc/common-event-format-v25/common-event-format-v25.pdf
+// and https://www.ibm.com/docs/en/dsm?topic=leef-overview
+package slogsiem // import "cdr.dev/slog/sloggers/slogsiem"
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/entryjson"
+	"cdr.dev/slog/internal/syncwriter"
+)
+
+// Format is the format of the lines.
+type Format int
+
+// The supported formats.
+const (
+	// FormatCEF is the ArcSight Common Event Format version 0.
+	FormatCEF Format = iota
+	// FormatLEEF is the QRadar Log Event Extended Format version 1.0.
+	FormatLEEF
+)
+
+// Options represents the options for the sink returned by Sink.
+type Options struct {
+	// Format defaults to FormatCEF.
+	Format Format
+
+	// Vendor, Product and Version identify the application
+	// in the header.
+	//
+	// Product defaults to the name of the executable.
+	Vendor  string
+	Product string
+	Version string
+
+	// EventID returns the signature ID of CEF or the event ID of
+	// LEEF of an entry which identifies the type of the event.
+	//
+	// Defaults to the dotted logger names of the entry or "log"
+	// if it has none.
+	EventID func(ent slog.SinkEntry) string
+
+	// Keys maps flattened field names to extension keys,
+	// e.g. "user" to "suser" for CEF or "usrName" for LEEF.
+	// Fields that are not mapped keep their names with the
+	// characters not allowed in keys replaced by _.
+	Keys map[string]string
+}
+
+// Sink creates a slog.Sink that writes CEF or LEEF formatted
+// lines to the given writer.
+//
+// If the writer implements Sync() error then
+// it will be called when syncing.
+func Sink(w io.Writer, opts *Options) slog.Sink {
+	if opts == nil {
+		opts = &Options{}
+	}
+	s := siemSink{
+		w:       syncwriter.New(w),
+		format:  opts.Format,
+		eventID: opts.EventID,
+		keys:    opts.Keys,
+	}
+	if s.eventID == nil {
+		s.eventID = defaultEventID
+	}
+
+	product := opts.Product
+	if product == "" {
+		exe, _ := os.Executable()
+		product = strings.TrimSuffix(filepath.Base(exe), filepath.Ext(exe))
+	}
+	for _, v := range []string{opts.Vendor, product, opts.Version} {
+		s.header = appendHeader(s.header, v)
+	}
+	return s
+}
+
+func defaultEventID(ent slog.SinkEntry) string {
+	if len(ent.LoggerNames) == 0 {
+		return "log"
+	}
+	return strings.Join(ent.LoggerNames, ".")
+}
+
+type siemSink struct {
+	w       *syncwriter.Writer
+	format  Format
+	eventID func(ent slog.SinkEntry) string
+	keys    map[string]string
+	// header holds the escaped vendor, product and version.
+	header []byte
+}
+
+func (s siemSink) LogEntry(ctx context.Context, ent slog.SinkEntry) {
+	b := make([]byte, 0, 256)
+	if s.format == FormatLEEF {
+		b = append(b, "LEEF:1.0|"...)
+	} else {
+		b = append(b, "CEF:0|"...)
+	}
+	b = append(b, s.header...)
+	b = appendHeader(b, s.eventID(ent))
+
+	sev := strconv.Itoa(severity(ent.Level))
+	var ext []byte
+	if s.format == FormatLEEF {
+		ext = s.appendExtension(ext, "devTime", ent.Time.UTC().Format("Jan 02 2006 15:04:05.000 MST"))
+		ext = s.appendExtension(ext, "sev", sev)
+	} else {
+		b = appendHeader(b, ent.Message)
+		b = appendHeader(b, sev)
+		ext = s.appendExtension(ext, "rt", strconv.FormatInt(ent.Time.UnixNano()/1e6, 10))
+	}
+	ext = s.appendExtension(ext, "msg", ent.Message)
+
+	for _, f := range entryjson.Flatten(ent.Fields, ".") {
+		key, ok := s.keys[f.Key]
+		if !ok {
+			key = sanitizeKey(f.Key)
+		}
+		ext = s.appendExtension(ext, key, formatValue(f.Value))
+	}
+	b = append(b, ext...)
+	b = append(b, '\n')
+
+	err := s.w.Write(ctx, "slogsiem", b)
+	if err != nil {
+		slog.ReportError(ctx, err, ent)
+	}
+}
+
+func (s siemSink) Sync() {
+	s.w.Sync("slogsiem")
+}
+
+// severity maps levels to the severity from 0 to 10.
+func severity(l slog.Level) int {
+	switch {
+	case l < slog.LevelInfo:
+		return 1
+	case l < slog.LevelWarn:
+		return 3
+	case l < slog.LevelError:
+		return 5
+	case l < slog.LevelCritical:
+		return 7
+	case l < slog.LevelFatal:
+		return 9
+	default:
+		return 10
+	}
+}
+
+// appendHeader appends a header field followed by a pipe.
+func appendHeader(b []byte, v string) []byte {
+	for i := 0; i < len(v); i++ {
+		switch c := v[i]; c {
+		case '\\', '|':
+			b = append(b, '\\', c)
+		case '\n', '\r':
+			b = append(b, ' ')
+		default:
+			b = append(b, c)
+		}
+	}
+	return append(b, '|')
+}
+
+// appendExtension appends a key value pair to the extensions in b
+// separated by a space with CEF and a tab with LEEF.
+func (s siemSink) appendExtension(b []byte, key, v string) []byte {
+	if len(b) > 0 {
+		if s.format == FormatLEEF {
+			b = append(b, '\t')
+		} else {
+			b = append(b, ' ')
+		}
+	}
+	b = append(b, key...)
+	b = append(b, '=')
+
+	for i := 0; i < len(v); i++ {
+		c := v[i]
+		if s.format == FormatLEEF {
+			// LEEF has no escaping so the delimiter
+			// and line breaks become spaces.
+			if c == '\t' || c == '\n' || c == '\r' {
+				c = ' '
+			}
+			b = append(b, c)
+			continue
+		}
+		switch c {
+		case '\\', '=':
+			b = append(b, '\\', c)
+		case '\n':
+			b = append(b, `\n`...)
+		case '\r':
+			b = append(b, `\r`...)
+		default:
+			b = append(b, c)
+		}
+	}
+	return b
+}
+
+// sanitizeKey replaces the characters that are not
+// letters, digits, dots or underscores with _.
+func sanitizeKey(k string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, k)
+}
+
+// formatValue formats a value decoded by entryjson.
+func formatValue(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return entryjson.String(v)
+}
@@ -0,0 +1,72 @@
+package slogsiem_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+	"cdr.dev/slog/sloggers/slogsiem"
+)
+
+var bg = context.Background()
+
+var kt = time.Date(2000, time.October, 10, 20, 55, 36, 0, time.UTC)
+
+var ent = slog.SinkEntry{
+	Time:        kt,
+	Level:       slog.LevelError,
+	LoggerNames: []string{"auth"},
+	Message:     "login failed",
+	Fields: slog.M(
+		slog.F("user", "frank"),
+		slog.F("attempts", 3),
+		slog.F("req", slog.M(
+			slog.F("path", "/login?a=1|b"),
+			slog.F("ua", "curl\t7\n"),
+		)),
+		slog.F("bad key", []int{1, 2}),
+	),
+}
+
+func TestSink_cef(t *testing.T) {
+	t.Parallel()
+
+	b := &bytes.Buffer{}
+	s := slogsiem.Sink(b, &slogsiem.Options{
+		Vendor:  "Acme",
+		Product: "Billing|Pro",
+		Version: "1.2",
+		Keys: map[string]string{
+			"user": "suser",
+		},
+	})
+	s.LogEntry(bg, ent)
+	s.LogEntry(bg, slog.SinkEntry{Time: kt, Level: slog.LevelInfo, Message: `a\b`})
+
+	assert.Equal(t, "cef", `CEF:0|Acme|Billing\|Pro|1.2|auth|login failed|7|rt=971211336000 msg=login failed suser=frank attempts=3 req.path=/login?a\=1|b req.ua=curl`+"\t"+`7\n bad_key=[1,2]`+"\n"+
+		`CEF:0|Acme|Billing\|Pro|1.2|log|a\\b|3|rt=971211336000 msg=a\\b`+"\n", b.String())
+}
+
+func TestSink_leef(t *testing.T) {
+	t.Parallel()
+
+	b := &bytes.Buffer{}
+	s := slogsiem.Sink(b, &slogsiem.Options{
+		Format:  slogsiem.FormatLEEF,
+		Vendor:  "Acme",
+		Product: "Billing",
+		Version: "1.2",
+		EventID: func(ent slog.SinkEntry) string {
+			return ent.Message
+		},
+		Keys: map[string]string{
+			"user": "usrName",
+		},
+	})
+	s.LogEntry(bg, ent)
+
+	assert.Equal(t, "leef", "LEEF:1.0|Acme|Billing|1.2|login failed|devTime=Oct 10 2000 20:55:36.000 UTC\tsev=7\tmsg=login failed\tusrName=frank\tattempts=3\treq.path=/login?a=1|b\treq.ua=curl 7 \tbad_key=[1,2]\n", b.String())
+}
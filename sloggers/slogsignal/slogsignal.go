@@ -0,0 +1,41 @@
+// Package slogsignal contains a helper that changes the
+// level of a logger when the process receives a signal.
+//
+// It makes it possible to debug a misbehaving process
+// without redeploying:
+//
+//	kill -USR1 <pid> # more verbose
+//	kill -USR2 <pid> # less verbose
+package slogsignal // import "cdr.dev/slog/sloggers/slogsignal"
+
+import (
+	"cdr.dev/slog"
+)
+
+var levels = []slog.Level{
+	slog.LevelDebug,
+	slog.LevelInfo,
+	slog.LevelWarn,
+	slog.LevelError,
+	slog.LevelCritical,
+	slog.LevelFatal,
+}
+
+// step returns the next standard level that is more
+// or less verbose than level.
+func step(level slog.Level, verbose bool) slog.Level {
+	if verbose {
+		for i := len(levels) - 1; i >= 0; i-- {
+			if levels[i] < level {
+				return levels[i]
+			}
+		}
+		return levels[0]
+	}
+	for _, l := range levels {
+		if l > level {
+			return l
+		}
+	}
+	return levels[len(levels)-1]
+}
@@ -0,0 +1,40 @@
+//go:build !windows
+// +build !windows
+
+package slogsignal_test
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+	"cdr.dev/slog/sloggers/slogsignal"
+)
+
+func TestNotify(t *testing.T) {
+	lvl := slog.NewAtomicLevel(slog.LevelInfo)
+	stop := slogsignal.Notify(lvl)
+	defer stop()
+
+	signal := func(sig syscall.Signal, exp slog.Level) {
+		t.Helper()
+
+		err := syscall.Kill(syscall.Getpid(), sig)
+		assert.Success(t, "kill", err)
+
+		deadline := time.Now().Add(time.Second * 5)
+		for lvl.Level() != exp && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		assert.Equal(t, "level", exp, lvl.Level())
+	}
+
+	signal(syscall.SIGUSR1, slog.LevelDebug)
+	signal(syscall.SIGUSR2, slog.LevelInfo)
+	signal(syscall.SIGUSR2, slog.LevelWarn)
+
+	lvl.Set(slog.LevelWarn + 5)
+	signal(syscall.SIGUSR1, slog.LevelWarn)
+}
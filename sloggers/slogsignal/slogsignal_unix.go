@@ -0,0 +1,44 @@
+//go:build !windows
+// +build !windows
+
+package slogsignal
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"cdr.dev/slog"
+)
+
+// Notify makes SIGUSR1 lower lvl to the next more verbose level
+// and SIGUSR2 raise it to the next less verbose level.
+//
+// Call stop to stop listening for the signals.
+//
+// On Windows, which lacks the signals, Notify does nothing.
+func Notify(lvl *slog.AtomicLevel) (stop func()) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGUSR1, syscall.SIGUSR2)
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case sig := <-c:
+				lvl.Set(step(lvl.Level(), sig == syscall.SIGUSR1))
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(c)
+			close(done)
+		})
+	}
+}
@@ -0,0 +1,10 @@
+package slogsignal
+
+import (
+	"cdr.dev/slog"
+)
+
+// Notify does nothing as Windows lacks SIGUSR1 and SIGUSR2.
+func Notify(lvl *slog.AtomicLevel) (stop func()) {
+	return func() {}
+}
@@ -0,0 +1,176 @@
+// Package slogsqlite contains the slogger that writes entries
+// into a table of a local SQLite database so that they can be
+// queried with SQL.
+//
+// It works with any SQLite driver for database/sql such as
+// github.com/mattn/go-sqlite3 or modernc.org/sqlite. The table
+// is created if it does not exist:
+//
+//	CREATE TABLE logs (
+//	  id INTEGER PRIMARY KEY,
+//	  ts TEXT NOT NULL,
+//	  level TEXT NOT NULL,
+//	  component TEXT NOT NULL,
+//	  msg TEXT NOT NULL,
+//	  caller TEXT NOT NULL,
+//	  func TEXT NOT NULL,
+//	  trace TEXT,
+//	  span TEXT,
+//	  fields TEXT NOT NULL
+//	)
+//
+// ts is the time in UTC with RFC 3339 so that it sorts and works with
+// the date functions of SQLite. fields is a JSON object so that fields
+// can be queried with json_extract, e.g.
+//
+//	SELECT ts, msg FROM logs WHERE json_extract(fields, '$.user_id') = 42
+package slogsqlite // import "cdr.dev/slog/sloggers/slogsqlite"
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.opencensus.io/trace"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/sloggers/slogbatch"
+)
+
+// Options represents the options for the sink returned by Sink.
+type Options struct {
+	// Table defaults to logs.
+	Table string
+
+	// NoWAL keeps the journal mode of the database instead of
+	// enabling write-ahead logging, which lets other processes
+	// read the logs while entries are written.
+	NoWAL bool
+
+	// Batch configures the batching of entries. Every batch
+	// is inserted in a single transaction.
+	// See slogbatch.Options.
+	Batch *slogbatch.Options
+}
+
+var tableRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Sink creates a slog.Sink that inserts batches of entries into db.
+//
+// The returned sink implements io.Closer. Close inserts the
+// remaining entries. The database is not closed.
+func Sink(db *sql.DB, opts *Options) (slog.Sink, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	table := opts.Table
+	if table == "" {
+		table = "logs"
+	}
+	if !tableRegexp.MatchString(table) {
+		return nil, xerrors.Errorf("invalid table name %q", table)
+	}
+
+	ctx := context.Background()
+	if !opts.NoWAL {
+		_, err := db.ExecContext(ctx, "PRAGMA journal_mode=WAL")
+		if err != nil {
+			return nil, xerrors.Errorf("failed to enable WAL: %w", err)
+		}
+	}
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+table+` (
+	id INTEGER PRIMARY KEY,
+	ts TEXT NOT NULL,
+	level TEXT NOT NULL,
+	component TEXT NOT NULL,
+	msg TEXT NOT NULL,
+	caller TEXT NOT NULL,
+	func TEXT NOT NULL,
+	trace TEXT,
+	span TEXT,
+	fields TEXT NOT NULL
+)`)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create table: %w", err)
+	}
+	_, err = db.ExecContext(ctx, `CREATE INDEX IF NOT EXISTS `+table+`_ts ON `+table+` (ts)`)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create index: %w", err)
+	}
+
+	s := &sqliteSink{
+		db:     db,
+		insert: `INSERT INTO ` + table + ` (ts, level, component, msg, caller, func, trace, span, fields) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+	}
+	return slogbatch.Sink(s, opts.Batch), nil
+}
+
+// timeLayout is RFC 3339 with a fixed number of
+// fractional digits so that times sort as text.
+const timeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+type sqliteSink struct {
+	db     *sql.DB
+	insert string
+}
+
+func (s *sqliteSink) LogEntry(ctx context.Context, ent slog.SinkEntry) {
+	s.LogEntries(ctx, []slog.SinkEntry{ent})
+}
+
+func (s *sqliteSink) LogEntries(ctx context.Context, ents []slog.SinkEntry) {
+	err := s.insertEntries(ctx, ents)
+	if err != nil {
+		slog.ReportError(ctx, xerrors.Errorf("slogsqlite: failed to insert %v entries: %w", len(ents), err), ents...)
+	}
+}
+
+func (s *sqliteSink) insertEntries(ctx context.Context, ents []slog.SinkEntry) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return xerrors.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, s.insert)
+	if err != nil {
+		return xerrors.Errorf("failed to prepare insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, ent := range ents {
+		var traceID, spanID interface{}
+		if ent.SpanContext != (trace.SpanContext{}) {
+			traceID = ent.SpanContext.TraceID.String()
+			spanID = ent.SpanContext.SpanID.String()
+		}
+		// No error is guaranteed due to slog.Map handling errors itself.
+		fields, _ := ent.Fields.MarshalJSON()
+
+		_, err = stmt.ExecContext(ctx,
+			ent.Time.UTC().Format(timeLayout),
+			ent.Level.String(),
+			strings.Join(ent.LoggerNames, "."),
+			ent.Message,
+			ent.File+":"+strconv.Itoa(ent.Line),
+			ent.Func,
+			traceID,
+			spanID,
+			string(fields),
+		)
+		if err != nil {
+			return xerrors.Errorf("failed to insert entry: %w", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return xerrors.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
+
+func (s *sqliteSink) Sync() {}
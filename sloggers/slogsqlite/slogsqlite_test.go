@@ -0,0 +1,158 @@
+package slogsqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opencensus.io/trace"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+	"cdr.dev/slog/sloggers/slogsqlite"
+)
+
+var bg = context.Background()
+
+var kt = time.Date(2000, time.February, 5, 4, 4, 4, 123456789, time.UTC)
+
+func TestSink(t *testing.T) {
+	t.Parallel()
+
+	d := &fakeDriver{}
+	db := sql.OpenDB(d)
+	defer db.Close()
+
+	s, err := slogsqlite.Sink(db, nil)
+	assert.Success(t, "sink", err)
+
+	s.LogEntry(bg, slog.SinkEntry{
+		Time:        kt,
+		Level:       slog.LevelInfo,
+		LoggerNames: []string{"comp", "db"},
+		Message:     "hi",
+		File:        "main.go",
+		Line:        62,
+		Func:        "main.main",
+		SpanContext: trace.SpanContext{TraceID: trace.TraceID{1}, SpanID: trace.SpanID{2}},
+		Fields:      slog.M(slog.F("id", 1)),
+	})
+	s.LogEntry(bg, slog.SinkEntry{Time: kt.Add(-time.Millisecond), Message: "two"})
+	err = s.(io.Closer).Close()
+	assert.Success(t, "close", err)
+
+	assert.Equal(t, "statements", []string{
+		"PRAGMA journal_mode=WAL",
+		"CREATE TABLE IF NOT EXISTS logs",
+		"CREATE INDEX IF NOT EXISTS logs_ts ON logs (ts)",
+		"BEGIN",
+		"INSERT INTO logs (ts, level, component, msg, caller, func, trace, span, fields) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		"INSERT INTO logs (ts, level, component, msg, caller, func, trace, span, fields) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		"COMMIT",
+	}, d.statements)
+	assert.Equal(t, "args", [][]driver.Value{
+		{"2000-02-05T04:04:04.123456789Z", "INFO", "comp.db", "hi", "main.go:62", "main.main",
+			"01000000000000000000000000000000", "0200000000000000", `{"id":1}`},
+		{"2000-02-05T04:04:04.122456789Z", "DEBUG", "", "two", ":0", "", nil, nil, "{}"},
+	}, d.args)
+}
+
+func TestSink_invalidTable(t *testing.T) {
+	t.Parallel()
+
+	db := sql.OpenDB(&fakeDriver{})
+	defer db.Close()
+
+	_, err := slogsqlite.Sink(db, &slogsqlite.Options{Table: "logs; DROP TABLE users"})
+	assert.Error(t, "sink", err)
+}
+
+// fakeDriver records the statements executed and
+// the arguments of the inserts.
+type fakeDriver struct {
+	mu         sync.Mutex
+	statements []string
+	args       [][]driver.Value
+}
+
+func (d *fakeDriver) Connect(context.Context) (driver.Conn, error) {
+	return fakeConn{d}, nil
+}
+
+func (d *fakeDriver) Driver() driver.Driver {
+	return nil
+}
+
+func (d *fakeDriver) record(query string, args []driver.Value) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Only the first line of CREATE TABLE is recorded.
+	for i, c := range query {
+		if c == '(' && query[:i] == "CREATE TABLE IF NOT EXISTS logs " {
+			query = query[:i-1]
+			break
+		}
+	}
+	d.statements = append(d.statements, query)
+	if len(args) > 0 {
+		d.args = append(d.args, args)
+	}
+}
+
+type fakeConn struct {
+	d *fakeDriver
+}
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return fakeStmt{c.d, query}, nil
+}
+
+func (c fakeConn) Close() error {
+	return nil
+}
+
+func (c fakeConn) Begin() (driver.Tx, error) {
+	c.d.record("BEGIN", nil)
+	return fakeTx{c.d}, nil
+}
+
+type fakeTx struct {
+	d *fakeDriver
+}
+
+func (tx fakeTx) Commit() error {
+	tx.d.record("COMMIT", nil)
+	return nil
+}
+
+func (tx fakeTx) Rollback() error {
+	tx.d.record("ROLLBACK", nil)
+	return nil
+}
+
+type fakeStmt struct {
+	d     *fakeDriver
+	query string
+}
+
+func (s fakeStmt) Close() error {
+	return nil
+}
+
+func (s fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.record(s.query, args)
+	return driver.RowsAffected(1), nil
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, io.EOF
+}
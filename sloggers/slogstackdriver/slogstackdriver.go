@@ -67,7 +67,10 @@ func (s stackdriverSink) LogEntry(ctx context.Context, ent slog.SinkEntry) {
 	buf, _ := json.Marshal(e)
 
 	buf = append(buf, '\n')
-	s.w.Write("slogstackdriver", buf)
+	err := s.w.Write(ctx, "slogstackdriver", buf)
+	if err != nil {
+		slog.ReportError(ctx, err, ent)
+	}
 }
 
 func (s stackdriverSink) Sync() {
@@ -75,14 +78,14 @@ func (s stackdriverSink) Sync() {
 }
 
 func sev(level slog.Level) logpbtype.LogSeverity {
-	switch level {
-	case slog.LevelDebug:
+	switch {
+	case level < slog.LevelInfo:
 		return logpbtype.LogSeverity_DEBUG
-	case slog.LevelInfo:
+	case level < slog.LevelWarn:
 		return logpbtype.LogSeverity_INFO
-	case slog.LevelWarn:
+	case level < slog.LevelError:
 		return logpbtype.LogSeverity_WARNING
-	case slog.LevelError:
+	case level < slog.LevelCritical:
 		return logpbtype.LogSeverity_ERROR
 	default:
 		return logpbtype.LogSeverity_CRITICAL
@@ -0,0 +1,412 @@
+// Package slogsyslog contains the slogger that writes
+// RFC 5424 messages to syslog.
+//
+// Format
+//
+//	<164>1 2019-09-10T20:19:07.159852Z myhost myapp 1234 comp.subcomp [slog@32473 caller="main.go:62" func="main.run" my_field="field value"] hi
+//
+// See https://tools.ietf.org/html/rfc5424
+package slogsyslog // import "cdr.dev/slog/sloggers/slogsyslog"
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opencensus.io/trace"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/entryjson"
+	"cdr.dev/slog/internal/netqueue"
+	"cdr.dev/slog/internal/severity"
+)
+
+// Facility is a syslog facility.
+type Facility int
+
+// The facilities of RFC 5424.
+const (
+	FacilityKern Facility = iota
+	FacilityUser
+	FacilityMail
+	FacilityDaemon
+	FacilityAuth
+	FacilitySyslog
+	FacilityLPR
+	FacilityNews
+	FacilityUUCP
+	FacilityCron
+	FacilityAuthPriv
+	FacilityFTP
+	FacilityLocal0 Facility = iota + 4
+	FacilityLocal1
+	FacilityLocal2
+	FacilityLocal3
+	FacilityLocal4
+	FacilityLocal5
+	FacilityLocal6
+	FacilityLocal7
+)
+
+// Options represents the options for the sink returned by Sink.
+type Options struct {
+	// Network is one of "unixgram", "unix", "udp", "tcp" or "tls".
+	//
+	// Defaults to the local syslog socket.
+	Network string
+
+	// Addr is the address of the syslog server.
+	Addr string
+
+	// TLSConfig is used when Network is "tls". The certificate of
+	// the server is verified against the system roots for the host
+	// of Addr unless it configures otherwise, e.g. with RootCAs for
+	// the CA bundle of a hosted provider.
+	TLSConfig *tls.Config
+
+	// Facility defaults to FacilityUser.
+	// FacilityKern is reserved for the kernel.
+	Facility Facility
+
+	// AppName defaults to the name of the executable.
+	AppName string
+
+	// Hostname defaults to os.Hostname.
+	Hostname string
+
+	// SDID is the ID of the structured data element
+	// the fields are encoded into.
+	//
+	// Defaults to slog@32473.
+	SDID string
+
+	// DialTimeout defaults to 5 seconds.
+	DialTimeout time.Duration
+
+	// BufferSize enables queueing with a Network other than the
+	// local syslog socket. Messages are then written from a
+	// background goroutine and up to BufferSize messages are
+	// queued while the server is unreachable. The oldest messages
+	// are dropped once the queue is full.
+	BufferSize int
+
+	// MinBackoff is the delay before the first reconnect when
+	// queueing. It doubles for every following reconnect up to
+	// MaxBackoff.
+	//
+	// Defaults to 100ms.
+	MinBackoff time.Duration
+
+	// MaxBackoff defaults to 30 seconds.
+	MaxBackoff time.Duration
+
+	// WriteTimeout is the maximum time a queued write may take
+	// before the sink reconnects.
+	//
+	// Defaults to 10 seconds.
+	WriteTimeout time.Duration
+}
+
+// Sink creates a slog.Sink that writes to syslog.
+//
+// Levels are mapped to severities: debug (7) for LevelDebug,
+// informational (6) for LevelInfo, warning (4) for LevelWarn,
+// error (3) for LevelError, critical (2) for LevelCritical and
+// emergency (0) for LevelFatal. The logger names are the MSGID
+// and the caller, trace and fields are encoded as structured data
+// with nested fields flattened into dotted names.
+//
+// Messages are written one per datagram over unixgram and udp and
+// with octet counting framing over unix, tcp and tls. If a write
+// fails, the sink reconnects and retries once.
+//
+// With BufferSize, the sink does not fail if the server is unreachable
+// and reconnects with exponential backoff instead which suits hosted
+// providers like Papertrail and Sumo Logic:
+//
+//	s, err := slogsyslog.Sink(&slogsyslog.Options{
+//		Network:    "tls",
+//		Addr:       "logs.papertrailapp.com:12345",
+//		BufferSize: 10000,
+//	})
+//
+// Sync then waits until the queued messages have been written or
+// the sink is disconnected.
+//
+// The returned sink implements io.Closer. Close writes the queued
+// messages if the sink is connected.
+func Sink(opts *Options) (slog.Sink, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	s := &syslogSink{
+		network:     opts.Network,
+		addr:        opts.Addr,
+		tlsConfig:   opts.TLSConfig,
+		facility:    opts.Facility,
+		appName:     opts.AppName,
+		hostname:    opts.Hostname,
+		sdID:        opts.SDID,
+		dialTimeout: opts.DialTimeout,
+		procID:      strconv.Itoa(os.Getpid()),
+	}
+	if s.facility == FacilityKern {
+		s.facility = FacilityUser
+	}
+	if s.appName == "" {
+		s.appName = filepath.Base(os.Args[0])
+	}
+	if s.hostname == "" {
+		s.hostname, _ = os.Hostname()
+	}
+	if s.sdID == "" {
+		s.sdID = "slog@32473"
+	}
+	if s.dialTimeout <= 0 {
+		s.dialTimeout = 5 * time.Second
+	}
+
+	if s.network != "" {
+		s.stream = s.network == "tcp" || s.network == "unix" || s.network == "tls"
+		if opts.BufferSize > 0 {
+			s.queue = netqueue.New(s.dial, netqueue.Options{
+				Name:         "slogsyslog",
+				BufferSize:   opts.BufferSize,
+				MinBackoff:   opts.MinBackoff,
+				MaxBackoff:   opts.MaxBackoff,
+				WriteTimeout: opts.WriteTimeout,
+			})
+			return s, nil
+		}
+	}
+
+	err := s.connect()
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+type syslogSink struct {
+	network     string
+	addr        string
+	tlsConfig   *tls.Config
+	facility    Facility
+	appName     string
+	hostname    string
+	procID      string
+	sdID        string
+	dialTimeout time.Duration
+
+	// queue is set when queueing.
+	queue *netqueue.Queue
+
+	mu     sync.Mutex
+	conn   net.Conn
+	stream bool
+}
+
+// localSockets are the paths of the local syslog socket
+// on Linux, macOS and the BSDs.
+var localSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// connect must be called with mu held or before the sink is used.
+func (s *syslogSink) connect() error {
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+
+	var err error
+	switch s.network {
+	case "":
+		for _, path := range localSockets {
+			for _, network := range []string{"unixgram", "unix"} {
+				s.conn, err = net.DialTimeout(network, path, s.dialTimeout)
+				if err == nil {
+					s.stream = network == "unix"
+					return nil
+				}
+			}
+		}
+		return xerrors.Errorf("failed to connect to local syslog: %w", err)
+	}
+
+	s.conn, err = s.dial()
+	if err != nil {
+		return xerrors.Errorf("failed to connect to syslog at %v %v: %w", s.network, s.addr, err)
+	}
+	return nil
+}
+
+// dial connects to Addr over Network.
+func (s *syslogSink) dial() (net.Conn, error) {
+	if s.network == "tls" {
+		d := &net.Dialer{Timeout: s.dialTimeout}
+		c, err := tls.DialWithDialer(d, "tcp", s.addr, s.tlsConfig)
+		if err != nil {
+			// Avoid returning a nil *tls.Conn as a non nil net.Conn.
+			return nil, err
+		}
+		return c, nil
+	}
+	return net.DialTimeout(s.network, s.addr, s.dialTimeout)
+}
+
+func (s *syslogSink) LogEntry(ctx context.Context, ent slog.SinkEntry) {
+	msg := s.format(ent)
+	if s.queue != nil {
+		if !s.queue.Write(s.frame(msg)) {
+			slog.ReportError(ctx, xerrors.New("slogsyslog: sink is closed"), ent)
+		}
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.write(msg)
+	if err != nil {
+		err = s.connect()
+		if err == nil {
+			err = s.write(msg)
+		}
+	}
+	if err != nil {
+		slog.ReportError(ctx, xerrors.Errorf("slogsyslog: failed to write entry: %w", err), ent)
+	}
+}
+
+func (s *syslogSink) write(msg []byte) error {
+	if s.conn == nil {
+		return xerrors.New("not connected")
+	}
+	_, err := s.conn.Write(s.frame(msg))
+	return err
+}
+
+// frame frames msg for the connection.
+func (s *syslogSink) frame(msg []byte) []byte {
+	if s.stream {
+		// RFC 6587 octet counting.
+		msg = append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+	}
+	return msg
+}
+
+func (s *syslogSink) Sync() {
+	if s.queue != nil {
+		s.queue.Sync()
+	}
+}
+
+func (s *syslogSink) Close() error {
+	if s.queue != nil {
+		return s.queue.Close()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+func (s *syslogSink) format(ent slog.SinkEntry) []byte {
+	pri := int(s.facility)*8 + severity.Syslog(ent.Level)
+
+	msgID := "-"
+	if len(ent.LoggerNames) > 0 {
+		msgID = header(strings.Join(ent.LoggerNames, "."), 32)
+	}
+
+	b := make([]byte, 0, 256)
+	b = append(b, '<')
+	b = strconv.AppendInt(b, int64(pri), 10)
+	b = append(b, ">1 "...)
+	b = append(b, ent.Time.Format("2006-01-02T15:04:05.000000Z07:00")...)
+	b = append(b, ' ')
+	b = append(b, header(s.hostname, 255)...)
+	b = append(b, ' ')
+	b = append(b, header(s.appName, 48)...)
+	b = append(b, ' ')
+	b = append(b, header(s.procID, 128)...)
+	b = append(b, ' ')
+	b = append(b, msgID...)
+	b = append(b, ' ')
+
+	b = append(b, '[')
+	b = append(b, s.sdID...)
+	b = appendParam(b, "caller", ent.File+":"+strconv.Itoa(ent.Line))
+	if ent.Func != "" {
+		b = appendParam(b, "func", ent.Func)
+	}
+	if ent.SpanContext != (trace.SpanContext{}) {
+		b = appendParam(b, "trace", ent.SpanContext.TraceID.String())
+		b = appendParam(b, "span", ent.SpanContext.SpanID.String())
+	}
+	for _, f := range entryjson.Flatten(ent.Fields, ".") {
+		b = appendParam(b, f.Key, entryjson.String(f.Value))
+	}
+	b = append(b, ']')
+
+	if ent.Message != "" {
+		b = append(b, ' ')
+		b = append(b, ent.Message...)
+	}
+	return b
+}
+
+// header sanitizes a header field into at most max printable
+// ASCII characters or the nil value - if it is empty.
+func header(s string, max int) string {
+	s = strings.Map(func(r rune) rune {
+		if r < '!' || r > '~' {
+			return '_'
+		}
+		return r
+	}, s)
+	if len(s) > max {
+		s = s[:max]
+	}
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// appendParam appends a SD-PARAM.
+func appendParam(b []byte, name, val string) []byte {
+	name = strings.Map(func(r rune) rune {
+		if r < '!' || r > '~' || r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, name)
+	if len(name) > 32 {
+		name = name[:32]
+	}
+
+	b = append(b, ' ')
+	b = append(b, name...)
+	b = append(b, '=', '"')
+	for _, r := range val {
+		switch r {
+		case '"', '\\', ']':
+			b = append(b, '\\')
+		}
+		b = append(b, string(r)...)
+	}
+	return append(b, '"')
+}
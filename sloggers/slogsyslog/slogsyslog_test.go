@@ -0,0 +1,212 @@
+package slogsyslog_test
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+	"cdr.dev/slog/sloggers/slogsyslog"
+)
+
+var bg = context.Background()
+
+var kt = time.Date(2000, time.February, 5, 4, 4, 4, 123456000, time.UTC)
+
+func TestUDP(t *testing.T) {
+	t.Parallel()
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Success(t, "listen", err)
+	defer pc.Close()
+
+	s, err := slogsyslog.Sink(&slogsyslog.Options{
+		Network:  "udp",
+		Addr:     pc.LocalAddr().String(),
+		Facility: slogsyslog.FacilityLocal4,
+		AppName:  "myapp",
+		Hostname: "myhost",
+	})
+	assert.Success(t, "sink", err)
+	defer s.(interface{ Close() error }).Close()
+
+	s.LogEntry(bg, slog.SinkEntry{
+		Time:        kt,
+		Level:       slog.LevelWarn,
+		LoggerNames: []string{"comp", "db"},
+		Message:     "hi",
+		File:        "main.go",
+		Line:        62,
+		Func:        "main.run",
+		Fields: slog.M(
+			slog.F("my field", `a "b" ]`),
+			slog.F("req", slog.M(slog.F("id", 1))),
+		),
+	})
+
+	b := make([]byte, 1024)
+	n, _, err := pc.ReadFrom(b)
+	assert.Success(t, "read", err)
+	assert.Equal(t, "message", `<164>1 2000-02-05T04:04:04.123456Z myhost myapp `+strconv.Itoa(os.Getpid())+
+		` comp.db [slog@32473 caller="main.go:62" func="main.run" my_field="a \"b\" \]" req.id="1"] hi`, string(b[:n]))
+}
+
+func TestTCPReconnect(t *testing.T) {
+	t.Parallel()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Success(t, "listen", err)
+	defer ln.Close()
+
+	msgs := make(chan string)
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			r := bufio.NewReader(c)
+			n, err := r.ReadString(' ')
+			if err != nil {
+				c.Close()
+				continue
+			}
+			length, _ := strconv.Atoi(strings.TrimSpace(n))
+			msg := make([]byte, length)
+			_, err = io.ReadFull(r, msg)
+			c.Close()
+			if err == nil {
+				msgs <- string(msg)
+			}
+		}
+	}()
+
+	s, err := slogsyslog.Sink(&slogsyslog.Options{
+		Network:  "tcp",
+		Addr:     ln.Addr().String(),
+		AppName:  "myapp",
+		Hostname: "myhost",
+	})
+	assert.Success(t, "sink", err)
+	defer s.(interface{ Close() error }).Close()
+
+	s.LogEntry(bg, slog.SinkEntry{Time: kt, Level: slog.LevelDebug, Message: "a"})
+	assert.True(t, "first", strings.HasPrefix(<-msgs, "<15>1 "))
+
+	// The server closed the connection so the sink has to reconnect.
+	// The first write may succeed locally before the reset is noticed.
+	for i := 0; ; i++ {
+		s.LogEntry(bg, slog.SinkEntry{Time: kt, Level: slog.LevelError, Message: "b"})
+		select {
+		case msg := <-msgs:
+			assert.True(t, "reconnected", strings.HasPrefix(msg, "<11>1 "))
+			assert.True(t, "message", strings.HasSuffix(msg, "] b"))
+			return
+		case <-time.After(100 * time.Millisecond):
+			if i > 10 {
+				t.Fatal("never reconnected")
+			}
+		}
+	}
+}
+
+func TestTLSQueue(t *testing.T) {
+	t.Parallel()
+
+	cert, pool := selfSigned(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Success(t, "listen", err)
+	addr := ln.Addr().String()
+	ln.Close()
+
+	s, err := slogsyslog.Sink(&slogsyslog.Options{
+		Network:    "tls",
+		Addr:       addr,
+		TLSConfig:  &tls.Config{RootCAs: pool},
+		AppName:    "myapp",
+		Hostname:   "myhost",
+		BufferSize: 10,
+		MinBackoff: 10 * time.Millisecond,
+		MaxBackoff: 10 * time.Millisecond,
+	})
+	assert.Success(t, "sink", err)
+	defer s.(io.Closer).Close()
+
+	// The messages are queued while the server is down.
+	s.LogEntry(bg, slog.SinkEntry{Time: kt, Level: slog.LevelInfo, Message: "a"})
+	s.Sync()
+	s.LogEntry(bg, slog.SinkEntry{Time: kt, Level: slog.LevelInfo, Message: "b"})
+
+	ln, err = tls.Listen("tcp", addr, &tls.Config{Certificates: []tls.Certificate{cert}})
+	assert.Success(t, "listen", err)
+	defer ln.Close()
+
+	c, err := ln.Accept()
+	assert.Success(t, "accept", err)
+	r := bufio.NewReader(c)
+	for _, msg := range []string{"a", "b"} {
+		n, err := r.ReadString(' ')
+		assert.Success(t, "read length", err)
+		length, _ := strconv.Atoi(strings.TrimSpace(n))
+		b := make([]byte, length)
+		_, err = io.ReadFull(r, b)
+		assert.Success(t, "read message", err)
+		assert.True(t, "message", strings.HasSuffix(string(b), "] "+msg))
+	}
+	c.Close()
+
+	// The certificate is verified against the system roots by default.
+	go func() {
+		c, err := ln.Accept()
+		if err == nil {
+			c.(*tls.Conn).Handshake()
+			c.Close()
+		}
+	}()
+	_, err = slogsyslog.Sink(&slogsyslog.Options{
+		Network: "tls",
+		Addr:    addr,
+	})
+	assert.Error(t, "sink", err)
+}
+
+func selfSigned(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Success(t, "generate key", err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "slogsyslog"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	assert.Success(t, "create certificate", err)
+	leaf, err := x509.ParseCertificate(der)
+	assert.Success(t, "parse certificate", err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pool
+}
@@ -51,16 +51,16 @@ func (ts testSink) LogEntry(ctx context.Context, ent slog.SinkEntry) {
 	// The testing package logs to stdout and not stderr.
 	s := entryhuman.Fmt(os.Stdout, ent)
 
-	switch ent.Level {
-	case slog.LevelDebug, slog.LevelInfo, slog.LevelWarn:
+	switch {
+	case ent.Level < slog.LevelError:
 		ts.tb.Log(s)
-	case slog.LevelError, slog.LevelCritical:
+	case ent.Level < slog.LevelFatal:
 		if ts.opts.IgnoreErrors {
 			ts.tb.Log(s)
 		} else {
 			ts.tb.Error(s)
 		}
-	case slog.LevelFatal:
+	default:
 		ts.tb.Fatal(s)
 	}
 }
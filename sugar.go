@@ -0,0 +1,145 @@
+package slog
+
+import (
+	"context"
+	"fmt"
+)
+
+// SugaredLogger wraps Logger with a printf style API and loosely
+// typed fields.
+//
+// It eases migrations from the stdlib log package and logrus but
+// Logger should be preferred as fields are statically checked.
+type SugaredLogger struct {
+	l Logger
+}
+
+// Sugar returns a SugaredLogger that logs to l.
+func (l Logger) Sugar() SugaredLogger {
+	return SugaredLogger{l: l}
+}
+
+// Desugar returns the underlying Logger.
+func (s SugaredLogger) Desugar() Logger {
+	return s.l
+}
+
+// With returns a SugaredLogger that prepends the given key value
+// pairs on every logged entry.
+func (s SugaredLogger) With(keysAndValues ...interface{}) SugaredLogger {
+	s.l = s.l.With(sweeten(keysAndValues)...)
+	return s
+}
+
+// Debugf formats the msg with fmt.Sprintf and logs it at LevelDebug.
+func (s SugaredLogger) Debugf(ctx context.Context, format string, v ...interface{}) {
+	l := s.l
+	l.skip++
+	l.Debug(ctx, fmt.Sprintf(format, v...))
+}
+
+// Infof formats the msg with fmt.Sprintf and logs it at LevelInfo.
+func (s SugaredLogger) Infof(ctx context.Context, format string, v ...interface{}) {
+	l := s.l
+	l.skip++
+	l.Info(ctx, fmt.Sprintf(format, v...))
+}
+
+// Warnf formats the msg with fmt.Sprintf and logs it at LevelWarn.
+func (s SugaredLogger) Warnf(ctx context.Context, format string, v ...interface{}) {
+	l := s.l
+	l.skip++
+	l.Warn(ctx, fmt.Sprintf(format, v...))
+}
+
+// Errorf formats the msg with fmt.Sprintf and logs it at LevelError.
+func (s SugaredLogger) Errorf(ctx context.Context, format string, v ...interface{}) {
+	l := s.l
+	l.skip++
+	l.Error(ctx, fmt.Sprintf(format, v...))
+}
+
+// Criticalf formats the msg with fmt.Sprintf and logs it at LevelCritical.
+func (s SugaredLogger) Criticalf(ctx context.Context, format string, v ...interface{}) {
+	l := s.l
+	l.skip++
+	l.Critical(ctx, fmt.Sprintf(format, v...))
+}
+
+// Fatalf formats the msg with fmt.Sprintf and logs it at LevelFatal.
+func (s SugaredLogger) Fatalf(ctx context.Context, format string, v ...interface{}) {
+	l := s.l
+	l.skip++
+	l.Fatal(ctx, fmt.Sprintf(format, v...))
+}
+
+// Debugw logs the msg and key value pairs at LevelDebug.
+func (s SugaredLogger) Debugw(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l := s.l
+	l.skip++
+	l.Debug(ctx, msg, sweeten(keysAndValues)...)
+}
+
+// Infow logs the msg and key value pairs at LevelInfo.
+func (s SugaredLogger) Infow(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l := s.l
+	l.skip++
+	l.Info(ctx, msg, sweeten(keysAndValues)...)
+}
+
+// Warnw logs the msg and key value pairs at LevelWarn.
+func (s SugaredLogger) Warnw(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l := s.l
+	l.skip++
+	l.Warn(ctx, msg, sweeten(keysAndValues)...)
+}
+
+// Errorw logs the msg and key value pairs at LevelError.
+func (s SugaredLogger) Errorw(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l := s.l
+	l.skip++
+	l.Error(ctx, msg, sweeten(keysAndValues)...)
+}
+
+// Criticalw logs the msg and key value pairs at LevelCritical.
+func (s SugaredLogger) Criticalw(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l := s.l
+	l.skip++
+	l.Critical(ctx, msg, sweeten(keysAndValues)...)
+}
+
+// Fatalw logs the msg and key value pairs at LevelFatal.
+func (s SugaredLogger) Fatalw(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l := s.l
+	l.skip++
+	l.Fatal(ctx, msg, sweeten(keysAndValues)...)
+}
+
+// sweeten converts alternating keys and values into fields.
+//
+// A Field is used as is. A key without a value is logged
+// under the "!BADKEY" key.
+func sweeten(keysAndValues []interface{}) Map {
+	m := make(Map, 0, len(keysAndValues)/2)
+	for i := 0; i < len(keysAndValues); i++ {
+		switch k := keysAndValues[i].(type) {
+		case Field:
+			m = append(m, k)
+			continue
+		case string:
+			if i+1 < len(keysAndValues) {
+				m = append(m, F(k, keysAndValues[i+1]))
+				i++
+				continue
+			}
+		default:
+			if i+1 < len(keysAndValues) {
+				m = append(m, F(fmt.Sprint(k), keysAndValues[i+1]))
+				i++
+				continue
+			}
+		}
+		m = append(m, F("!BADKEY", keysAndValues[i]))
+	}
+	return m
+}
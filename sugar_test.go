@@ -0,0 +1,44 @@
+package slog_test
+
+import (
+	"runtime"
+	"testing"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+)
+
+var _, sugarTestFile, _, _ = runtime.Caller(0)
+
+func TestSugaredLogger(t *testing.T) {
+	t.Parallel()
+
+	s := &fakeSink{}
+	l := slog.Make(s).Leveled(slog.LevelDebug)
+	exits := 0
+	l.SetExit(func(int) {
+		exits++
+	})
+	sl := l.Sugar().With("with", 1)
+
+	sl.Infof(bg, "hello %v", "world")
+	sl.Errorw(bg, "msg", "a", 1, slog.F("b", 2), 3, 4, "dangling")
+	sl.Debugf(bg, "")
+	sl.Warnw(bg, "")
+	sl.Criticalf(bg, "")
+	sl.Fatalw(bg, "")
+
+	assert.Len(t, "entries", 6, s.entries)
+	assert.Equal(t, "msg", "hello world", s.entries[0].Message)
+	assert.Equal(t, "file", sugarTestFile, s.entries[0].File)
+	assert.Equal(t, "line", 24, s.entries[0].Line)
+	assert.Equal(t, "fields", slog.M(
+		slog.F("with", 1),
+		slog.F("a", 1),
+		slog.F("b", 2),
+		slog.F("3", 4),
+		slog.F("!BADKEY", "dangling"),
+	), s.entries[1].Fields)
+	assert.Equal(t, "syncs", 3, s.syncs)
+	assert.Equal(t, "exits", 1, exits)
+}
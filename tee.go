@@ -0,0 +1,31 @@
+package slog
+
+import (
+	"context"
+)
+
+// Tee returns a Sink that writes every entry to all of the given sinks.
+//
+// Sync and Close are called on every sink as well. If more than
+// one sink fails to close, Close returns a CloseError.
+func Tee(sinks ...Sink) Sink {
+	return teeSink(append([]Sink(nil), sinks...))
+}
+
+type teeSink []Sink
+
+func (s teeSink) LogEntry(ctx context.Context, e SinkEntry) {
+	for _, s := range s {
+		s.LogEntry(ctx, e)
+	}
+}
+
+func (s teeSink) Sync() {
+	for _, s := range s {
+		s.Sync()
+	}
+}
+
+func (s teeSink) Close() error {
+	return closeSinks(s)
+}
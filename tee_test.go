@@ -0,0 +1,42 @@
+package slog_test
+
+import (
+	"io"
+	"testing"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+)
+
+func TestTee(t *testing.T) {
+	t.Parallel()
+
+	s1 := &fakeSink{}
+	s2 := &fakeSink{}
+	l := slog.Make(slog.Tee(s1, s2))
+
+	l.Info(bg, "meow")
+	l.Error(bg, "bar")
+
+	assert.Len(t, "entries", 2, s1.entries)
+	assert.Equal(t, "entries", s1.entries, s2.entries)
+	assert.Equal(t, "syncs", 1, s1.syncs)
+	assert.Equal(t, "syncs", 1, s2.syncs)
+}
+
+func TestTee_Close(t *testing.T) {
+	t.Parallel()
+
+	s1 := &closeSink{err: io.ErrClosedPipe}
+	s2 := &closeSink{}
+	s3 := &closeSink{err: io.ErrUnexpectedEOF}
+	err := slog.Make(slog.Tee(s1, s2, s3)).Close()
+
+	errs, ok := err.(slog.CloseError)
+	assert.True(t, "close error", ok)
+	assert.Len(t, "errs", 2, errs)
+	assert.True(t, "first err", errs[0] == io.ErrClosedPipe)
+	assert.True(t, "second err", errs[1] == io.ErrUnexpectedEOF)
+	assert.Equal(t, "msg", "failed to close 2 sinks: io: read/write on closed pipe; unexpected EOF", err.Error())
+	assert.Equal(t, "closes", 1, s3.closes)
+}
@@ -0,0 +1,75 @@
+package slog
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// WithTimeout returns a Sink that gives s at most d to log
+// an entry or sync so that a hung backend can't stall callers.
+//
+// When d elapses, the context passed to s is canceled and the
+// entry is reported with ReportError unless s already reported
+// it so that the fallback Sink receives it only once. s keeps
+// running in the background and is expected to give up once it
+// notices the canceled context. The built in sinks stop waiting for
+// concurrent writes to complete but a write already in
+// progress cannot be interrupted.
+//
+// Every entry and sync runs on its own goroutine. A goroutine
+// stays alive until s returns, so a Sink that never returns
+// leaks one goroutine per timed out call.
+func WithTimeout(s Sink, d time.Duration) Sink {
+	return timeoutSink{
+		s: s,
+		d: d,
+	}
+}
+
+type timeoutSink struct {
+	s Sink
+	d time.Duration
+}
+
+func (s timeoutSink) LogEntry(ctx context.Context, e SinkEntry) {
+	o := &reportOnce{}
+	sctx, cancel := context.WithTimeout(context.WithValue(ctx, reportOnceKey{}, o), s.d)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.s.LogEntry(sctx, e)
+	}()
+
+	select {
+	case <-done:
+	case <-sctx.Done():
+		if o.claim() {
+			ReportError(ctx, xerrors.Errorf("failed to log entry within %v: %w", s.d, sctx.Err()), e)
+		}
+	}
+}
+
+func (s timeoutSink) Sync() {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.s.Sync()
+	}()
+
+	t := time.NewTimer(s.d)
+	defer t.Stop()
+
+	select {
+	case <-done:
+	case <-t.C:
+		ReportError(context.Background(), xerrors.Errorf("failed to sync within %v", s.d))
+	}
+}
+
+func (s timeoutSink) Close() error {
+	return closeSinks([]Sink{s.s})
+}
@@ -0,0 +1,72 @@
+package slog_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+	"cdr.dev/slog/sloggers/slogjson"
+)
+
+type blockWriter chan struct{}
+
+func (w blockWriter) Write(p []byte) (int, error) {
+	<-w
+	return len(p), nil
+}
+
+type chanSink chan slog.SinkEntry
+
+func (s chanSink) LogEntry(_ context.Context, e slog.SinkEntry) {
+	s <- e
+}
+
+func (s chanSink) Sync() {}
+
+// This test cannot be parallel as it modifies global state.
+func TestWithTimeout(t *testing.T) {
+	t.Cleanup(func() {
+		slog.OnError(nil)
+		slog.SetFallback(nil)
+	})
+
+	errs := make(chan error, 8)
+	slog.OnError(func(err error) {
+		errs <- err
+	})
+	fallback := make(chanSink, 8)
+	slog.SetFallback(fallback)
+
+	w := make(blockWriter)
+	defer close(w)
+	l := slog.Make(slog.WithTimeout(slogjson.Sink(w), time.Millisecond*10))
+
+	// The first entry hangs in Write and the second
+	// gives up waiting for it once the timeout elapses.
+	// Both time out and are reported once even though
+	// slogjson also notices the second timing out.
+	l.Info(bg, "first")
+	l.Info(bg, "second")
+
+	for _, msg := range []string{"first", "second"} {
+		err := <-errs
+		assert.True(t, "deadline exceeded", xerrors.Is(err, context.DeadlineExceeded))
+		assert.Equal(t, "fallback entry", msg, (<-fallback).Message)
+	}
+	select {
+	case err := <-errs:
+		t.Fatalf("unexpected error: %v", err)
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	s := &fakeSink{}
+	l = slog.Make(slog.WithTimeout(s, time.Minute))
+	l.Info(bg, "fast")
+	l.Sync()
+	assert.Len(t, "entries", 1, s.entries)
+	assert.Equal(t, "syncs", 1, s.syncs)
+}
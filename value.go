@@ -0,0 +1,65 @@
+package slog
+
+import (
+	"sync"
+)
+
+// Value is implemented by types that control their own
+// representation in logs.
+//
+// SlogValue is called when the value is encoded and its result
+// is encoded in place of the value.
+type Value interface {
+	SlogValue() interface{}
+}
+
+// LazyValue is a field value that is computed only
+// if it is encoded by a sink.
+//
+// Use it to avoid the cost of expensive field values
+// in entries that are filtered out.
+type LazyValue struct {
+	once sync.Once
+	fn   func() interface{}
+	v    interface{}
+}
+
+// Lazy returns a LazyValue that calls fn at most once
+// to compute the value when it is first needed.
+func Lazy(fn func() interface{}) *LazyValue {
+	return &LazyValue{
+		fn: fn,
+	}
+}
+
+var _ Value = &LazyValue{}
+
+// SlogValue implements Value.
+//
+// It returns the computed value.
+func (v *LazyValue) SlogValue() interface{} {
+	v.once.Do(func() {
+		v.v = v.fn()
+	})
+	return v.v
+}
+
+// FlatError returns a Value that encodes err as the string
+// returned by its Error method instead of as the chain of
+// wrapped errors.
+//
+//	log.Error(ctx, "failed to connect", slog.F("error", slog.FlatError(err)))
+func FlatError(err error) Value {
+	return flatError{err}
+}
+
+type flatError struct {
+	err error
+}
+
+func (e flatError) SlogValue() interface{} {
+	if e.err == nil {
+		return nil
+	}
+	return e.err.Error()
+}
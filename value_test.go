@@ -0,0 +1,57 @@
+package slog_test
+
+import (
+	"testing"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/internal/assert"
+)
+
+func TestLazy(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	v := slog.Lazy(func() interface{} {
+		calls++
+		return slog.M(slog.F("stats", []int{1, 2}))
+	})
+
+	s := &fakeSink{}
+	l := slog.Make(s)
+	l.Debug(bg, "filtered", slog.F("lazy", v))
+	assert.Len(t, "entries", 0, s.entries)
+	assert.Equal(t, "calls", 0, calls)
+
+	l.Info(bg, "logged", slog.F("lazy", v))
+	assert.Equal(t, "calls", 0, calls)
+
+	test := func() {
+		t.Helper()
+		act := marshalJSON(t, s.entries[0].Fields)
+		exp := indentJSON(t, `{"lazy": {"stats": [1, 2]}}`)
+		assert.Equal(t, "JSON", exp, act)
+	}
+	test()
+	test()
+	assert.Equal(t, "calls", 1, calls)
+}
+
+type user struct {
+	id       int
+	password string
+}
+
+func (u user) SlogValue() interface{} {
+	return slog.M(slog.F("id", u.id))
+}
+
+func TestValue(t *testing.T) {
+	t.Parallel()
+
+	act := marshalJSON(t, slog.M(
+		slog.F("user", user{id: 3, password: "hunter2"}),
+		slog.F("users", []user{{id: 4}}),
+	))
+	exp := indentJSON(t, `{"user": {"id": 3}, "users": [{"id": 4}]}`)
+	assert.Equal(t, "JSON", exp, act)
+}